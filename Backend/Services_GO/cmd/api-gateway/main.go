@@ -213,7 +213,33 @@ func main() {
 			})
 		}
 
+		// Calendário institucional (servido pelo chamadas-service; escrita restrita a administradores no serviço)
+		calendario := v1.Group("/calendario")
+		calendario.Use(middleware.Auth()) // Aplicar middleware JWT
+		{
+			proxyCalendario := func(c *gin.Context) {
+				if err := proxyManager.ProxyRequest("chamadas-service", c.Writer, c.Request); err != nil {
+					logger.Error("Erro no proxy para chamadas-service", err)
+				}
+			}
+			calendario.Any("", proxyCalendario)
+			calendario.Any("/*path", proxyCalendario)
+		}
+
+		// Busca unificada (servida pelo enrollment-service, que acessa alunos, responsáveis, cursos e turmas)
+		search := v1.Group("/search")
+		search.Use(middleware.Auth()) // Aplicar middleware JWT
+		{
+			search.GET("", func(c *gin.Context) {
+				if err := proxyManager.ProxyRequest("enrollment-service", c.Writer, c.Request); err != nil {
+					logger.Error("Erro no proxy para enrollment-service", err)
+				}
+			})
+		}
+
+		// Presenças (servidas pelo chamadas-service; o serviço confere se o usuário pode lançar na turma)
 		presencas := v1.Group("/presencas")
+		presencas.Use(middleware.Auth()) // Aplicar middleware JWT
 		{
 			presencas.Any("/*path", func(c *gin.Context) {
 				if err := proxyManager.ProxyRequest("chamadas-service", c.Writer, c.Request); err != nil {
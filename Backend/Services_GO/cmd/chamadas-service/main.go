@@ -10,6 +10,8 @@ import (
 	"sysocial/internal/shared/config"
 	"sysocial/internal/shared/database"
 	"sysocial/internal/shared/logger"
+	"sysocial/internal/shared/middleware"
+	"sysocial/internal/shared/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -37,17 +39,21 @@ func main() {
 	chamadasRepo := repository.NewChamadasRepository(db)
 
 	// Inicializar serviços
-	chamadasService := service.NewChamadasService(chamadasRepo, logger)
+	chamadasService := service.NewChamadasService(chamadasRepo, logger, cfg.Instituicao, cfg.Frequencia, cfg.Chamada)
 
 	// Inicializar handlers
 	chamadasHandler := handler.NewChamadasHandler(chamadasService)
 
+	// Configurar validações customizadas e mensagens em pt-BR
+	validation.RegisterGin()
+
 	// Configurar roteador
 	router := gin.Default()
 
 	// Middleware
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
+	router.Use(middleware.Identity()) // Identidade repassada pelo API Gateway
 
 	// Rotas
 	v1 := router.Group("/api/v1")
@@ -56,14 +62,37 @@ func main() {
 		chamadas := v1.Group("/chamadas")
 		{
 			chamadas.POST("/", chamadasHandler.CreateChamada)
+			chamadas.POST("/:userId/sessoes", chamadasHandler.AbrirChamada)
+			chamadas.POST("/:userId/gerar", middleware.RequireTipo(middleware.TipoAdmin, middleware.TipoUsuario), chamadasHandler.GerarChamadas)
 			chamadas.GET("/:userId/:turmaId/:anoMes", chamadasHandler.GetChamadasPorTurmaMes)
+			chamadas.GET("/:userId/export/:escopo/:id/:anoMes", middleware.RequireTipo(middleware.TipoAdmin, middleware.TipoUsuario), chamadasHandler.ExportFrequenciaMensal)
+			chamadas.GET("/:userId/pdf/:tipo/:turmaId/:anoMes", chamadasHandler.GerarPDFFrequencia)
+			chamadas.GET("/:userId/relatorios/frequencia", middleware.RequireTipo(middleware.TipoAdmin, middleware.TipoUsuario), chamadasHandler.GetRelatorioFrequencia)
+			chamadas.GET("/:userId/relatorios/risco", middleware.RequireTipo(middleware.TipoAdmin, middleware.TipoUsuario), chamadasHandler.GetRiscoEvasao)
+			chamadas.GET("/:userId/periodos/:turmaId/:anoMes", chamadasHandler.GetSituacaoPeriodo)
+			chamadas.POST("/:userId/periodos/:turmaId/:anoMes/fechar", middleware.RequireTipo(middleware.TipoAdmin, middleware.TipoUsuario), chamadasHandler.FecharPeriodo)
+			chamadas.POST("/:userId/periodos/:turmaId/:anoMes/reabrir", middleware.RequireTipo(middleware.TipoAdmin), chamadasHandler.ReabrirPeriodo)
+			chamadas.GET("/:userId/diarios/turma/:turmaId", chamadasHandler.GetDiarioTurma)
+			chamadas.GET("/:userId/diarios/chamada/:chamadaId", chamadasHandler.GetDiarioAula)
+			chamadas.POST("/:userId/diarios/chamada/:chamadaId", chamadasHandler.SalvarDiarioAula)
+			chamadas.DELETE("/:userId/diarios/chamada/:chamadaId", chamadasHandler.DeleteDiarioAula)
 			chamadas.GET("/turma/:turmaId", chamadasHandler.GetChamadasByTurmaID)
 			chamadas.PUT("/:id", chamadasHandler.UpdateChamada)
 		}
 
+		// Rotas para o Calendário Institucional (feriados, recessos, cancelamentos e aulas extras)
+		calendario := v1.Group("/calendario")
+		{
+			calendario.GET("", chamadasHandler.ListEventosCalendario)
+			calendario.POST("", middleware.RequireTipo(middleware.TipoAdmin), chamadasHandler.CreateEventoCalendario)
+			calendario.PUT("/:id", middleware.RequireTipo(middleware.TipoAdmin), chamadasHandler.UpdateEventoCalendario)
+			calendario.DELETE("/:id", middleware.RequireTipo(middleware.TipoAdmin), chamadasHandler.DeleteEventoCalendario)
+		}
+
 		// Rotas para Presenças
 		presencas := v1.Group("/presencas")
 		{
+			presencas.GET("/status", chamadasHandler.ListStatusPresenca)
 			presencas.GET("/chamada/:chamadaId", chamadasHandler.GetPresencasByChamadaID)
 			presencas.POST("/", chamadasHandler.CreatePresencas)
 			presencas.POST("/turma", chamadasHandler.UpsertPresencas)
@@ -10,6 +10,8 @@ import (
 	"sysocial/internal/shared/config"
 	"sysocial/internal/shared/database"
 	"sysocial/internal/shared/logger"
+	"sysocial/internal/shared/middleware"
+	"sysocial/internal/shared/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -42,12 +44,16 @@ func main() {
 	// Inicializar handlers
 	cursosTurmasHandler := handler.NewCursosTurmasHandler(cursosTurmasService)
 
+	// Configurar validações customizadas e mensagens em pt-BR
+	validation.RegisterGin()
+
 	// Configurar roteador
 	router := gin.Default()
 
 	// Middleware
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
+	router.Use(middleware.Identity()) // Identidade repassada pelo API Gateway
 
 	/*
 		router.Use(func(c *gin.Context) {
@@ -83,7 +89,12 @@ func main() {
 		{
 			turmas.POST("/ins", cursosTurmasHandler.CreateTurma)
 			turmas.GET("/all", cursosTurmasHandler.GetAllTurmas)
+			turmas.GET("/minhas", cursosTurmasHandler.GetMinhasTurmas)
 			turmas.GET("/:id/alunos", cursosTurmasHandler.GetAlunosByTurmaID)
+			turmas.GET("/:id/professores", cursosTurmasHandler.GetProfessoresTurma)
+			turmas.POST("/:id/professores", middleware.RequireTipo(middleware.TipoAdmin), cursosTurmasHandler.AtribuirProfessor)
+			turmas.PUT("/:id/professores/:atribuicaoId", middleware.RequireTipo(middleware.TipoAdmin), cursosTurmasHandler.UpdateAtribuicaoProfessor)
+			turmas.DELETE("/:id/professores/:atribuicaoId", middleware.RequireTipo(middleware.TipoAdmin), cursosTurmasHandler.RemoverAtribuicaoProfessor)
 			turmas.GET("/:id", cursosTurmasHandler.GetTurmaByID)
 			turmas.PUT("/:id", cursosTurmasHandler.UpdateTurma)
 			turmas.DELETE("/:id", cursosTurmasHandler.DeleteTurma)
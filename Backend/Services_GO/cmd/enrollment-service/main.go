@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
@@ -10,6 +11,8 @@ import (
 	"sysocial/internal/shared/config"
 	"sysocial/internal/shared/database"
 	"sysocial/internal/shared/logger"
+	"sysocial/internal/shared/middleware"
+	"sysocial/internal/shared/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -37,17 +40,24 @@ func main() {
 	enrollmentRepo := repository.NewEnrollmentRepository(db)
 
 	// Inicializar serviços
-	enrollmentService := service.NewEnrollmentService(enrollmentRepo, logger)
+	enrollmentService := service.NewEnrollmentService(enrollmentRepo, logger, cfg.ListaEspera, cfg.Turno)
+
+	// Lista de espera: expira ofertas vencidas e oferece vagas livres periodicamente
+	go enrollmentService.RunWaitlistSweep(context.Background())
 
 	// Inicializar handlers
 	enrollmentHandler := handler.NewEnrollmentHandler(enrollmentService)
 
+	// Configurar validações customizadas e mensagens em pt-BR
+	validation.RegisterGin()
+
 	// Configurar roteador
 	router := gin.Default()
 
 	// Middleware
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
+	router.Use(middleware.Identity()) // Identidade repassada pelo API Gateway
 
 	// Rotas
 	v1 := router.Group("/api/v1")
@@ -56,14 +66,32 @@ func main() {
 		{
 			enrollments.GET("/students", enrollmentHandler.SearchStudents)
 			enrollments.POST("/", enrollmentHandler.CreateEnrollment)
+			enrollments.POST("/import", enrollmentHandler.ImportEnrollments)
+			enrollments.GET("/import/template", enrollmentHandler.GetImportTemplate)
 			enrollments.GET("/:id", enrollmentHandler.GetEnrollment)
 			enrollments.PUT("/:id", enrollmentHandler.UpdateEnrollment)
 			enrollments.PATCH("/:id/cancel", enrollmentHandler.CancelEnrollment)
+			enrollments.PATCH("/:id/courses/:matriculaId/cancel", enrollmentHandler.CancelCourse)
+			enrollments.PATCH("/:id/courses/:matriculaId/suspend", enrollmentHandler.SuspendCourse)
+			enrollments.PATCH("/:id/courses/:matriculaId/complete", enrollmentHandler.CompleteCourse)
+			enrollments.PATCH("/:id/courses/:matriculaId/status", enrollmentHandler.ChangeCourseStatus)
+			enrollments.POST("/:id/courses/:matriculaId/transfer", enrollmentHandler.TransferCourse)
+			enrollments.GET("/:id/timeline", enrollmentHandler.GetEnrollmentTimeline)
+			enrollments.GET("/:id/waitlist", enrollmentHandler.GetStudentWaitlist)
+			enrollments.POST("/:id/waitlist", enrollmentHandler.JoinWaitlist)
+			enrollments.DELETE("/:id/waitlist/:entryId", enrollmentHandler.LeaveWaitlist)
+			enrollments.POST("/:id/waitlist/:entryId/accept", enrollmentHandler.AcceptWaitlistOffer)
+			enrollments.POST("/:id/waitlist/:entryId/decline", enrollmentHandler.DeclineWaitlistOffer)
+			enrollments.GET("/waitlist/classes/:classId", enrollmentHandler.GetClassWaitlist)
+			enrollments.GET("/waitlist/offers", enrollmentHandler.GetPendingOffers)
 			enrollments.GET("/available-courses", enrollmentHandler.GetAvailableCourses)
 			enrollments.GET("/courses", enrollmentHandler.GetAvailableCourses)
 			enrollments.GET("/check-cpf", enrollmentHandler.CheckCpf)
 			enrollments.GET("/guardian", enrollmentHandler.GetGuardian)
 		}
+
+		// Busca unificada (alunos, responsáveis, cursos e turmas)
+		v1.GET("/search", enrollmentHandler.Search)
 	}
 
 	// Health check
@@ -0,0 +1,71 @@
+// Comando de reconciliação das vagas: recalcula curso.vagas_totais e curso.vagas_restantes a partir das turmas, das
+// matrículas ATIVO e das ofertas pendentes da lista de espera e lista as turmas com mais matrículas ativas do que vagas.
+//
+// Uso (a partir de cmd/vagas-reconcile, como os serviços):
+//
+//	go run .            recalcula e grava
+//	go run . -dry-run   só mostra as divergências
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"sysocial/internal/shared/config"
+	"sysocial/internal/shared/database"
+	"sysocial/internal/shared/logger"
+	"sysocial/internal/shared/vagas"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "apenas lista as divergências, sem gravar")
+	flag.Parse()
+
+	// Carregar variáveis de ambiente
+	if err := godotenv.Load("../../config.env"); err != nil {
+		log.Printf("Aviso: Arquivo config.env não encontrado: %v", err)
+	}
+
+	logger := logger.New()
+	cfg := config.Load()
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		logger.Fatal("Erro ao conectar com o banco de dados", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	divergencias, err := vagas.ListarDivergencias(ctx, db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	for _, d := range divergencias {
+		logger.Infof("Curso %d (%s): gravado %d/%d, derivado das turmas %d/%d (restantes/totais)",
+			d.CursoID, d.Nome, d.VagasRestantes, d.VagasTotais, d.VagasRestantesDerivadas, d.VagasTotaisDerivadas)
+	}
+
+	lotadas, err := vagas.ListarTurmasLotadas(ctx, db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	for _, t := range lotadas {
+		logger.Warnf("Turma %d (%s): %d matrículas ativas para %d vagas; regularize pela secretaria",
+			t.TurmaID, t.Nome, t.Ocupadas, t.Vagas)
+	}
+
+	if *dryRun {
+		logger.Infof("Dry-run: %d cursos divergentes, %d turmas acima da capacidade", len(divergencias), len(lotadas))
+		return
+	}
+
+	atualizados, err := vagas.RecalcularTodos(ctx, db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	logger.Infof("Vagas reconciliadas: %d cursos atualizados, %d turmas acima da capacidade", atualizados, len(lotadas))
+}
@@ -6,6 +6,7 @@ import (
 	"sysocial/internal/auth/model"
 	"sysocial/internal/auth/service"
 	"sysocial/internal/shared/logger"
+	"sysocial/internal/shared/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -23,7 +24,7 @@ func NewAuthHandler(authService service.AuthService, logger logger.Logger) *Auth
 	return &AuthHandler{
 		authService: authService,
 		logger:      logger,
-		validator:   validator.New(),
+		validator:   validation.New(),
 	}
 }
 
@@ -32,17 +33,13 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	var req model.LoginRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Dados inválidos",
-		})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
 	// Validar dados
 	if err := h.validator.Struct(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Dados inválidos",
-		})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
@@ -63,17 +60,13 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	var req model.RegisterRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Dados inválidos",
-		})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
 	// Validar dados
 	if err := h.validator.Struct(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Dados inválidos",
-		})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
@@ -94,17 +87,13 @@ func (h *AuthHandler) ValidateToken(c *gin.Context) {
 	var req model.ValidateTokenRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Dados inválidos",
-		})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
 	// Validar dados
 	if err := h.validator.Struct(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Dados inválidos",
-		})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
@@ -12,7 +12,7 @@ type LoginRequest struct {
 type RegisterRequest struct {
 	Username   string `json:"username" validate:"required,min=3,max=20"`
 	Nome       string `json:"nome" validate:"required,min=2,max=50"`
-	Telefone   string `json:"telefone" validate:"omitempty,min=10,max=15"`
+	Telefone   string `json:"telefone" validate:"omitempty,telefone"`
 	Email      string `json:"email" validate:"required,email"`
 	Senha      string `json:"senha" validate:"required,min=6"`
 	Tipo       string `json:"tipo" validate:"required,oneof=A U M P R"`
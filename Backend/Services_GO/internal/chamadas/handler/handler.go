@@ -1,10 +1,16 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"sysocial/internal/chamadas/model"
 	"sysocial/internal/chamadas/service"
+	"sysocial/internal/shared/export"
+	"sysocial/internal/shared/middleware"
+	"sysocial/internal/shared/validation"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,12 +30,24 @@ func (h *ChamadasHandler) CreateChamada(c *gin.Context) {
 	var payload model.CreateChamadaPayload
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
-	id, err := h.service.CreateChamada(c.Request.Context(), payload)
+	id, err := h.service.CreateChamada(c.Request.Context(), payload, usuarioAutenticado(c))
 	if err != nil {
+		if errors.Is(err, service.ErrChamadaInvalida) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao criar chamada", "details": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrSemPermissao) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Sem permissão para lançar chamadas desta turma", "details": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrPeriodoBloqueado) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Período bloqueado para edição", "details": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar chamada", "details": err.Error()})
 		return
 	}
@@ -69,12 +87,24 @@ func (h *ChamadasHandler) UpdateChamada(c *gin.Context) {
 
 	var payload model.UpdateChamadaPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
-	err = h.service.UpdateChamada(c.Request.Context(), id, payload)
+	err = h.service.UpdateChamada(c.Request.Context(), id, payload, usuarioAutenticado(c))
 	if err != nil {
+		if errors.Is(err, service.ErrChamadaInvalida) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao atualizar chamada", "details": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrSemPermissao) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Sem permissão para lançar chamadas desta turma", "details": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrPeriodoBloqueado) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Período bloqueado para edição", "details": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar chamada", "details": err.Error()})
 		return
 	}
@@ -82,6 +112,121 @@ func (h *ChamadasHandler) UpdateChamada(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Chamada atualizada com sucesso"})
 }
 
+// AbrirChamada POST /api/v1/chamadas/:userId/sessoes
+// Abre a chamada da turma na data para quem está lançando; se já estiver aberta, devolve a existente (200)
+func (h *ChamadasHandler) AbrirChamada(c *gin.Context) {
+	var payload model.AbrirChamadaPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	chamada, err := h.service.AbrirChamada(c.Request.Context(), payload, usuarioAutenticado(c))
+	if err != nil {
+		respondAberturaChamadaError(c, "Erro ao abrir chamada", err)
+		return
+	}
+
+	status := http.StatusOK
+	if chamada.Criada {
+		status = http.StatusCreated
+	}
+	c.JSON(status, chamada)
+}
+
+// GerarChamadas POST /api/v1/chamadas/:userId/gerar
+// Abre as chamadas de todas as datas de aula da turma no período (padrão: período letivo da turma)
+func (h *ChamadasHandler) GerarChamadas(c *gin.Context) {
+	var payload model.GerarChamadasPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	result, err := h.service.GerarChamadas(c.Request.Context(), payload, usuarioAutenticado(c))
+	if err != nil {
+		respondAberturaChamadaError(c, "Erro ao gerar chamadas", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// respondAberturaChamadaError mapeia os erros de abertura de chamadas para 400/403/404, demais para 500
+func respondAberturaChamadaError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrChamadaInvalida), errors.Is(err, service.ErrPeriodoInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message, "details": err.Error()})
+	case errors.Is(err, service.ErrTurmaNaoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Turma não encontrada"})
+	case errors.Is(err, service.ErrSemPermissao):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sem permissão para lançar chamadas desta turma", "details": err.Error()})
+	case errors.Is(err, service.ErrPeriodoBloqueado):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Período bloqueado para edição", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// ========== FECHAMENTO MENSAL ==========
+
+// GetSituacaoPeriodo GET /api/v1/chamadas/:userId/periodos/:turmaId/:anoMes
+func (h *ChamadasHandler) GetSituacaoPeriodo(c *gin.Context) {
+	turmaID, err := strconv.Atoi(c.Param("turmaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da turma inválido"})
+		return
+	}
+
+	situacao, err := h.service.GetSituacaoPeriodo(c.Request.Context(), turmaID, c.Param("anoMes"))
+	if err != nil {
+		respondAberturaChamadaError(c, "Erro ao consultar período", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, situacao)
+}
+
+// FecharPeriodo POST /api/v1/chamadas/:userId/periodos/:turmaId/:anoMes/fechar
+func (h *ChamadasHandler) FecharPeriodo(c *gin.Context) {
+	turmaID, err := strconv.Atoi(c.Param("turmaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da turma inválido"})
+		return
+	}
+
+	situacao, err := h.service.FecharPeriodo(c.Request.Context(), turmaID, c.Param("anoMes"), middleware.UserID(c))
+	if err != nil {
+		respondAberturaChamadaError(c, "Erro ao fechar o mês", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Mês fechado com sucesso", "periodo": situacao})
+}
+
+// ReabrirPeriodo POST /api/v1/chamadas/:userId/periodos/:turmaId/:anoMes/reabrir (somente administradores)
+func (h *ChamadasHandler) ReabrirPeriodo(c *gin.Context) {
+	turmaID, err := strconv.Atoi(c.Param("turmaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da turma inválido"})
+		return
+	}
+
+	var payload model.ReabrirPeriodoPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	situacao, err := h.service.ReabrirPeriodo(c.Request.Context(), turmaID, c.Param("anoMes"), payload, middleware.UserID(c))
+	if err != nil {
+		respondAberturaChamadaError(c, "Erro ao reabrir o mês", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Mês reaberto com sucesso", "periodo": situacao})
+}
+
 // ========== HANDLERS PARA PRESENÇA ==========
 
 // GetPresencasByChamadaID GET /api/v1/presencas/chamada/:chamadaId
@@ -102,12 +247,24 @@ func (h *ChamadasHandler) GetPresencasByChamadaID(c *gin.Context) {
 	c.JSON(http.StatusOK, presencas)
 }
 
+// ListStatusPresenca GET /api/v1/presencas/status
+// Status aceitos no lançamento de presenças, com a categoria usada no cálculo da frequência
+func (h *ChamadasHandler) ListStatusPresenca(c *gin.Context) {
+	status, err := h.service.ListStatusPresenca(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao listar status de presença", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
 // CreatePresencas POST /api/v1/presencas
 func (h *ChamadasHandler) CreatePresencas(c *gin.Context) {
 	var payload model.CreatePresencasPayload
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
@@ -116,15 +273,21 @@ func (h *ChamadasHandler) CreatePresencas(c *gin.Context) {
 		return
 	}
 
-	err := h.service.CreatePresencas(c.Request.Context(), payload)
+	chave, ok := idempotencyKey(c)
+	if !ok {
+		return
+	}
+
+	resultado, err := h.service.CreatePresencas(c.Request.Context(), payload, chave, usuarioAutenticado(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar presenças", "details": err.Error()})
+		respondPresencasError(c, "Erro ao criar presenças", err)
 		return
 	}
 
+	markReplay(c, resultado)
 	c.JSON(http.StatusCreated, gin.H{
 		"message":  "Presenças criadas com sucesso",
-		"quantidade": len(payload.Presencas),
+		"quantidade": resultado.Quantidade,
 	})
 }
 
@@ -137,8 +300,16 @@ func (h *ChamadasHandler) DeletePresencasByChamadaID(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeletePresencasByChamadaID(c.Request.Context(), chamadaID)
+	err = h.service.DeletePresencasByChamadaID(c.Request.Context(), chamadaID, usuarioAutenticado(c))
 	if err != nil {
+		if errors.Is(err, service.ErrSemPermissao) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Sem permissão para lançar chamadas desta turma", "details": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrPeriodoBloqueado) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Período bloqueado para edição", "details": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao deletar presenças", "details": err.Error()})
 		return
 	}
@@ -151,7 +322,7 @@ func (h *ChamadasHandler) UpsertPresencas(c *gin.Context) {
 	var payload model.UpsertPresencasPayload
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
@@ -160,27 +331,74 @@ func (h *ChamadasHandler) UpsertPresencas(c *gin.Context) {
 		return
 	}
 
-	err := h.service.UpsertPresencas(c.Request.Context(), payload)
+	chave, ok := idempotencyKey(c)
+	if !ok {
+		return
+	}
+
+	resultado, err := h.service.UpsertPresencas(c.Request.Context(), payload, chave, usuarioAutenticado(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao processar presenças", "details": err.Error()})
+		respondPresencasError(c, "Erro ao processar presenças", err)
 		return
 	}
 
+	markReplay(c, resultado)
 	c.JSON(http.StatusOK, gin.H{
 		"message":   "Presenças processadas com sucesso",
-		"quantidade": len(payload.Records),
+		"quantidade": resultado.Quantidade,
 	})
 }
 
-// GetChamadasPorTurmaMes GET /api/v1/chamadas/:userId/:turmaId/:anoMes
-func (h *ChamadasHandler) GetChamadasPorTurmaMes(c *gin.Context) {
-	usuarioIDStr := c.Param("userId")
-	usuarioID, err := strconv.Atoi(usuarioIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do usuário inválido"})
-		return
+// Header de idempotência dos lançamentos de presença e o header que marca uma resposta repetida
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// idempotencyKey lê o header Idempotency-Key (opcional); responde 400 e devolve ok = false se for inválido
+func idempotencyKey(c *gin.Context) (string, bool) {
+	chave := strings.TrimSpace(c.GetHeader(HeaderIdempotencyKey))
+	if len(chave) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key inválida", "details": "a chave deve ter no máximo 100 caracteres"})
+		return "", false
 	}
+	return chave, true
+}
 
+// usuarioAutenticado monta o usuário que está lançando a partir da identidade repassada pelo API Gateway
+func usuarioAutenticado(c *gin.Context) model.Usuario {
+	return model.Usuario{ID: middleware.UserID(c), Tipo: middleware.UserTipo(c)}
+}
+
+// markReplay sinaliza no header quando o resultado veio de uma requisição anterior com a mesma chave
+func markReplay(c *gin.Context, resultado *model.ResultadoPresencas) {
+	if resultado.Repetida {
+		c.Header(HeaderIdempotentReplayed, "true")
+	}
+}
+
+// respondPresencasError mapeia status inválido e aluno sem matrícula para 400, permissão e período bloqueado para 403 e os erros de
+// idempotência para 409/422, demais para 500
+func respondPresencasError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrStatusInvalido), errors.Is(err, service.ErrAlunoSemMatricula):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message, "details": err.Error()})
+	case errors.Is(err, service.ErrSemPermissao):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sem permissão para lançar chamadas desta turma", "details": err.Error()})
+	case errors.Is(err, service.ErrPeriodoBloqueado):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Período bloqueado para edição", "details": err.Error()})
+	case errors.Is(err, service.ErrIdempotenciaEmAndamento):
+		c.JSON(http.StatusConflict, gin.H{"error": message, "details": err.Error()})
+	case errors.Is(err, service.ErrIdempotenciaConflito):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": message, "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// GetChamadasPorTurmaMes GET /api/v1/chamadas/:userId/:turmaId/:anoMes
+// Somente leitura: datas sem chamada aberta voltam com id 0 (abrir com POST /:userId/sessoes)
+func (h *ChamadasHandler) GetChamadasPorTurmaMes(c *gin.Context) {
 	turmaIDStr := c.Param("turmaId")
 	turmaID, err := strconv.Atoi(turmaIDStr)
 	if err != nil {
@@ -194,7 +412,7 @@ func (h *ChamadasHandler) GetChamadasPorTurmaMes(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.GetChamadasPorTurmaMes(c.Request.Context(), turmaID, anoMes, usuarioID)
+	result, err := h.service.GetChamadasPorTurmaMes(c.Request.Context(), turmaID, anoMes)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar chamadas", "details": err.Error()})
 		return
@@ -203,7 +421,348 @@ func (h *ChamadasHandler) GetChamadasPorTurmaMes(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// Conteúdos da exportação mensal (query conteudo)
+const (
+	ConteudoFrequencia = "frequencia" // Frequência dos alunos por data
+	ConteudoDiario     = "diario"     // Conteúdo ministrado (diário de classe)
+)
+
+// ExportFrequenciaMensal GET /api/v1/chamadas/:userId/export/:escopo/:id/:anoMes?delimiter=;&conteudo=diario
+// escopo = "turma" ou "curso"; devolve CSV (BOM UTF-8, ";" por padrão para o Excel em pt-BR).
+// conteudo = "frequencia" (padrão) ou "diario" (conteúdo ministrado no mês)
+func (h *ChamadasHandler) ExportFrequenciaMensal(c *gin.Context) {
+	escopo := c.Param("escopo")
+	if escopo != service.EscopoTurma && escopo != service.EscopoCurso {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Escopo inválido. Use turma ou curso"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	anoMes := c.Param("anoMes")
+	if len(anoMes) != 6 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de ano/mês inválido. Use AAAAMM (ex: 202511)"})
+		return
+	}
+
+	delimiter, err := export.ParseDelimiter(c.Query("delimiter"), ';')
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Delimitador inválido", "details": err.Error()})
+		return
+	}
+
+	conteudo := c.DefaultQuery("conteudo", ConteudoFrequencia)
+	var table *export.Table
+	switch conteudo {
+	case ConteudoFrequencia:
+		table, err = h.service.ExportFrequenciaMensal(c.Request.Context(), escopo, id, anoMes)
+	case ConteudoDiario:
+		table, err = h.service.ExportDiarioMensal(c.Request.Context(), escopo, id, anoMes)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Conteúdo inválido. Use frequencia ou diario"})
+		return
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrPeriodoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Período inválido", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao exportar " + conteudo, "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(export.FormatCSV))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s_%s_%d_%s.csv"`, conteudo, escopo, id, anoMes))
+	c.Status(http.StatusOK)
+	if err := export.WriteCSV(c.Writer, *table, delimiter); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// GerarPDFFrequencia GET /api/v1/chamadas/:userId/pdf/:tipo/:turmaId/:anoMes
+// tipo = "folha" (lista em branco para chamada em papel) ou "relatorio" (frequência lançada com percentuais)
+func (h *ChamadasHandler) GerarPDFFrequencia(c *gin.Context) {
+	tipo := c.Param("tipo")
+	if tipo != service.PDFFolha && tipo != service.PDFRelatorio {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo inválido. Use folha ou relatorio"})
+		return
+	}
+
+	turmaID, err := strconv.Atoi(c.Param("turmaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da turma inválido"})
+		return
+	}
+
+	anoMes := c.Param("anoMes")
+	if len(anoMes) != 6 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de ano/mês inválido. Use AAAAMM (ex: 202511)"})
+		return
+	}
+
+	pdf, err := h.service.GerarPDFFrequencia(c.Request.Context(), tipo, turmaID, anoMes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar PDF", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="frequencia_%s_turma_%d_%s.pdf"`, tipo, turmaID, anoMes))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// ========== RELATÓRIOS DE FREQUÊNCIA ==========
+
+// GetRelatorioFrequencia GET /api/v1/chamadas/:userId/relatorios/frequencia?dataInicio=&dataFim=&cursoId=&turmaId=&alunoId=
+func (h *ChamadasHandler) GetRelatorioFrequencia(c *gin.Context) {
+	var filtro model.FrequenciaFiltro
+	if err := c.ShouldBindQuery(&filtro); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	relatorio, err := h.service.GetRelatorioFrequencia(c.Request.Context(), filtro)
+	if err != nil {
+		if errors.Is(err, service.ErrPeriodoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Período inválido", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar relatório de frequência", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, relatorio)
+}
+
+// GetRiscoEvasao GET /api/v1/chamadas/:userId/relatorios/risco?dataInicio=&dataFim=&cursoId=&turmaId=&frequenciaMinima=&faltasConsecutivas=&minimoAulas=&limit=
+func (h *ChamadasHandler) GetRiscoEvasao(c *gin.Context) {
+	var filtro model.FrequenciaFiltro
+	if err := c.ShouldBindQuery(&filtro); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	var query model.RiscoEvasaoQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	relatorio, err := h.service.GetRiscoEvasao(c.Request.Context(), filtro, query)
+	if err != nil {
+		if errors.Is(err, service.ErrPeriodoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Período inválido", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar relatório de risco de evasão", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, relatorio)
+}
+
+// ========== DIÁRIO DE CLASSE ==========
+
+// GetDiarioAula GET /api/v1/chamadas/:userId/diarios/chamada/:chamadaId
+func (h *ChamadasHandler) GetDiarioAula(c *gin.Context) {
+	chamadaID, err := strconv.Atoi(c.Param("chamadaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da chamada inválido"})
+		return
+	}
+
+	diario, err := h.service.GetDiarioAula(c.Request.Context(), chamadaID)
+	if err != nil {
+		respondDiarioError(c, "Erro ao buscar diário de classe", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diario)
+}
+
+// SalvarDiarioAula POST /api/v1/chamadas/:userId/diarios/chamada/:chamadaId
+// Registra (201) ou substitui (200) o diário de classe da chamada
+func (h *ChamadasHandler) SalvarDiarioAula(c *gin.Context) {
+	chamadaID, err := strconv.Atoi(c.Param("chamadaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da chamada inválido"})
+		return
+	}
 
+	var payload model.DiarioAulaPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	diario, criado, err := h.service.SalvarDiarioAula(c.Request.Context(), chamadaID, payload, usuarioAutenticado(c))
+	if err != nil {
+		respondDiarioError(c, "Erro ao gravar diário de classe", err)
+		return
+	}
+
+	status := http.StatusOK
+	if criado {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"message": "Diário de classe gravado com sucesso",
+		"diario":  diario,
+	})
+}
 
+// DeleteDiarioAula DELETE /api/v1/chamadas/:userId/diarios/chamada/:chamadaId
+func (h *ChamadasHandler) DeleteDiarioAula(c *gin.Context) {
+	chamadaID, err := strconv.Atoi(c.Param("chamadaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da chamada inválido"})
+		return
+	}
 
+	if err := h.service.DeleteDiarioAula(c.Request.Context(), chamadaID, usuarioAutenticado(c)); err != nil {
+		respondDiarioError(c, "Erro ao apagar diário de classe", err)
+		return
+	}
 
+	c.JSON(http.StatusOK, gin.H{"message": "Diário de classe apagado com sucesso"})
+}
+
+// GetDiarioTurma GET /api/v1/chamadas/:userId/diarios/turma/:turmaId?dataInicio=&dataFim=
+// Linha do tempo da turma: todas as chamadas do período, com o diário de cada aula
+func (h *ChamadasHandler) GetDiarioTurma(c *gin.Context) {
+	turmaID, err := strconv.Atoi(c.Param("turmaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da turma inválido"})
+		return
+	}
+
+	var filtro model.DiarioFiltro
+	if err := c.ShouldBindQuery(&filtro); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	diarios, err := h.service.GetDiarioTurma(c.Request.Context(), turmaID, filtro)
+	if err != nil {
+		respondDiarioError(c, "Erro ao listar diário da turma", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": diarios})
+}
+
+// respondDiarioError mapeia os erros do diário de classe para 400/403/404, demais para 500
+func respondDiarioError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrDiarioInvalido), errors.Is(err, service.ErrPeriodoInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message, "details": err.Error()})
+	case errors.Is(err, service.ErrChamadaNaoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chamada não encontrada"})
+	case errors.Is(err, service.ErrTurmaNaoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Turma não encontrada"})
+	case errors.Is(err, service.ErrDiarioNaoEncontrado):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Diário de classe não registrado para esta chamada"})
+	case errors.Is(err, service.ErrSemPermissao):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sem permissão para lançar chamadas desta turma", "details": err.Error()})
+	case errors.Is(err, service.ErrPeriodoBloqueado):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Período bloqueado para edição", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// ========== CALENDÁRIO INSTITUCIONAL ==========
+
+// ListEventosCalendario GET /api/v1/calendario?dataInicio=&dataFim=&turmaId=
+func (h *ChamadasHandler) ListEventosCalendario(c *gin.Context) {
+	var filtro model.CalendarioFiltro
+	if err := c.ShouldBindQuery(&filtro); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	eventos, err := h.service.ListEventosCalendario(c.Request.Context(), filtro)
+	if err != nil {
+		if errors.Is(err, service.ErrPeriodoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Período inválido", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao listar calendário", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": eventos})
+}
+
+// CreateEventoCalendario POST /api/v1/calendario
+func (h *ChamadasHandler) CreateEventoCalendario(c *gin.Context) {
+	var payload model.EventoCalendarioPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	id, err := h.service.CreateEventoCalendario(c.Request.Context(), payload, middleware.UserID(c))
+	if err != nil {
+		respondEventoCalendarioError(c, "Erro ao cadastrar evento do calendário", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Evento cadastrado com sucesso",
+		"id":      id,
+	})
+}
+
+// UpdateEventoCalendario PUT /api/v1/calendario/:id
+func (h *ChamadasHandler) UpdateEventoCalendario(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do evento inválido"})
+		return
+	}
+
+	var payload model.EventoCalendarioPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	if err := h.service.UpdateEventoCalendario(c.Request.Context(), id, payload); err != nil {
+		respondEventoCalendarioError(c, "Erro ao atualizar evento do calendário", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Evento atualizado com sucesso"})
+}
+
+// DeleteEventoCalendario DELETE /api/v1/calendario/:id
+func (h *ChamadasHandler) DeleteEventoCalendario(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do evento inválido"})
+		return
+	}
+
+	if err := h.service.DeleteEventoCalendario(c.Request.Context(), id); err != nil {
+		respondEventoCalendarioError(c, "Erro ao excluir evento do calendário", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Evento excluído com sucesso"})
+}
+
+// respondEventoCalendarioError mapeia os erros do calendário para 400/404, demais para 500
+func respondEventoCalendarioError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrEventoInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message, "details": err.Error()})
+	case errors.Is(err, service.ErrEventoNaoEncontrado):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
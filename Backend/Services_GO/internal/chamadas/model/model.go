@@ -10,31 +10,93 @@ type Chamada struct {
 
 // Presenca representa a tabela presenca
 type Presenca struct {
-	ID         int    `json:"id" db:"id_presenca"`
-	ChamadaID  int    `json:"chamadaId" db:"chamada_id_chamada"`
-	AlunoID    int    `json:"alunoId" db:"aluno_id_aluno"`
-	Presente   string `json:"presente" db:"presente"` // VARCHAR(2): "P", "F", etc.
-	Observacao string `json:"observacao" db:"observacao"`
+	ID         int     `json:"id" db:"id_presenca"`
+	ChamadaID  int     `json:"chamadaId" db:"chamada_id_chamada"`
+	AlunoID    int     `json:"alunoId" db:"aluno_id_aluno"`
+	Presente   string  `json:"presente" db:"presente"` // Código do status (tabela status_presenca)
+	Observacao string  `json:"observacao" db:"observacao"`
+	Anexos     []Anexo `json:"anexos"` // Documentos de justificativa (file-service, entidade_pai = "presenca")
 }
 
-// CreateChamadaPayload payload para criar uma chamada
+// Anexo metadados de um arquivo enviado ao file-service (justificativas de presença e anexos do diário de classe)
+type Anexo struct {
+	ID          int64  `json:"id"`
+	NomeArquivo string `json:"nomeArquivo"`
+	Extensao    string `json:"extensao"`
+	Observacao  string `json:"observacao"`
+}
+
+// Categorias dos status de presença: definem como cada status entra na frequência
+const (
+	CategoriaPresente    = "PRESENTE"    // Conta como aula assistida e interrompe a sequência de faltas
+	CategoriaAusente     = "AUSENTE"     // Conta como falta e aumenta a sequência de faltas
+	CategoriaJustificado = "JUSTIFICADO" // Falta justificada: conta como aula, mas não altera a sequência de faltas
+	CategoriaDispensado  = "DISPENSADO"  // Aluno dispensado: não entra na frequência
+)
+
+// StatusPresenca representa a tabela status_presenca (códigos aceitos em presenca.presente)
+type StatusPresenca struct {
+	Codigo    string `json:"codigo" db:"codigo"`
+	Nome      string `json:"nome" db:"nome"`
+	Categoria string `json:"categoria" db:"categoria"`
+	Ativo     bool   `json:"ativo" db:"ativo"`
+}
+
+// CreateChamadaPayload payload para criar uma chamada (o responsável é o usuário autenticado)
 type CreateChamadaPayload struct {
-	UsuarioID int    `json:"usuarioId" binding:"required"`
-	TurmaID   int    `json:"turmaId" binding:"required"`
-	DataAula  string `json:"dataAula" binding:"required"` // Formato: YYYY-MM-DD
+	TurmaID  int    `json:"turmaId" binding:"required"`
+	DataAula string `json:"dataAula" binding:"required,data"` // Formato: YYYY-MM-DD
 }
 
-// UpdateChamadaPayload payload para atualizar uma chamada
+// UpdateChamadaPayload payload para atualizar uma chamada (o responsável não muda)
 type UpdateChamadaPayload struct {
-	UsuarioID *int    `json:"usuarioId"`
-	TurmaID   *int    `json:"turmaId"`
-	DataAula  *string `json:"dataAula"` // Formato: YYYY-MM-DD
+	TurmaID  *int    `json:"turmaId"`
+	DataAula *string `json:"dataAula" binding:"omitempty,data"` // Formato: YYYY-MM-DD
+}
+
+// AbrirChamadaPayload payload para abrir a chamada (sessão de aula) de uma turma em uma data
+type AbrirChamadaPayload struct {
+	TurmaID  int    `json:"turmaId" binding:"required,min=1"`
+	DataAula string `json:"dataAula" binding:"required,data"` // Formato: YYYY-MM-DD
+}
+
+// AbrirChamadaResponse chamada aberta (criada = false quando já existia)
+type AbrirChamadaResponse struct {
+	ID       int    `json:"id"`
+	TurmaID  int    `json:"turmaId"`
+	DataAula string `json:"dataAula"`
+	Criada   bool   `json:"criada"`
+}
+
+// GerarChamadasPayload payload para gerar as chamadas da turma no período (padrão: período letivo da turma)
+type GerarChamadasPayload struct {
+	TurmaID    int    `json:"turmaId" binding:"required,min=1"`
+	DataInicio string `json:"dataInicio" binding:"omitempty,data"` // Padrão: início da turma
+	DataFim    string `json:"dataFim" binding:"omitempty,data"`    // Padrão: fim da turma
+}
+
+// GerarChamadasResponse resultado da geração de chamadas de uma turma
+type GerarChamadasResponse struct {
+	TurmaID     int             `json:"turmaId"`
+	DataInicio  string          `json:"dataInicio"`
+	DataFim     string          `json:"dataFim"`
+	Aulas       int             `json:"aulas"`      // Datas de aula no período
+	Criadas     int             `json:"criadas"`    // Chamadas abertas agora
+	Existentes  int             `json:"existentes"` // Chamadas que já estavam abertas
+	DiasSemAula []DiaSemAula    `json:"diasSemAula"`
+	Bloqueadas  []DataBloqueada `json:"bloqueadas"` // Datas de aula puladas: mês fechado ou usuário sem atribuição
+}
+
+// DataBloqueada data de aula em que o usuário não pode abrir a chamada, com o motivo
+type DataBloqueada struct {
+	Data   string `json:"data"` // Formato: YYYY-MM-DD
+	Motivo string `json:"motivo"`
 }
 
 // CreatePresencaPayload payload para criar uma presença individual
 type CreatePresencaPayload struct {
 	AlunoID    int    `json:"alunoId" binding:"required"`
-	Presente   string `json:"presente"` // VARCHAR(2): "P", "F", etc.
+	Presente   string `json:"presente" binding:"required,max=2"` // Código do status (GET /presencas/status)
 	Observacao string `json:"observacao"`
 }
 
@@ -43,42 +105,317 @@ type PresencaPorData struct {
 	PresencaID  *int   `json:"presencaId,omitempty"`
 	Present     string `json:"present"`
 	Observation string `json:"observation"`
+	Anexos      int    `json:"anexos,omitempty"` // Documentos de justificativa anexados
 }
 
 // AlunoPresencas representa um aluno com suas presenças por data
 type AlunoPresencas struct {
-	AlunoID   int                        `json:"alunoId"`
-	AlunoNome string                     `json:"alunoNome"`
-	Presencas map[string]PresencaPorData `json:"presencas"` // Chave é a data (YYYY-MM-DD)
+	AlunoID    int                        `json:"alunoId"`
+	AlunoNome  string                     `json:"alunoNome"`
+	Matriculas []PeriodoMatricula         `json:"matriculas"` // Períodos em que o aluno esteve matriculado na turma no mês
+	Presencas  map[string]PresencaPorData `json:"presencas"`  // Chave é a data (YYYY-MM-DD)
+}
+
+// PeriodoMatricula vigência de uma matrícula do aluno na turma (tabela matricula)
+type PeriodoMatricula struct {
+	DataInicio string  `json:"dataInicio"` // Formato: YYYY-MM-DD
+	DataFim    *string `json:"dataFim"`    // null = matrícula em andamento
+}
+
+// MatriculadoEm informa se o aluno tinha matrícula vigente na turma na data (YYYY-MM-DD)
+func (a AlunoPresencas) MatriculadoEm(data string) bool {
+	for _, periodo := range a.Matriculas {
+		if periodo.DataInicio <= data && (periodo.DataFim == nil || *periodo.DataFim >= data) {
+			return true
+		}
+	}
+	return false
 }
 
 // DataChamada representa uma data com seu ID de chamada
 type DataChamada struct {
-	Data string `json:"data"` // Formato: YYYY-MM-DD
-	ID   int    `json:"id"`   // ID da chamada
+	Data   string `json:"data"`             // Formato: YYYY-MM-DD
+	ID     int    `json:"id"`               // ID da chamada (0 enquanto a chamada da data não foi aberta)
+	Extra  bool   `json:"extra,omitempty"`  // Aula extra do calendário (fora do dia da semana da turma)
+	Motivo string `json:"motivo,omitempty"` // Motivo da aula extra
 }
 
 // ChamadasPorTurmaMesResponse resposta do GET de chamadas por turma e mês
 type ChamadasPorTurmaMesResponse struct {
-	Datas  []DataChamada    `json:"datas"` // Array de datas com IDs das chamadas
-	Alunos []AlunoPresencas `json:"alunos"`
+	Datas       []DataChamada    `json:"datas"`       // Array de datas com IDs das chamadas
+	DiasSemAula []DiaSemAula     `json:"diasSemAula"` // Feriados, recessos e cancelamentos no mês
+	Periodo     *SituacaoPeriodo `json:"periodo"`     // Se o mês ainda pode ser lançado/editado
+	Alunos      []AlunoPresencas `json:"alunos"`
 }
 
 // CreatePresencasPayload payload para criar múltiplas presenças
 type CreatePresencasPayload struct {
 	ChamadaID int                     `json:"chamadaId" binding:"required"`
-	Presencas []CreatePresencaPayload `json:"presencas" binding:"required"`
+	Presencas []CreatePresencaPayload `json:"presencas" binding:"required,min=1,dive"`
 }
 
 // UpsertPresencasPayload payload para criar/atualizar múltiplas presenças
 type UpsertPresencasPayload struct {
 	ChamadaID int                    `json:"chamadaId" binding:"required"`
-	Records   []UpsertPresencaRecord `json:"records" binding:"required"`
+	Records   []UpsertPresencaRecord `json:"records" binding:"required,min=1,dive"`
 }
 
 // UpsertPresencaRecord representa um registro de presença para upsert
 type UpsertPresencaRecord struct {
 	IDEstudante int    `json:"idEstudante" binding:"required"`
-	Present     string `json:"present"` // VARCHAR(2): "P", "F", "J", etc.
+	Present     string `json:"present" binding:"required,max=2"` // Código do status (GET /presencas/status)
 	Observation string `json:"observation"`
 }
+
+// ResultadoPresencas resultado de um lançamento de presenças; é o que se repete nos reenvios com a mesma Idempotency-Key
+type ResultadoPresencas struct {
+	ChamadaID  int  `json:"chamadaId"`
+	Quantidade int  `json:"quantidade"`
+	Repetida   bool `json:"-"` // Resposta devolvida de uma requisição anterior com a mesma chave
+}
+
+// RequisicaoIdempotente registro de uma Idempotency-Key já usada em uma operação
+type RequisicaoIdempotente struct {
+	HashCorpo string
+	Resultado *string // JSON do resultado; nil enquanto a requisição original está em andamento
+}
+
+// FrequenciaTurmaMes reúne, sem criar chamadas, as datas e presenças de uma turma em um mês (usada em exportações)
+type FrequenciaTurmaMes struct {
+	TurmaID     int
+	TurmaNome   string
+	CursoID     int
+	CursoNome   string
+	Datas       []DataChamada // ID = 0 quando a chamada da data ainda não foi aberta
+	DiasSemAula []DiaSemAula
+	Alunos      []AlunoPresencas
+}
+
+// FrequenciaFiltro filtros (query string) dos relatórios de frequência; datas no formato AAAA-MM-DD
+type FrequenciaFiltro struct {
+	DataInicio string `form:"dataInicio" binding:"omitempty,data"` // Padrão: 90 dias antes de dataFim
+	DataFim    string `form:"dataFim" binding:"omitempty,data"`    // Padrão: hoje
+	CursoID    int    `form:"cursoId" binding:"omitempty,min=1"`
+	TurmaID    int    `form:"turmaId" binding:"omitempty,min=1"`
+	AlunoID    int    `form:"alunoId" binding:"omitempty,min=1"`
+}
+
+// RiscoEvasaoQuery critérios do relatório de risco (query string, junto com FrequenciaFiltro); zero = valor configurado no serviço
+type RiscoEvasaoQuery struct {
+	FrequenciaMinima   int `form:"frequenciaMinima" binding:"omitempty,min=1,max=100"`
+	FaltasConsecutivas int `form:"faltasConsecutivas" binding:"omitempty,min=1"`
+	MinimoAulas        int `form:"minimoAulas" binding:"omitempty,min=1"`
+	Limit              int `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// RegistroFrequencia uma presença lançada com os dados de turma e curso (base dos relatórios)
+type RegistroFrequencia struct {
+	AlunoID   int
+	AlunoNome string
+	TurmaID   int
+	TurmaNome string
+	CursoID   int
+	CursoNome string
+	DataAula  string // Formato: YYYY-MM-DD
+	Presente  string
+}
+
+// ResumoFrequencia totais de registros de presença e o percentual de frequência (presenças / aulas registradas)
+type ResumoFrequencia struct {
+	AulasRegistradas   int      `json:"aulasRegistradas"`
+	Presencas          int      `json:"presencas"`
+	Faltas             int      `json:"faltas"`
+	FaltasJustificadas int      `json:"faltasJustificadas"`
+	Dispensas          int      `json:"dispensas"`  // Não entram em aulasRegistradas nem na frequência
+	Frequencia         *float64 `json:"frequencia"` // Percentual com uma casa decimal; null sem registros
+}
+
+// FrequenciaAluno frequência de um aluno em uma turma no período
+type FrequenciaAluno struct {
+	AlunoID   int    `json:"alunoId"`
+	AlunoNome string `json:"alunoNome"`
+	TurmaID   int    `json:"turmaId"`
+	TurmaNome string `json:"turmaNome"`
+	CursoID   int    `json:"cursoId"`
+	CursoNome string `json:"cursoNome"`
+	ResumoFrequencia
+	FaltasConsecutivasAtuais int     `json:"faltasConsecutivasAtuais"` // Faltas seguidas até a última aula registrada
+	MaiorSequenciaFaltas     int     `json:"maiorSequenciaFaltas"`
+	UltimaPresenca           *string `json:"ultimaPresenca"` // Formato: YYYY-MM-DD
+}
+
+// FrequenciaTurma frequência consolidada de uma turma no período
+type FrequenciaTurma struct {
+	TurmaID   int    `json:"turmaId"`
+	TurmaNome string `json:"turmaNome"`
+	CursoID   int    `json:"cursoId"`
+	CursoNome string `json:"cursoNome"`
+	Aulas     int    `json:"aulas"` // Datas com presença lançada
+	Alunos    int    `json:"alunos"`
+	ResumoFrequencia
+}
+
+// FrequenciaCurso frequência consolidada de um curso no período
+type FrequenciaCurso struct {
+	CursoID   int    `json:"cursoId"`
+	CursoNome string `json:"cursoNome"`
+	Turmas    int    `json:"turmas"`
+	Alunos    int    `json:"alunos"`
+	ResumoFrequencia
+}
+
+// RelatorioFrequencia resposta do relatório de frequência por curso, turma e aluno
+type RelatorioFrequencia struct {
+	DataInicio string            `json:"dataInicio"`
+	DataFim    string            `json:"dataFim"`
+	Cursos     []FrequenciaCurso `json:"cursos"`
+	Turmas     []FrequenciaTurma `json:"turmas"`
+	Alunos     []FrequenciaAluno `json:"alunos"`
+}
+
+// CriteriosRisco critérios aplicados no relatório de risco de evasão
+type CriteriosRisco struct {
+	FrequenciaMinima   int `json:"frequenciaMinima"`
+	FaltasConsecutivas int `json:"faltasConsecutivas"`
+	MinimoAulas        int `json:"minimoAulas"`
+}
+
+// AlunoRisco aluno em risco de evasão, com a pontuação usada na ordenação e os motivos
+type AlunoRisco struct {
+	FrequenciaAluno
+	Pontuacao float64  `json:"pontuacao"`
+	Motivos   []string `json:"motivos"`
+}
+
+// RelatorioRisco resposta do relatório de risco de evasão (alunos do maior para o menor risco)
+type RelatorioRisco struct {
+	DataInicio string         `json:"dataInicio"`
+	DataFim    string         `json:"dataFim"`
+	Criterios  CriteriosRisco `json:"criterios"`
+	Total      int            `json:"total"`
+	Alunos     []AlunoRisco   `json:"alunos"`
+}
+
+// Tipos de evento do calendário institucional
+const (
+	EventoFeriado      = "FERIADO"      // Dia sem aula em todas as turmas
+	EventoRecesso      = "RECESSO"      // Período sem aula em todas as turmas
+	EventoCancelamento = "CANCELAMENTO" // Aula(s) cancelada(s) em uma turma
+	EventoAulaExtra    = "AULA_EXTRA"   // Aula em uma data fora do dia da semana da turma
+)
+
+// EventoCalendario representa a tabela calendario_evento
+type EventoCalendario struct {
+	ID         int    `json:"id" db:"id_evento"`
+	Tipo       string `json:"tipo" db:"tipo"`
+	DataInicio string `json:"dataInicio" db:"data_inicio"` // Formato: YYYY-MM-DD
+	DataFim    string `json:"dataFim" db:"data_fim"`       // Formato: YYYY-MM-DD
+	TurmaID    *int   `json:"turmaId" db:"turma_id_turma"` // null = todas as turmas (feriado/recesso)
+	Motivo     string `json:"motivo" db:"motivo"`
+	CriadoPor  *int   `json:"criadoPor" db:"criado_por"`
+}
+
+// EventoCalendarioPayload payload para criar/atualizar um evento do calendário
+type EventoCalendarioPayload struct {
+	Tipo       string `json:"tipo" binding:"required,oneof=FERIADO RECESSO CANCELAMENTO AULA_EXTRA"`
+	DataInicio string `json:"dataInicio" binding:"required,data"`
+	DataFim    string `json:"dataFim" binding:"omitempty,data"` // Padrão: dataInicio
+	TurmaID    *int   `json:"turmaId" binding:"omitempty,min=1"`
+	Motivo     string `json:"motivo" binding:"required,max=200"`
+}
+
+// CalendarioFiltro filtros (query string) da listagem do calendário; com turmaId, inclui os eventos de todas as turmas
+type CalendarioFiltro struct {
+	DataInicio string `form:"dataInicio" binding:"required,data"`
+	DataFim    string `form:"dataFim" binding:"required,data"`
+	TurmaID    int    `form:"turmaId" binding:"omitempty,min=1"`
+}
+
+// DiaSemAula data do dia da semana da turma em que não há aula, com o motivo do calendário
+type DiaSemAula struct {
+	Data   string `json:"data"` // Formato: YYYY-MM-DD
+	Tipo   string `json:"tipo"`
+	Motivo string `json:"motivo"`
+}
+
+// Situações de edição das chamadas de uma turma no mês
+const (
+	PeriodoAberto   = "ABERTO"   // Dentro da janela de edição
+	PeriodoFechado  = "FECHADO"  // Fechado manualmente ou com a janela de edição encerrada
+	PeriodoReaberto = "REABERTO" // Reaberto por um administrador até reabertoAte
+)
+
+// PeriodoChamada representa a tabela periodo_chamada (fechamento/reabertura do mês de uma turma)
+type PeriodoChamada struct {
+	TurmaID     int
+	AnoMes      string // Formato: AAAAMM
+	Situacao    string // FECHADO ou REABERTO
+	FechadoPor  *int
+	FechadoEm   *string
+	ReabertoPor *int
+	ReabertoEm  *string
+	ReabertoAte *string // Formato: YYYY-MM-DD
+	Motivo      string
+}
+
+// SituacaoPeriodo situação de edição das chamadas de uma turma no mês
+type SituacaoPeriodo struct {
+	TurmaID     int     `json:"turmaId"`
+	AnoMes      string  `json:"anoMes"`
+	Situacao    string  `json:"situacao"` // ABERTO, FECHADO ou REABERTO
+	Editavel    bool    `json:"editavel"`
+	EditavelAte *string `json:"editavelAte"`      // Último dia para lançar/editar (null quando fechado)
+	Motivo      string  `json:"motivo,omitempty"` // Por que está fechado, ou o motivo da reabertura
+	FechadoPor  *int    `json:"fechadoPor,omitempty"`
+	FechadoEm   *string `json:"fechadoEm,omitempty"`
+	ReabertoPor *int    `json:"reabertoPor,omitempty"`
+	ReabertoEm  *string `json:"reabertoEm,omitempty"`
+}
+
+// ReabrirPeriodoPayload payload para reabrir o mês de uma turma (somente administradores)
+type ReabrirPeriodoPayload struct {
+	Motivo string `json:"motivo" binding:"required,max=200"`
+	Ate    string `json:"ate" binding:"omitempty,data"` // Último dia da reabertura. Padrão: hoje + CHAMADA_DIAS_REABERTURA
+}
+
+// DiarioAula registro do diário de classe de uma chamada (tabela diario_aula). Na linha do tempo da turma,
+// chamadas sem diário aparecem com registrado = false.
+type DiarioAula struct {
+	ID                int     `json:"id"` // 0 quando o diário da chamada ainda não foi registrado
+	ChamadaID         int     `json:"chamadaId"`
+	TurmaID           int     `json:"turmaId"`
+	TurmaNome         string  `json:"turmaNome"`
+	CursoNome         string  `json:"cursoNome"`
+	DataAula          string  `json:"dataAula"` // Formato: YYYY-MM-DD
+	Registrado        bool    `json:"registrado"`
+	Tema              string  `json:"tema"`
+	Conteudo          string  `json:"conteudo"`
+	Atividades        string  `json:"atividades"`
+	Materiais         string  `json:"materiais"`
+	Observacoes       string  `json:"observacoes"` // Observações do professor
+	RegistradoPor     *int    `json:"registradoPor,omitempty"`
+	RegistradoPorNome string  `json:"registradoPorNome,omitempty"`
+	AtualizadoEm      *string `json:"atualizadoEm,omitempty"`
+	Anexos            []Anexo `json:"anexos"` // Arquivos do file-service (entidade_pai = "diario_aula", id_entidade_pai = chamadaId)
+}
+
+// DiarioAulaPayload payload para registrar ou atualizar o diário de classe de uma chamada
+type DiarioAulaPayload struct {
+	Tema        string `json:"tema" binding:"required,max=200"`
+	Conteudo    string `json:"conteudo" binding:"max=5000"`
+	Atividades  string `json:"atividades" binding:"max=5000"`
+	Materiais   string `json:"materiais" binding:"max=2000"`
+	Observacoes string `json:"observacoes" binding:"max=2000"`
+}
+
+// DiarioFiltro filtros (query string) da linha do tempo do diário; datas no formato AAAA-MM-DD
+type DiarioFiltro struct {
+	DataInicio string `form:"dataInicio" binding:"omitempty,data"` // Padrão: início da turma
+	DataFim    string `form:"dataFim" binding:"omitempty,data"`    // Padrão: fim da turma
+}
+
+// Usuario identifica quem está lançando chamadas e presenças (identidade repassada pelo API Gateway)
+type Usuario struct {
+	ID   int
+	Tipo string // usuarios.tipo: A (administrador), U (regular) ou P (professor)
+}
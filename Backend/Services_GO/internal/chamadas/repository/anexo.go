@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"sysocial/internal/chamadas/model"
+
+	"github.com/lib/pq"
+)
+
+// ========== ANEXOS (file-service) ==========
+
+// Entidades (anexos.entidade_pai) dos arquivos enviados ao file-service pelas telas de chamada
+const (
+	EntidadeAnexoPresenca = "presenca"    // Documentos de justificativa; id_entidade_pai = id_presenca
+	EntidadeAnexoDiario   = "diario_aula" // Anexos do diário de classe; id_entidade_pai = id_chamada
+)
+
+// GetAnexos busca os metadados dos arquivos da entidade, agrupados pelo ID da entidade (sem o conteúdo do arquivo)
+func (r *ChamadasRepository) GetAnexos(ctx context.Context, entidade string, entidadeIDs []int) (map[int][]model.Anexo, error) {
+	anexos := make(map[int][]model.Anexo)
+	if len(entidadeIDs) == 0 {
+		return anexos, nil
+	}
+
+	ids := make([]string, 0, len(entidadeIDs))
+	for _, id := range entidadeIDs {
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, id_entidade_pai, nome_arquivo, extensao, COALESCE(observacao, '')
+		FROM anexos
+		WHERE entidade_pai = $1 AND id_entidade_pai = ANY($2)
+		ORDER BY id`,
+		entidade, pq.Array(ids),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar anexos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var anexo model.Anexo
+		var entidadeID string
+		if err := rows.Scan(&anexo.ID, &entidadeID, &anexo.NomeArquivo, &anexo.Extensao, &anexo.Observacao); err != nil {
+			return nil, fmt.Errorf("erro ao escanear anexo: %w", err)
+		}
+		if id, err := strconv.Atoi(entidadeID); err == nil {
+			anexos[id] = append(anexos[id], anexo)
+		}
+	}
+
+	return anexos, rows.Err()
+}
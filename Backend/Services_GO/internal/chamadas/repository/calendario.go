@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"sysocial/internal/chamadas/model"
+)
+
+// diaSemAulaSQL é verdadeiro quando o calendário suspende a aula da turma (%[1]s) na data (%[2]s);
+// uma aula extra cadastrada para a turma na mesma data prevalece (mesma regra de diaSemAula)
+const diaSemAulaSQL = `(EXISTS (
+			SELECT 1 FROM calendario_evento e
+			WHERE e.tipo IN ('FERIADO', 'RECESSO', 'CANCELAMENTO')
+			  AND %[2]s BETWEEN e.data_inicio AND e.data_fim
+			  AND (e.turma_id_turma IS NULL OR e.turma_id_turma = %[1]s))
+		AND NOT EXISTS (
+			SELECT 1 FROM calendario_evento e
+			WHERE e.tipo = 'AULA_EXTRA' AND e.turma_id_turma = %[1]s
+			  AND %[2]s BETWEEN e.data_inicio AND e.data_fim))`
+
+// ========== CALENDÁRIO INSTITUCIONAL ==========
+
+// ListEventosCalendario lista os eventos que se sobrepõem ao período; com turmaID, traz os da turma
+// e os de todas as turmas (feriados e recessos)
+func (r *ChamadasRepository) ListEventosCalendario(ctx context.Context, filtro model.CalendarioFiltro) ([]model.EventoCalendario, error) {
+	query := `
+		SELECT id_evento, tipo, to_char(data_inicio, 'YYYY-MM-DD'), to_char(data_fim, 'YYYY-MM-DD'),
+		       turma_id_turma, motivo, criado_por
+		FROM calendario_evento
+		WHERE data_inicio <= $2 AND data_fim >= $1`
+	args := []interface{}{filtro.DataInicio, filtro.DataFim}
+	if filtro.TurmaID > 0 {
+		query += ` AND (turma_id_turma IS NULL OR turma_id_turma = $3)`
+		args = append(args, filtro.TurmaID)
+	}
+	query += ` ORDER BY data_inicio, id_evento`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar calendário: %w", err)
+	}
+	defer rows.Close()
+
+	eventos := []model.EventoCalendario{}
+	for rows.Next() {
+		evento, err := scanEventoCalendario(rows)
+		if err != nil {
+			return nil, err
+		}
+		eventos = append(eventos, *evento)
+	}
+
+	return eventos, rows.Err()
+}
+
+// GetEventoCalendarioByID busca um evento do calendário por ID
+func (r *ChamadasRepository) GetEventoCalendarioByID(ctx context.Context, id int) (*model.EventoCalendario, error) {
+	query := `
+		SELECT id_evento, tipo, to_char(data_inicio, 'YYYY-MM-DD'), to_char(data_fim, 'YYYY-MM-DD'),
+		       turma_id_turma, motivo, criado_por
+		FROM calendario_evento
+		WHERE id_evento = $1`
+
+	evento, err := scanEventoCalendario(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return evento, err
+}
+
+// CreateEventoCalendario cadastra um evento no calendário
+func (r *ChamadasRepository) CreateEventoCalendario(ctx context.Context, payload model.EventoCalendarioPayload, usuarioID int) (int, error) {
+	query := `
+		INSERT INTO calendario_evento (tipo, data_inicio, data_fim, turma_id_turma, motivo, criado_por)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id_evento`
+
+	var criadoPor interface{}
+	if usuarioID > 0 {
+		criadoPor = usuarioID
+	}
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query,
+		payload.Tipo, payload.DataInicio, payload.DataFim, payload.TurmaID, payload.Motivo, criadoPor,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao criar evento do calendário: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateEventoCalendario substitui os dados de um evento do calendário
+func (r *ChamadasRepository) UpdateEventoCalendario(ctx context.Context, id int, payload model.EventoCalendarioPayload) error {
+	query := `
+		UPDATE calendario_evento
+		SET tipo = $1, data_inicio = $2, data_fim = $3, turma_id_turma = $4, motivo = $5
+		WHERE id_evento = $6`
+
+	_, err := r.db.ExecContext(ctx, query, payload.Tipo, payload.DataInicio, payload.DataFim, payload.TurmaID, payload.Motivo, id)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar evento do calendário: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteEventoCalendario remove um evento do calendário
+func (r *ChamadasRepository) DeleteEventoCalendario(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM calendario_evento WHERE id_evento = $1`, id)
+	if err != nil {
+		return fmt.Errorf("erro ao excluir evento do calendário: %w", err)
+	}
+
+	return nil
+}
+
+// VerificaDiaSemAula indica se o calendário suspende a aula da turma na data (nil quando há aula)
+func (r *ChamadasRepository) VerificaDiaSemAula(ctx context.Context, turmaID int, data string) (*model.DiaSemAula, error) {
+	eventos, err := r.ListEventosCalendario(ctx, model.CalendarioFiltro{DataInicio: data, DataFim: data, TurmaID: turmaID})
+	if err != nil {
+		return nil, err
+	}
+
+	return diaSemAula(eventos, data), nil
+}
+
+// datasDeAulaDoMes aplica o calendário às datas do dia da semana da turma no mês: separa os dias sem aula
+// (com o motivo) e acrescenta as aulas extras
+func (r *ChamadasRepository) datasDeAulaDoMes(ctx context.Context, turmaID int, ano int, mes time.Month, datas []string) ([]model.DataChamada, []model.DiaSemAula, error) {
+	inicio := time.Date(ano, mes, 1, 0, 0, 0, 0, time.UTC)
+	eventos, err := r.ListEventosCalendario(ctx, model.CalendarioFiltro{
+		DataInicio: inicio.Format("2006-01-02"),
+		DataFim:    inicio.AddDate(0, 1, -1).Format("2006-01-02"),
+		TurmaID:    turmaID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aulas, semAula := aplicarCalendario(datas, eventos, ano, mes)
+	return aulas, semAula, nil
+}
+
+// aplicarCalendario remove das datas os dias sem aula e inclui as aulas extras do mês, em ordem de data
+func aplicarCalendario(datas []string, eventos []model.EventoCalendario, ano int, mes time.Month) ([]model.DataChamada, []model.DiaSemAula) {
+	aulas := []model.DataChamada{}
+	semAula := []model.DiaSemAula{}
+	incluidas := make(map[string]bool)
+
+	for _, data := range datas {
+		if dia := diaSemAula(eventos, data); dia != nil {
+			semAula = append(semAula, *dia)
+			continue
+		}
+		aulas = append(aulas, model.DataChamada{Data: data})
+		incluidas[data] = true
+	}
+
+	for _, evento := range eventos {
+		if evento.Tipo != model.EventoAulaExtra {
+			continue
+		}
+		inicio, errInicio := time.Parse("2006-01-02", evento.DataInicio)
+		fim, errFim := time.Parse("2006-01-02", evento.DataFim)
+		if errInicio != nil || errFim != nil {
+			continue
+		}
+		for dia := inicio; !dia.After(fim); dia = dia.AddDate(0, 0, 1) {
+			data := dia.Format("2006-01-02")
+			if dia.Year() != ano || dia.Month() != mes || incluidas[data] {
+				continue
+			}
+			aulas = append(aulas, model.DataChamada{Data: data, Extra: true, Motivo: evento.Motivo})
+			incluidas[data] = true
+		}
+	}
+
+	sort.Slice(aulas, func(i, j int) bool { return aulas[i].Data < aulas[j].Data })
+	return aulas, semAula
+}
+
+// diaSemAula devolve o primeiro feriado, recesso ou cancelamento que cobre a data, a menos que haja aula extra nela
+func diaSemAula(eventos []model.EventoCalendario, data string) *model.DiaSemAula {
+	var bloqueio *model.EventoCalendario
+	for i, evento := range eventos {
+		if data < evento.DataInicio || data > evento.DataFim {
+			continue
+		}
+		if evento.Tipo == model.EventoAulaExtra {
+			return nil
+		}
+		if bloqueio == nil {
+			bloqueio = &eventos[i]
+		}
+	}
+
+	if bloqueio == nil {
+		return nil
+	}
+	return &model.DiaSemAula{Data: data, Tipo: bloqueio.Tipo, Motivo: bloqueio.Motivo}
+}
+
+// scanEventoCalendario lê uma linha de calendario_evento (sql.Row ou sql.Rows)
+func scanEventoCalendario(row interface{ Scan(...interface{}) error }) (*model.EventoCalendario, error) {
+	var evento model.EventoCalendario
+	var turmaID, criadoPor sql.NullInt64
+	err := row.Scan(&evento.ID, &evento.Tipo, &evento.DataInicio, &evento.DataFim, &turmaID, &evento.Motivo, &criadoPor)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao escanear evento do calendário: %w", err)
+	}
+
+	if turmaID.Valid {
+		id := int(turmaID.Int64)
+		evento.TurmaID = &id
+	}
+	if criadoPor.Valid {
+		id := int(criadoPor.Int64)
+		evento.CriadoPor = &id
+	}
+	return &evento, nil
+}
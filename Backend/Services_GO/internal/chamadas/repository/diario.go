@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"sysocial/internal/chamadas/model"
+)
+
+// ========== DIÁRIO DE CLASSE ==========
+
+// selectDiarioAula parte da chamada: chamadas sem diário vêm com id_diario nulo
+const selectDiarioAula = `
+	SELECT c.id_chamada, c.turmas_id_turma, t.nome_turma, cu.nome, to_char(c.data_aula, 'YYYY-MM-DD'),
+	       d.id_diario, COALESCE(d.tema, ''), COALESCE(d.conteudo, ''), COALESCE(d.atividades, ''),
+	       COALESCE(d.materiais, ''), COALESCE(d.observacoes, ''),
+	       d.registrado_por, COALESCE(u.nome, ''), to_char(d.atualizado_em, 'YYYY-MM-DD"T"HH24:MI:SS')
+	FROM chamada c
+	INNER JOIN turma t ON t.id_turma = c.turmas_id_turma
+	INNER JOIN curso cu ON cu.id_curso = t.cursos_id_curso
+	LEFT JOIN diario_aula d ON d.chamada_id_chamada = c.id_chamada
+	LEFT JOIN usuarios u ON u.id_usuario = d.registrado_por`
+
+// GetDiarioAula busca o diário da chamada (registrado = false quando ainda não foi preenchido); nil quando a
+// chamada não existe
+func (r *ChamadasRepository) GetDiarioAula(ctx context.Context, chamadaID int) (*model.DiarioAula, error) {
+	diario, err := scanDiarioAula(r.db.QueryRowContext(ctx, selectDiarioAula+`
+		WHERE c.id_chamada = $1`, chamadaID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return diario, err
+}
+
+// GetDiariosTurma lista as chamadas da turma no período (datas vazias = sem limite), em ordem de data, com o
+// diário de cada uma
+func (r *ChamadasRepository) GetDiariosTurma(ctx context.Context, turmaID int, dataInicio, dataFim string) ([]model.DiarioAula, error) {
+	query := selectDiarioAula + `
+		WHERE c.turmas_id_turma = $1
+		  AND c.data_aula >= COALESCE(NULLIF($2, '')::date, '-infinity'::date)
+		  AND c.data_aula <= COALESCE(NULLIF($3, '')::date, 'infinity'::date)
+		ORDER BY c.data_aula`
+
+	rows, err := r.db.QueryContext(ctx, query, turmaID, dataInicio, dataFim)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar diário da turma: %w", err)
+	}
+	defer rows.Close()
+
+	diarios := []model.DiarioAula{}
+	for rows.Next() {
+		diario, err := scanDiarioAula(rows)
+		if err != nil {
+			return nil, err
+		}
+		diarios = append(diarios, *diario)
+	}
+
+	return diarios, rows.Err()
+}
+
+// SaveDiarioAula registra ou substitui o diário da chamada; devolve true quando o diário foi criado.
+// A constraint diario_aula_chamada_key resolve gravações simultâneas.
+func (r *ChamadasRepository) SaveDiarioAula(ctx context.Context, chamadaID int, payload model.DiarioAulaPayload, usuarioID int) (bool, error) {
+	query := `
+		INSERT INTO diario_aula (chamada_id_chamada, tema, conteudo, atividades, materiais, observacoes,
+		                         registrado_por, atualizado_por)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (chamada_id_chamada) DO UPDATE SET
+			tema = EXCLUDED.tema,
+			conteudo = EXCLUDED.conteudo,
+			atividades = EXCLUDED.atividades,
+			materiais = EXCLUDED.materiais,
+			observacoes = EXCLUDED.observacoes,
+			atualizado_por = EXCLUDED.atualizado_por,
+			atualizado_em = now()
+		RETURNING (xmax = 0)`
+
+	var criado bool
+	err := r.db.QueryRowContext(ctx, query, chamadaID, payload.Tema, payload.Conteudo, payload.Atividades,
+		payload.Materiais, payload.Observacoes, nullableID(usuarioID)).Scan(&criado)
+	if err != nil {
+		return false, fmt.Errorf("erro ao gravar diário de classe: %w", err)
+	}
+
+	return criado, nil
+}
+
+// DeleteDiarioAula apaga o diário da chamada com os seus anexos; devolve false quando não havia diário
+func (r *ChamadasRepository) DeleteDiarioAula(ctx context.Context, chamadaID int) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM diario_aula WHERE chamada_id_chamada = $1`, chamadaID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao apagar diário de classe: %w", err)
+	}
+	afetadas, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao apagar diário de classe: %w", err)
+	}
+	if afetadas == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM anexos WHERE entidade_pai = $1 AND id_entidade_pai = $2::text`,
+		EntidadeAnexoDiario, chamadaID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao apagar anexos do diário: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+	return true, nil
+}
+
+// scanDiarioAula lê uma linha de selectDiarioAula
+func scanDiarioAula(row interface{ Scan(...interface{}) error }) (*model.DiarioAula, error) {
+	var diario model.DiarioAula
+	var id, registradoPor sql.NullInt64
+	var atualizadoEm sql.NullString
+	err := row.Scan(
+		&diario.ChamadaID, &diario.TurmaID, &diario.TurmaNome, &diario.CursoNome, &diario.DataAula,
+		&id, &diario.Tema, &diario.Conteudo, &diario.Atividades,
+		&diario.Materiais, &diario.Observacoes,
+		&registradoPor, &diario.RegistradoPorNome, &atualizadoEm,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao escanear diário de classe: %w", err)
+	}
+
+	diario.ID = int(id.Int64)
+	diario.Registrado = id.Valid
+	diario.RegistradoPor = nullIntPtr(registradoPor)
+	diario.AtualizadoEm = nullStringPtr(atualizadoEm)
+	return &diario, nil
+}
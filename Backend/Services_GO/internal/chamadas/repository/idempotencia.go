@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sysocial/internal/chamadas/model"
+)
+
+// ========== IDEMPOTÊNCIA (Idempotency-Key) ==========
+
+// ReservarIdempotencia registra a chave do usuário para a operação. Devolve reservada = true quando a requisição
+// deve ser executada (chave nova, vencida ou parada em andamento há mais de prazoAndamento, ex: o serviço caiu antes
+// de guardar o resultado); caso contrário, devolve o registro existente para comparar e repetir.
+func (r *ChamadasRepository) ReservarIdempotencia(ctx context.Context, chave, operacao string, usuarioID int, hashCorpo string, validade, prazoAndamento time.Duration) (*model.RequisicaoIdempotente, bool, error) {
+	query := `
+		INSERT INTO requisicao_idempotente (chave, operacao, usuario_id, hash_corpo)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chave, operacao, usuario_id) DO UPDATE
+		SET hash_corpo = EXCLUDED.hash_corpo, resultado = NULL, criado_em = now()
+		WHERE requisicao_idempotente.criado_em < now() - make_interval(secs => $5)
+		   OR (requisicao_idempotente.resultado IS NULL AND requisicao_idempotente.criado_em < now() - make_interval(secs => $6))
+		RETURNING chave`
+
+	var reservada string
+	err := r.db.QueryRowContext(ctx, query, chave, operacao, usuarioID, hashCorpo, validade.Seconds(), prazoAndamento.Seconds()).Scan(&reservada)
+	if err == nil {
+		return nil, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, fmt.Errorf("erro ao registrar chave de idempotência: %w", err)
+	}
+
+	var registro model.RequisicaoIdempotente
+	var resultado sql.NullString
+	err = r.db.QueryRowContext(ctx,
+		`SELECT hash_corpo, resultado FROM requisicao_idempotente WHERE chave = $1 AND operacao = $2 AND usuario_id = $3`,
+		chave, operacao, usuarioID,
+	).Scan(&registro.HashCorpo, &resultado)
+	if err != nil {
+		return nil, false, fmt.Errorf("erro ao buscar chave de idempotência: %w", err)
+	}
+	if resultado.Valid {
+		registro.Resultado = &resultado.String
+	}
+
+	return &registro, false, nil
+}
+
+// ConcluirIdempotencia guarda o resultado da operação para as próximas requisições com a mesma chave
+func (r *ChamadasRepository) ConcluirIdempotencia(ctx context.Context, chave, operacao string, usuarioID int, resultado string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE requisicao_idempotente SET resultado = $4 WHERE chave = $1 AND operacao = $2 AND usuario_id = $3`,
+		chave, operacao, usuarioID, resultado,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao concluir chave de idempotência: %w", err)
+	}
+
+	return nil
+}
+
+// LiberarIdempotencia remove a chave de uma operação que falhou, permitindo que o reenvio seja executado
+func (r *ChamadasRepository) LiberarIdempotencia(ctx context.Context, chave, operacao string, usuarioID int) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM requisicao_idempotente WHERE chave = $1 AND operacao = $2 AND usuario_id = $3 AND resultado IS NULL`,
+		chave, operacao, usuarioID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao liberar chave de idempotência: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"sysocial/internal/chamadas/model"
+)
+
+// ========== FECHAMENTO MENSAL ==========
+
+// GetPeriodoChamada busca o fechamento/reabertura do mês da turma (nil quando não há registro)
+func (r *ChamadasRepository) GetPeriodoChamada(ctx context.Context, turmaID int, anoMes string) (*model.PeriodoChamada, error) {
+	query := `
+		SELECT turma_id_turma, ano_mes, situacao,
+		       fechado_por, to_char(fechado_em, 'YYYY-MM-DD"T"HH24:MI:SS'),
+		       reaberto_por, to_char(reaberto_em, 'YYYY-MM-DD"T"HH24:MI:SS'), to_char(reaberto_ate, 'YYYY-MM-DD'),
+		       COALESCE(motivo, '')
+		FROM periodo_chamada
+		WHERE turma_id_turma = $1 AND ano_mes = $2`
+
+	var periodo model.PeriodoChamada
+	var fechadoPor, reabertoPor sql.NullInt64
+	var fechadoEm, reabertoEm, reabertoAte sql.NullString
+	err := r.db.QueryRowContext(ctx, query, turmaID, anoMes).Scan(
+		&periodo.TurmaID, &periodo.AnoMes, &periodo.Situacao,
+		&fechadoPor, &fechadoEm,
+		&reabertoPor, &reabertoEm, &reabertoAte,
+		&periodo.Motivo,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar período da chamada: %w", err)
+	}
+
+	periodo.FechadoPor = nullIntPtr(fechadoPor)
+	periodo.ReabertoPor = nullIntPtr(reabertoPor)
+	periodo.FechadoEm = nullStringPtr(fechadoEm)
+	periodo.ReabertoEm = nullStringPtr(reabertoEm)
+	periodo.ReabertoAte = nullStringPtr(reabertoAte)
+	return &periodo, nil
+}
+
+// FecharPeriodoChamada fecha o mês da turma: nenhuma chamada ou presença do mês pode mais ser alterada
+func (r *ChamadasRepository) FecharPeriodoChamada(ctx context.Context, turmaID int, anoMes string, usuarioID int) error {
+	query := `
+		INSERT INTO periodo_chamada (turma_id_turma, ano_mes, situacao, fechado_por, fechado_em)
+		VALUES ($1, $2, 'FECHADO', $3, now())
+		ON CONFLICT (turma_id_turma, ano_mes) DO UPDATE
+		SET situacao = 'FECHADO', fechado_por = EXCLUDED.fechado_por, fechado_em = EXCLUDED.fechado_em,
+		    reaberto_ate = NULL, motivo = NULL`
+
+	_, err := r.db.ExecContext(ctx, query, turmaID, anoMes, nullableID(usuarioID))
+	if err != nil {
+		return fmt.Errorf("erro ao fechar período da chamada: %w", err)
+	}
+
+	return nil
+}
+
+// ReabrirPeriodoChamada libera a edição do mês da turma até a data informada (AAAA-MM-DD)
+func (r *ChamadasRepository) ReabrirPeriodoChamada(ctx context.Context, turmaID int, anoMes, ate, motivo string, usuarioID int) error {
+	query := `
+		INSERT INTO periodo_chamada (turma_id_turma, ano_mes, situacao, reaberto_por, reaberto_em, reaberto_ate, motivo)
+		VALUES ($1, $2, 'REABERTO', $3, now(), $4, $5)
+		ON CONFLICT (turma_id_turma, ano_mes) DO UPDATE
+		SET situacao = 'REABERTO', reaberto_por = EXCLUDED.reaberto_por, reaberto_em = EXCLUDED.reaberto_em,
+		    reaberto_ate = EXCLUDED.reaberto_ate, motivo = EXCLUDED.motivo`
+
+	_, err := r.db.ExecContext(ctx, query, turmaID, anoMes, nullableID(usuarioID), ate, motivo)
+	if err != nil {
+		return fmt.Errorf("erro ao reabrir período da chamada: %w", err)
+	}
+
+	return nil
+}
+
+// nullableID grava NULL quando não há usuário identificado
+func nullableID(id int) interface{} {
+	if id > 0 {
+		return id
+	}
+	return nil
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	id := int(v.Int64)
+	return &id
+}
+
+func nullStringPtr(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// ========== PROFESSORES DA TURMA ==========
+
+// ProfessorAtribuido informa se o usuário é professor (titular ou substituto) da turma na data (AAAA-MM-DD).
+// As atribuições (turma_professor) são mantidas pelo cursosturmas-service.
+func (r *ChamadasRepository) ProfessorAtribuido(ctx context.Context, turmaID, usuarioID int, dataAula string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM turma_professor
+			WHERE turma_id_turma = $1 AND usuario_id_usuario = $2
+			  AND data_inicio <= $3::date
+			  AND (data_fim IS NULL OR data_fim >= $3::date)
+		)`
+
+	var atribuido bool
+	if err := r.db.QueryRowContext(ctx, query, turmaID, usuarioID, dataAula).Scan(&atribuido); err != nil {
+		return false, fmt.Errorf("erro ao verificar professor da turma: %w", err)
+	}
+	return atribuido, nil
+}
@@ -3,9 +3,15 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"sysocial/internal/chamadas/model"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type ChamadasRepository struct {
@@ -18,20 +24,28 @@ func NewChamadasRepository(db *sql.DB) *ChamadasRepository {
 
 // ========== MÉTODOS PARA CHAMADA ==========
 
-// CreateChamada cria uma nova chamada
-func (r *ChamadasRepository) CreateChamada(ctx context.Context, payload model.CreateChamadaPayload) (int, error) {
+// ErrChamadaDuplicada indica que a turma já tem chamada na data (constraint chamada_turma_data_key)
+var ErrChamadaDuplicada = errors.New("já existe chamada para a turma nesta data")
+
+// CreateChamada cria uma nova chamada aberta pelo usuário; ErrChamadaDuplicada se outra requisição já criou a da
+// mesma turma e data
+func (r *ChamadasRepository) CreateChamada(ctx context.Context, payload model.CreateChamadaPayload, usuarioID int) (int, error) {
 	query := `
 		INSERT INTO chamada (users_id_usuario, turmas_id_turma, data_aula)
 		VALUES ($1, $2, $3)
+		ON CONFLICT (turmas_id_turma, data_aula) DO NOTHING
 		RETURNING id_chamada`
 
 	var id int
 	err := r.db.QueryRowContext(ctx, query,
-		payload.UsuarioID,
+		usuarioID,
 		payload.TurmaID,
 		payload.DataAula,
 	).Scan(&id)
 
+	if err == sql.ErrNoRows {
+		return 0, ErrChamadaDuplicada
+	}
 	if err != nil {
 		return 0, fmt.Errorf("erro ao criar chamada: %w", err)
 	}
@@ -42,7 +56,7 @@ func (r *ChamadasRepository) CreateChamada(ctx context.Context, payload model.Cr
 // GetChamadaByID busca uma chamada por ID
 func (r *ChamadasRepository) GetChamadaByID(ctx context.Context, id int) (*model.Chamada, error) {
 	query := `
-		SELECT id_chamada, users_id_usuario, turmas_id_turma, data_aula
+		SELECT id_chamada, users_id_usuario, turmas_id_turma, to_char(data_aula, 'YYYY-MM-DD')
 		FROM chamada
 		WHERE id_chamada = $1`
 
@@ -96,18 +110,13 @@ func (r *ChamadasRepository) GetChamadasByTurmaID(ctx context.Context, turmaID i
 	return chamadas, nil
 }
 
-// UpdateChamada atualiza uma chamada
+// UpdateChamada atualiza uma chamada; ErrChamadaDuplicada se a turma já tem outra chamada na nova data
 func (r *ChamadasRepository) UpdateChamada(ctx context.Context, id int, payload model.UpdateChamadaPayload) error {
 	chamada, err := r.GetChamadaByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	usuarioID := chamada.UsuarioID
-	if payload.UsuarioID != nil {
-		usuarioID = *payload.UsuarioID
-	}
-
 	turmaID := chamada.TurmaID
 	if payload.TurmaID != nil {
 		turmaID = *payload.TurmaID
@@ -120,10 +129,14 @@ func (r *ChamadasRepository) UpdateChamada(ctx context.Context, id int, payload
 
 	query := `
 		UPDATE chamada
-		SET users_id_usuario = $1, turmas_id_turma = $2, data_aula = $3
-		WHERE id_chamada = $4`
+		SET turmas_id_turma = $1, data_aula = $2
+		WHERE id_chamada = $3`
 
-	_, err = r.db.ExecContext(ctx, query, usuarioID, turmaID, dataAula, id)
+	_, err = r.db.ExecContext(ctx, query, turmaID, dataAula, id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "chamada_turma_data_key" {
+		return ErrChamadaDuplicada
+	}
 	if err != nil {
 		return fmt.Errorf("erro ao atualizar chamada: %w", err)
 	}
@@ -195,79 +208,30 @@ func (r *ChamadasRepository) GetPresencasByChamadaID(ctx context.Context, chamad
 	return presencas, nil
 }
 
-// CreatePresencas cria OU atualiza presenças (Upsert Manual)
+// CreatePresencas cria ou atualiza as presenças da chamada (um registro por aluno)
 func (r *ChamadasRepository) CreatePresencas(ctx context.Context, chamadaID int, presencas []model.CreatePresencaPayload) error {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("erro ao iniciar transação: %w", err)
-	}
-
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-		} else if err != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Query de Update (Tenta atualizar se já existe)
-	updateQuery := `
-		UPDATE presenca 
-		SET presente = $1, observacao = $2 
-		WHERE chamada_id_chamada = $3 AND aluno_id_aluno = $4`
-
-	// Query de Insert (Cria se não existir)
-	insertQuery := `
-		INSERT INTO presenca (chamada_id_chamada, aluno_id_aluno, presente, observacao)
-		VALUES ($1, $2, $3, $4)`
-
+	alunos := make([]int, 0, len(presencas))
+	presentes := make([]string, 0, len(presencas))
+	observacoes := make([]string, 0, len(presencas))
 	for _, presenca := range presencas {
-		var observacao interface{}
-		if presenca.Observacao != "" {
-			observacao = presenca.Observacao
-		}
-
-		// 1. Tenta UPDATE
-		res, err := tx.ExecContext(ctx, updateQuery,
-			presenca.Presente, // String (P, F, FJ)
-			observacao,
-			chamadaID,
-			presenca.AlunoID,
-		)
-		if err != nil {
-			return fmt.Errorf("erro ao atualizar presença: %w", err)
-		}
-
-		rows, err := res.RowsAffected()
-		if err != nil {
-			return fmt.Errorf("erro ao verificar linhas afetadas: %w", err)
-		}
-
-		// 2. Se UPDATE não afetou nenhuma linha (registro não existe), faz INSERT
-		if rows == 0 {
-			_, err = tx.ExecContext(ctx, insertQuery,
-				chamadaID,
-				presenca.AlunoID,
-				presenca.Presente,
-				observacao,
-			)
-			if err != nil {
-				return fmt.Errorf("erro ao inserir presença: %w", err)
-			}
-		}
-	}
-
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("erro ao commitar transação: %w", err)
+		alunos = append(alunos, presenca.AlunoID)
+		presentes = append(presentes, presenca.Presente) // Código já validado em status_presenca
+		observacoes = append(observacoes, presenca.Observacao)
 	}
 
-	return nil
+	return r.salvarPresencas(ctx, chamadaID, alunos, presentes, observacoes)
 }
 
-// DeletePresencasByChamadaID deleta todas as presenças de uma chamada
+// DeletePresencasByChamadaID deleta todas as presenças de uma chamada, com os documentos de justificativa
 func (r *ChamadasRepository) DeletePresencasByChamadaID(ctx context.Context, chamadaID int) error {
-	query := `DELETE FROM presenca WHERE chamada_id_chamada = $1`
-	_, err := r.db.ExecContext(ctx, query, chamadaID)
+	query := `
+		WITH removidas AS (
+			DELETE FROM presenca WHERE chamada_id_chamada = $1
+			RETURNING id_presenca
+		)
+		DELETE FROM anexos
+		WHERE entidade_pai = $2 AND id_entidade_pai IN (SELECT id_presenca::text FROM removidas)`
+	_, err := r.db.ExecContext(ctx, query, chamadaID, EntidadeAnexoPresenca)
 	if err != nil {
 		return fmt.Errorf("erro ao deletar presenças: %w", err)
 	}
@@ -277,99 +241,82 @@ func (r *ChamadasRepository) DeletePresencasByChamadaID(ctx context.Context, cha
 
 // UpsertPresencas cria ou atualiza múltiplas presenças
 func (r *ChamadasRepository) UpsertPresencas(ctx context.Context, payload model.UpsertPresencasPayload) error {
-	// Verificar se chamada existe
-	_, err := r.GetChamadaByID(ctx, payload.ChamadaID)
-	if err != nil {
-		return fmt.Errorf("chamada não encontrada: %w", err)
+	alunos := make([]int, 0, len(payload.Records))
+	presentes := make([]string, 0, len(payload.Records))
+	observacoes := make([]string, 0, len(payload.Records))
+	for _, record := range payload.Records {
+		alunos = append(alunos, record.IDEstudante)
+		presentes = append(presentes, record.Present) // Código já validado em status_presenca
+		observacoes = append(observacoes, record.Observation)
 	}
 
-	// Iniciar transação
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("erro ao iniciar transação: %w", err)
-	}
+	return r.salvarPresencas(ctx, payload.ChamadaID, alunos, presentes, observacoes)
+}
 
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-		} else if err != nil {
-			tx.Rollback()
+// salvarPresencas grava as presenças da chamada em um único INSERT ... ON CONFLICT: cria as que faltam e
+// atualiza as existentes. A constraint presenca_chamada_aluno_key impede duplicatas em envios simultâneos;
+// se o mesmo aluno vier mais de uma vez, vale o último registro.
+func (r *ChamadasRepository) salvarPresencas(ctx context.Context, chamadaID int, alunos []int, presentes, observacoes []string) error {
+	posicao := make(map[int]int, len(alunos))
+	var ids []int
+	var valores, obs []string
+	for i, alunoID := range alunos {
+		if j, ok := posicao[alunoID]; ok {
+			valores[j], obs[j] = presentes[i], observacoes[i]
+			continue
 		}
-	}()
-
-	// Query para verificar se presença existe
-	queryCheck := `SELECT id_presenca FROM presenca WHERE chamada_id_chamada = $1 AND aluno_id_aluno = $2`
+		posicao[alunoID] = len(ids)
+		ids = append(ids, alunoID)
+		valores = append(valores, presentes[i])
+		obs = append(obs, observacoes[i])
+	}
 
-	// Query para inserir
-	queryInsert := `
+	query := `
 		INSERT INTO presenca (chamada_id_chamada, aluno_id_aluno, presente, observacao)
-		VALUES ($1, $2, $3, $4)`
-
-	// Query para atualizar
-	queryUpdate := `
-		UPDATE presenca
-		SET presente = $1, observacao = $2
-		WHERE chamada_id_chamada = $3 AND aluno_id_aluno = $4`
-
-	for _, record := range payload.Records {
-		// Verificar se aluno existe (usando a transação)
-		var count int
-		queryAluno := `SELECT COUNT(*) FROM aluno WHERE id_aluno = $1 AND ativo = true`
-		err = tx.QueryRowContext(ctx, queryAluno, record.IDEstudante).Scan(&count)
-		if err != nil {
-			return fmt.Errorf("erro ao verificar aluno %d: %w", record.IDEstudante, err)
-		}
-		if count == 0 {
-			return fmt.Errorf("aluno %d não encontrado ou inativo", record.IDEstudante)
-		}
+		SELECT $1, p.aluno, p.presente, NULLIF(p.observacao, '')
+		FROM unnest($2::int[], $3::text[], $4::text[]) AS p(aluno, presente, observacao)
+		ON CONFLICT (chamada_id_chamada, aluno_id_aluno)
+		DO UPDATE SET presente = EXCLUDED.presente, observacao = EXCLUDED.observacao`
 
-		// Verificar se presença já existe
-		var presencaID int
-		err = tx.QueryRowContext(ctx, queryCheck, payload.ChamadaID, record.IDEstudante).Scan(&presencaID)
-
-		var observacao interface{}
-		if record.Observation != "" {
-			observacao = record.Observation
-		}
+	_, err := r.db.ExecContext(ctx, query, chamadaID, pq.Array(ids), pq.Array(valores), pq.Array(obs))
+	if err != nil {
+		return fmt.Errorf("erro ao salvar presenças: %w", err)
+	}
 
-		// Se presente está vazio, usar default 'F '
-		presente := record.Present
-		if presente == "" {
-			presente = "F "
-		}
+	return nil
+}
 
-		if err == sql.ErrNoRows {
-			// Não existe, criar novo registro
-			_, err = tx.ExecContext(ctx, queryInsert,
-				payload.ChamadaID,
-				record.IDEstudante,
-				presente,
-				observacao,
-			)
-			if err != nil {
-				return fmt.Errorf("erro ao inserir presença para aluno %d: %w", record.IDEstudante, err)
-			}
-		} else if err != nil {
-			return fmt.Errorf("erro ao verificar presença: %w", err)
-		} else {
-			// Existe, atualizar
-			_, err = tx.ExecContext(ctx, queryUpdate,
-				presente,
-				observacao,
-				payload.ChamadaID,
-				record.IDEstudante,
-			)
-			if err != nil {
-				return fmt.Errorf("erro ao atualizar presença para aluno %d: %w", record.IDEstudante, err)
-			}
-		}
+// matriculaVigenteSQL é verdadeiro quando o aluno (%[1]s) tem matrícula vigente na turma (%[2]s) na data (%[3]s);
+// pré-inscrições ainda não entram na lista de chamada
+const matriculaVigenteSQL = `EXISTS (
+			SELECT 1 FROM matricula m
+			WHERE m.aluno_id_aluno = %[1]s AND m.turmas_id_turma = %[2]s AND m.status <> 'PRE_INSCRITO'
+			  AND m.data_inicio <= %[3]s AND (m.data_fim IS NULL OR m.data_fim >= %[3]s))`
+
+// GetAlunosSemMatricula devolve, entre os IDs informados, os alunos sem matrícula vigente na turma na data da aula
+func (r *ChamadasRepository) GetAlunosSemMatricula(ctx context.Context, turmaID int, dataAula string, alunoIDs []int) ([]int, error) {
+	query := fmt.Sprintf(`
+		SELECT DISTINCT id
+		FROM unnest($1::int[]) AS id
+		WHERE NOT %s
+		ORDER BY id`, fmt.Sprintf(matriculaVigenteSQL, "id", "$2", "$3::date"))
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(alunoIDs), turmaID, dataAula)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar matrículas dos alunos: %w", err)
 	}
+	defer rows.Close()
 
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("erro ao commitar transação: %w", err)
+	var semMatricula []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("erro ao escanear aluno: %w", err)
+		}
+		semMatricula = append(semMatricula, id)
 	}
 
-	return nil
+	return semMatricula, rows.Err()
 }
 
 // VerificaTurmaExiste verifica se uma turma existe
@@ -394,20 +341,73 @@ func (r *ChamadasRepository) VerificaAlunoExiste(ctx context.Context, alunoID in
 	return count > 0, nil
 }
 
-// GetChamadasPorTurmaMes busca chamadas por turma e mês/ano, criando chamadas se necessário
-func (r *ChamadasRepository) GetChamadasPorTurmaMes(ctx context.Context, turmaID int, anoMes string, usuarioID int) (*model.ChamadasPorTurmaMesResponse, error) {
-	// Parse anoMes (formato: AAAAMM)
-	if len(anoMes) != 6 {
-		return nil, fmt.Errorf("formato de ano/mês inválido. Use AAAAMM (ex: 202511)")
+// GetChamadasPorTurmaMes monta a tela de chamada da turma no mês sem gravar nada: as datas da grade ainda
+// sem chamada aparecem com id 0 e só passam a existir quando a chamada é aberta (AbrirChamada/GerarChamadas)
+func (r *ChamadasRepository) GetChamadasPorTurmaMes(ctx context.Context, turmaID int, anoMes string) (*model.ChamadasPorTurmaMesResponse, error) {
+	freq, err := r.GetFrequenciaTurmaMes(ctx, turmaID, anoMes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Toda data em que o aluno estava matriculado tem entrada, mesmo sem presença lançada; datas fora da
+	// matrícula ficam sem entrada (o aluno não deve receber presença nelas)
+	for _, aluno := range freq.Alunos {
+		for _, aula := range freq.Datas {
+			if _, ok := aluno.Presencas[aula.Data]; !ok && aluno.MatriculadoEm(aula.Data) {
+				aluno.Presencas[aula.Data] = model.PresencaPorData{}
+			}
+		}
+	}
+
+	datas := freq.Datas
+	if datas == nil {
+		datas = []model.DataChamada{}
+	}
+
+	return &model.ChamadasPorTurmaMesResponse{
+		Datas:       datas,
+		DiasSemAula: freq.DiasSemAula,
+		Alunos:      freq.Alunos,
+	}, nil
+}
+
+// GetTurmasByCurso lista os IDs das turmas de um curso, em ordem de nome
+func (r *ChamadasRepository) GetTurmasByCurso(ctx context.Context, cursoID int) ([]int, error) {
+	query := `SELECT id_turma FROM turma WHERE cursos_id_curso = $1 ORDER BY nome_turma`
+
+	rows, err := r.db.QueryContext(ctx, query, cursoID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar turmas do curso: %w", err)
+	}
+	defer rows.Close()
+
+	var turmas []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("erro ao escanear turma: %w", err)
+		}
+		turmas = append(turmas, id)
 	}
 
-	ano := anoMes[:4]
-	mes := anoMes[4:]
+	return turmas, rows.Err()
+}
 
-	// Buscar turma e dia da semana
-	var diaSemana string
-	queryTurma := `SELECT dia_semana FROM turma WHERE id_turma = $1`
-	err := r.db.QueryRowContext(ctx, queryTurma, turmaID).Scan(&diaSemana)
+// GetFrequenciaTurmaMes monta a frequência de uma turma no mês sem criar chamadas: as datas são as do dia
+// da semana da turma (ajustadas pelo calendário institucional) mais as chamadas já registradas no mês
+func (r *ChamadasRepository) GetFrequenciaTurmaMes(ctx context.Context, turmaID int, anoMes string) (*model.FrequenciaTurmaMes, error) {
+	ano, mes, err := parseAnoMes(anoMes)
+	if err != nil {
+		return nil, err
+	}
+
+	freq := &model.FrequenciaTurmaMes{TurmaID: turmaID}
+	queryTurma := `
+		SELECT t.nome_turma, c.id_curso, c.nome
+		FROM turma t
+		INNER JOIN curso c ON t.cursos_id_curso = c.id_curso
+		WHERE t.id_turma = $1`
+	err = r.db.QueryRowContext(ctx, queryTurma, turmaID).Scan(&freq.TurmaNome, &freq.CursoID, &freq.CursoNome)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("turma não encontrada")
@@ -415,221 +415,228 @@ func (r *ChamadasRepository) GetChamadasPorTurmaMes(ctx context.Context, turmaID
 		return nil, fmt.Errorf("erro ao buscar turma: %w", err)
 	}
 
-	// Mapear dia da semana em português para time.Weekday
-	diaSemanaMap := map[string]time.Weekday{
-		"Domingo":       time.Sunday,
-		"Segunda-feira": time.Monday,
-		"Terça-feira":   time.Tuesday,
-		"Quarta-feira":  time.Wednesday,
-		"Quinta-feira":  time.Thursday,
-		"Sexta-feira":   time.Friday,
-		"Sábado":        time.Saturday,
-		"Segunda":       time.Monday,
-		"Terça":         time.Tuesday,
-		"Quarta":        time.Wednesday,
-		"Quinta":        time.Thursday,
-		"Sexta":         time.Friday,
+	diasDeAula, err := r.getDiasDeAula(ctx, turmaID)
+	if err != nil {
+		return nil, err
 	}
-
-	targetWeekday, ok := diaSemanaMap[diaSemana]
-	if !ok {
-		return nil, fmt.Errorf("dia da semana inválido: %s", diaSemana)
+	aulas, diasSemAula, err := r.datasDeAulaDoMes(ctx, turmaID, ano, mes, calcularDatasDoMes(ano, mes, diasDeAula...))
+	if err != nil {
+		return nil, err
 	}
+	freq.DiasSemAula = diasSemAula
 
-	// Parse ano e mês
-	anoInt := 0
-	mesInt := 0
-	fmt.Sscanf(ano, "%d", &anoInt)
-	fmt.Sscanf(mes, "%d", &mesInt)
-
-	if mesInt < 1 || mesInt > 12 {
-		return nil, fmt.Errorf("mês inválido: %d", mesInt)
+	chamadasMap := make(map[string]model.DataChamada) // data -> aula (ID = id_chamada)
+	for _, aula := range aulas {
+		chamadasMap[aula.Data] = aula
 	}
 
-	// Calcular datas do mês que correspondem ao dia da semana
-	datas := calcularDatasDoMes(anoInt, time.Month(mesInt), targetWeekday)
-
-	// Para cada data, criar chamada se não existir
-	chamadasMap := make(map[string]int) // data -> id_chamada
-	for _, data := range datas {
-		// Verificar se chamada já existe
-		var chamadaID int
-		queryChamada := `SELECT id_chamada FROM chamada WHERE turmas_id_turma = $1 AND data_aula = $2`
-		err := r.db.QueryRowContext(ctx, queryChamada, turmaID, data).Scan(&chamadaID)
-
-		if err == sql.ErrNoRows {
-			// Criar chamada
-			queryInsert := `
-				INSERT INTO chamada (users_id_usuario, turmas_id_turma, data_aula)
-				VALUES ($1, $2, $3)
-				RETURNING id_chamada`
-			err = r.db.QueryRowContext(ctx, queryInsert, usuarioID, turmaID, data).Scan(&chamadaID)
-			if err != nil {
-				return nil, fmt.Errorf("erro ao criar chamada para data %s: %w", data, err)
-			}
-		} else if err != nil {
-			return nil, fmt.Errorf("erro ao verificar chamada: %w", err)
+	// Chamadas abertas em dias que o calendário suspendeu não entram na frequência
+	inicio := time.Date(ano, mes, 1, 0, 0, 0, 0, time.UTC)
+	queryChamadas := fmt.Sprintf(`
+		SELECT c.id_chamada, to_char(c.data_aula, 'YYYY-MM-DD')
+		FROM chamada c
+		WHERE c.turmas_id_turma = $1 AND c.data_aula >= $2 AND c.data_aula < $3
+		  AND NOT %s`, fmt.Sprintf(diaSemAulaSQL, "c.turmas_id_turma", "c.data_aula"))
+	rows, err := r.db.QueryContext(ctx, queryChamadas, turmaID, inicio, inicio.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar chamadas: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("erro ao escanear chamada: %w", err)
 		}
+		aula := chamadasMap[data]
+		aula.Data, aula.ID = data, id
+		chamadasMap[data] = aula
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		chamadasMap[data] = chamadaID
+	for _, aula := range chamadasMap {
+		freq.Datas = append(freq.Datas, aula)
 	}
+	sort.Slice(freq.Datas, func(i, j int) bool { return freq.Datas[i].Data < freq.Datas[j].Data })
 
-	// Buscar alunos da turma
+	// Alunos com matrícula vigente na turma em algum dia do mês, com os períodos de cada matrícula: alunos que
+	// saíram continuam nos meses em que estavam matriculados, e quem entrou no meio do mês só conta dali em diante
 	queryAlunos := `
-		SELECT DISTINCT a.id_aluno, a.nome_completo
-		FROM aluno a
-		INNER JOIN matricula m ON a.id_aluno = m.aluno_id_aluno
-		WHERE m.turmas_id_turma = $1
-		  AND a.ativo = true
-		ORDER BY a.nome_completo`
-
-	rows, err := r.db.QueryContext(ctx, queryAlunos, turmaID)
+		SELECT a.id_aluno, a.nome_completo, to_char(m.data_inicio, 'YYYY-MM-DD'), to_char(m.data_fim, 'YYYY-MM-DD')
+		FROM matricula m
+		INNER JOIN aluno a ON a.id_aluno = m.aluno_id_aluno
+		WHERE m.turmas_id_turma = $1 AND m.status <> 'PRE_INSCRITO'
+		  AND m.data_inicio < $3
+		  AND (m.data_fim IS NULL OR m.data_fim >= $2)
+		ORDER BY a.nome_completo, a.id_aluno, m.data_inicio`
+	rowsAlunos, err := r.db.QueryContext(ctx, queryAlunos, turmaID, inicio, inicio.AddDate(0, 1, 0))
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar alunos: %w", err)
 	}
-	defer rows.Close()
+	defer rowsAlunos.Close()
 
-	var alunos []model.AlunoPresencas
-	for rows.Next() {
+	alunoIndex := make(map[int]int)
+	for rowsAlunos.Next() {
 		var alunoID int
 		var alunoNome string
-		err := rows.Scan(&alunoID, &alunoNome)
-		if err != nil {
+		var periodo model.PeriodoMatricula
+		var dataFim sql.NullString
+		if err := rowsAlunos.Scan(&alunoID, &alunoNome, &periodo.DataInicio, &dataFim); err != nil {
 			return nil, fmt.Errorf("erro ao escanear aluno: %w", err)
 		}
-
-		aluno := model.AlunoPresencas{
-			AlunoID:   alunoID,
-			AlunoNome: alunoNome,
-			Presencas: make(map[string]model.PresencaPorData),
+		periodo.DataFim = nullStringPtr(dataFim)
+
+		i, ok := alunoIndex[alunoID]
+		if !ok {
+			i = len(freq.Alunos)
+			alunoIndex[alunoID] = i
+			freq.Alunos = append(freq.Alunos, model.AlunoPresencas{
+				AlunoID:   alunoID,
+				AlunoNome: alunoNome,
+				Presencas: make(map[string]model.PresencaPorData),
+			})
 		}
+		freq.Alunos[i].Matriculas = append(freq.Alunos[i].Matriculas, periodo)
+	}
+	if err := rowsAlunos.Err(); err != nil {
+		return nil, err
+	}
 
-		// Inicializar presenças para todas as datas
-		for _, data := range datas {
-			aluno.Presencas[data] = model.PresencaPorData{
-				Present:     "",
-				Observation: "",
-			}
-		}
+	queryPresencas := fmt.Sprintf(`
+		SELECT p.id_presenca, p.aluno_id_aluno, COALESCE(p.presente, ''), COALESCE(p.observacao, ''), to_char(c.data_aula, 'YYYY-MM-DD'),
+		       (SELECT count(*) FROM anexos an WHERE an.entidade_pai = 'presenca' AND an.id_entidade_pai = p.id_presenca::text)
+		FROM presenca p
+		INNER JOIN chamada c ON p.chamada_id_chamada = c.id_chamada
+		WHERE c.turmas_id_turma = $1 AND c.data_aula >= $2 AND c.data_aula < $3
+		  AND NOT %s`, fmt.Sprintf(diaSemAulaSQL, "c.turmas_id_turma", "c.data_aula"))
+	rowsPresencas, err := r.db.QueryContext(ctx, queryPresencas, turmaID, inicio, inicio.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar presenças: %w", err)
+	}
+	defer rowsPresencas.Close()
 
-		alunos = append(alunos, aluno)
+	for rowsPresencas.Next() {
+		var presencaID, alunoID, anexos int
+		var presente, observacao, data string
+		if err := rowsPresencas.Scan(&presencaID, &alunoID, &presente, &observacao, &data, &anexos); err != nil {
+			return nil, fmt.Errorf("erro ao escanear presença: %w", err)
+		}
+		if i, ok := alunoIndex[alunoID]; ok {
+			id := presencaID
+			freq.Alunos[i].Presencas[data] = model.PresencaPorData{PresencaID: &id, Present: presente, Observation: observacao, Anexos: anexos}
+		}
 	}
 
-	// Buscar presenças existentes para todas as chamadas
-	if len(chamadasMap) > 0 {
-		// Construir lista de IDs de chamadas e placeholders
-		args := make([]interface{}, 0, len(chamadasMap))
-		placeholders := make([]string, 0, len(chamadasMap))
+	return freq, rowsPresencas.Err()
+}
 
-		argIndex := 1
-		for _, id := range chamadasMap {
-			placeholders = append(placeholders, fmt.Sprintf("$%d", argIndex))
-			args = append(args, id)
-			argIndex++
-		}
+// GetRegistrosFrequencia lista as presenças lançadas no período, ordenadas por curso, turma, aluno e data.
+// Com apenasAtivos, considera só alunos ativos com matrícula ativa na turma (relatório de risco de evasão).
+func (r *ChamadasRepository) GetRegistrosFrequencia(ctx context.Context, filtro model.FrequenciaFiltro, apenasAtivos bool) ([]model.RegistroFrequencia, error) {
+	// Dias suspensos pelo calendário (feriados, recessos, cancelamentos) não contam como aula
+	conditions := []string{"c.data_aula >= $1", "c.data_aula <= $2", "NOT " + fmt.Sprintf(diaSemAulaSQL, "t.id_turma", "c.data_aula")}
+	args := []interface{}{filtro.DataInicio, filtro.DataFim}
+
+	if filtro.CursoID > 0 {
+		args = append(args, filtro.CursoID)
+		conditions = append(conditions, fmt.Sprintf("cu.id_curso = $%d", len(args)))
+	}
+	if filtro.TurmaID > 0 {
+		args = append(args, filtro.TurmaID)
+		conditions = append(conditions, fmt.Sprintf("t.id_turma = $%d", len(args)))
+	}
+	if filtro.AlunoID > 0 {
+		args = append(args, filtro.AlunoID)
+		conditions = append(conditions, fmt.Sprintf("a.id_aluno = $%d", len(args)))
+	}
+	if apenasAtivos {
+		conditions = append(conditions, `a.ativo = true AND EXISTS (
+			SELECT 1 FROM matricula m
+			WHERE m.aluno_id_aluno = a.id_aluno AND m.turmas_id_turma = t.id_turma AND m.status = 'ATIVO')`)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT a.id_aluno, a.nome_completo, t.id_turma, t.nome_turma, cu.id_curso, cu.nome,
+		       to_char(c.data_aula, 'YYYY-MM-DD'), COALESCE(p.presente, '')
+		FROM presenca p
+		INNER JOIN chamada c ON p.chamada_id_chamada = c.id_chamada
+		INNER JOIN turma t ON c.turmas_id_turma = t.id_turma
+		INNER JOIN curso cu ON t.cursos_id_curso = cu.id_curso
+		INNER JOIN aluno a ON p.aluno_id_aluno = a.id_aluno
+		WHERE %s
+		ORDER BY cu.nome, cu.id_curso, t.nome_turma, t.id_turma, a.nome_completo, a.id_aluno, c.data_aula`,
+		strings.Join(conditions, " AND "))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar registros de frequência: %w", err)
+	}
+	defer rows.Close()
 
-		// Query para buscar todas as presenças de uma vez usando IN
-		placeholdersStr := ""
-		for i, ph := range placeholders {
-			if i > 0 {
-				placeholdersStr += ", "
-			}
-			placeholdersStr += ph
+	var registros []model.RegistroFrequencia
+	for rows.Next() {
+		var reg model.RegistroFrequencia
+		if err := rows.Scan(&reg.AlunoID, &reg.AlunoNome, &reg.TurmaID, &reg.TurmaNome, &reg.CursoID, &reg.CursoNome, &reg.DataAula, &reg.Presente); err != nil {
+			return nil, fmt.Errorf("erro ao escanear registro de frequência: %w", err)
 		}
+		registros = append(registros, reg)
+	}
 
-		queryPresencas := fmt.Sprintf(`
-			SELECT p.id_presenca, p.chamada_id_chamada, p.aluno_id_aluno, 
-			       COALESCE(p.presente, '') as presente, 
-			       COALESCE(p.observacao, '') as observacao,
-			       c.data_aula
-			FROM presenca p
-			INNER JOIN chamada c ON p.chamada_id_chamada = c.id_chamada
-			WHERE p.chamada_id_chamada IN (%s)`, placeholdersStr)
-
-		rowsPresencas, err := r.db.QueryContext(ctx, queryPresencas, args...)
-		if err != nil && err != sql.ErrNoRows {
-			return nil, fmt.Errorf("erro ao buscar presenças: %w", err)
-		}
+	return registros, rows.Err()
+}
 
-		if err == nil {
-			defer rowsPresencas.Close()
-
-			// Mapear presenças por aluno e data
-			for rowsPresencas.Next() {
-				var presencaID int
-				var chamadaID int
-				var alunoID int
-				var presente sql.NullString
-				var observacao sql.NullString
-				var dataAula string
-
-				err := rowsPresencas.Scan(&presencaID, &chamadaID, &alunoID, &presente, &observacao, &dataAula)
-				if err != nil {
-					return nil, fmt.Errorf("erro ao escanear presença: %w", err)
-				}
-
-				// Encontrar aluno e atualizar presença
-				for i := range alunos {
-					if alunos[i].AlunoID == alunoID {
-						presencaData := model.PresencaPorData{
-							PresencaID:  &presencaID,
-							Present:     "",
-							Observation: "",
-						}
-
-						if presente.Valid {
-							presencaData.Present = presente.String
-						}
-						if observacao.Valid {
-							presencaData.Observation = observacao.String
-						}
-
-						alunos[i].Presencas[dataAula] = presencaData
-						break
-					}
-				}
-			}
-		}
+// parseAnoMes interpreta o período no formato AAAAMM (ex: 202511)
+func parseAnoMes(anoMes string) (int, time.Month, error) {
+	if len(anoMes) != 6 {
+		return 0, 0, fmt.Errorf("formato de ano/mês inválido. Use AAAAMM (ex: 202511)")
 	}
 
-	// Construir array de datas com IDs das chamadas
-	datasComIDs := make([]model.DataChamada, 0, len(datas))
-	for _, data := range datas {
-		chamadaID, exists := chamadasMap[data]
-		if exists {
-			datasComIDs = append(datasComIDs, model.DataChamada{
-				Data: data,
-				ID:   chamadaID,
-			})
-		}
+	ano, errAno := strconv.Atoi(anoMes[:4])
+	mes, errMes := strconv.Atoi(anoMes[4:])
+	if errAno != nil || errMes != nil {
+		return 0, 0, fmt.Errorf("formato de ano/mês inválido. Use AAAAMM (ex: 202511)")
+	}
+	if mes < 1 || mes > 12 {
+		return 0, 0, fmt.Errorf("mês inválido: %d", mes)
 	}
 
-	return &model.ChamadasPorTurmaMesResponse{
-		Datas:  datasComIDs,
-		Alunos: alunos,
-	}, nil
+	return ano, time.Month(mes), nil
 }
 
-// calcularDatasDoMes calcula todas as datas de um mês que correspondem a um dia da semana
-func calcularDatasDoMes(ano int, mes time.Month, weekday time.Weekday) []string {
-	// Primeiro dia do mês
-	primeiroDia := time.Date(ano, mes, 1, 0, 0, 0, 0, time.UTC)
+// getDiasDeAula lista os dias da semana em que a turma tem aula (grade em turma_horario)
+func (r *ChamadasRepository) getDiasDeAula(ctx context.Context, turmaID int) ([]time.Weekday, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT dia_semana FROM turma_horario WHERE turma_id_turma = $1`, turmaID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar horários da turma: %w", err)
+	}
+	defer rows.Close()
 
-	// Encontrar o primeiro dia da semana no mês
-	diasParaAdicionar := int(weekday - primeiroDia.Weekday())
-	if diasParaAdicionar < 0 {
-		diasParaAdicionar += 7
+	var dias []time.Weekday
+	for rows.Next() {
+		var dia int
+		if err := rows.Scan(&dia); err != nil {
+			return nil, fmt.Errorf("erro ao escanear horário: %w", err)
+		}
+		dias = append(dias, time.Weekday(dia))
 	}
 
-	primeiraData := primeiroDia.AddDate(0, 0, diasParaAdicionar)
+	return dias, rows.Err()
+}
 
-	var datas []string
-	dataAtual := primeiraData
+// calcularDatasDoMes calcula, em ordem, todas as datas de um mês que caem nos dias da semana informados
+func calcularDatasDoMes(ano int, mes time.Month, weekdays ...time.Weekday) []string {
+	incluir := make(map[time.Weekday]bool, len(weekdays))
+	for _, weekday := range weekdays {
+		incluir[weekday] = true
+	}
 
-	// Adicionar todas as datas do mesmo dia da semana no mês
-	for dataAtual.Month() == mes {
-		datas = append(datas, dataAtual.Format("2006-01-02"))
-		dataAtual = dataAtual.AddDate(0, 0, 7) // Adicionar 7 dias
+	var datas []string
+	for dia := time.Date(ano, mes, 1, 0, 0, 0, 0, time.UTC); dia.Month() == mes; dia = dia.AddDate(0, 0, 1) {
+		if incluir[dia.Weekday()] {
+			datas = append(datas, dia.Format("2006-01-02"))
+		}
 	}
 
 	return datas
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sysocial/internal/chamadas/model"
+
+	"github.com/lib/pq"
+)
+
+// ========== ABERTURA DE CHAMADAS (SESSÕES DE AULA) ==========
+
+// GetChamadaByTurmaData busca a chamada da turma na data (nil quando ainda não foi aberta)
+func (r *ChamadasRepository) GetChamadaByTurmaData(ctx context.Context, turmaID int, dataAula string) (*model.Chamada, error) {
+	query := `
+		SELECT id_chamada, users_id_usuario, turmas_id_turma, to_char(data_aula, 'YYYY-MM-DD')
+		FROM chamada
+		WHERE turmas_id_turma = $1 AND data_aula = $2`
+
+	var chamada model.Chamada
+	err := r.db.QueryRowContext(ctx, query, turmaID, dataAula).Scan(
+		&chamada.ID,
+		&chamada.UsuarioID,
+		&chamada.TurmaID,
+		&chamada.DataAula,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar chamada: %w", err)
+	}
+
+	return &chamada, nil
+}
+
+// AbrirChamada cria a chamada da turma na data, atribuída a quem abriu; se ela já existe, devolve a existente
+// (criada = false). A constraint chamada_turma_data_key resolve aberturas simultâneas.
+func (r *ChamadasRepository) AbrirChamada(ctx context.Context, turmaID int, dataAula string, usuarioID int) (int, bool, error) {
+	query := `
+		INSERT INTO chamada (users_id_usuario, turmas_id_turma, data_aula)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (turmas_id_turma, data_aula) DO NOTHING
+		RETURNING id_chamada`
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query, usuarioID, turmaID, dataAula).Scan(&id)
+	if err == nil {
+		return id, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, fmt.Errorf("erro ao abrir chamada: %w", err)
+	}
+
+	chamada, err := r.GetChamadaByTurmaData(ctx, turmaID, dataAula)
+	if err != nil {
+		return 0, false, err
+	}
+	if chamada == nil {
+		return 0, false, fmt.Errorf("erro ao abrir chamada: chamada de %s não encontrada após conflito", dataAula)
+	}
+
+	return chamada.ID, false, nil
+}
+
+// CreateChamadas abre de uma vez as chamadas da turma nas datas informadas, ignorando as que já existem;
+// devolve quantas foram criadas
+func (r *ChamadasRepository) CreateChamadas(ctx context.Context, turmaID int, datas []string, usuarioID int) (int, error) {
+	if len(datas) == 0 {
+		return 0, nil
+	}
+
+	query := `
+		INSERT INTO chamada (users_id_usuario, turmas_id_turma, data_aula)
+		SELECT $1, $2, d FROM unnest($3::date[]) AS d
+		ON CONFLICT (turmas_id_turma, data_aula) DO NOTHING`
+
+	res, err := r.db.ExecContext(ctx, query, usuarioID, turmaID, pq.Array(datas))
+	if err != nil {
+		return 0, fmt.Errorf("erro ao gerar chamadas: %w", err)
+	}
+
+	criadas, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao verificar chamadas criadas: %w", err)
+	}
+
+	return int(criadas), nil
+}
+
+// GetPeriodoTurma devolve o período letivo da turma (AAAA-MM-DD); vazio quando a turma não existe
+func (r *ChamadasRepository) GetPeriodoTurma(ctx context.Context, turmaID int) (string, string, error) {
+	query := `
+		SELECT to_char(data_inicio, 'YYYY-MM-DD'), to_char(data_fim, 'YYYY-MM-DD')
+		FROM turma
+		WHERE id_turma = $1`
+
+	var inicio, fim string
+	err := r.db.QueryRowContext(ctx, query, turmaID).Scan(&inicio, &fim)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("erro ao buscar turma: %w", err)
+	}
+
+	return inicio, fim, nil
+}
+
+// GetDatasDeAula lista as datas de aula da turma entre inicio e fim (AAAA-MM-DD): os dias da grade de horários,
+// já com o calendário institucional aplicado, e os dias sem aula no período
+func (r *ChamadasRepository) GetDatasDeAula(ctx context.Context, turmaID int, inicio, fim string) ([]model.DataChamada, []model.DiaSemAula, error) {
+	dataInicio, err := time.Parse("2006-01-02", inicio)
+	if err != nil {
+		return nil, nil, fmt.Errorf("data inicial inválida: %s", inicio)
+	}
+	dataFim, err := time.Parse("2006-01-02", fim)
+	if err != nil {
+		return nil, nil, fmt.Errorf("data final inválida: %s", fim)
+	}
+
+	diasDeAula, err := r.getDiasDeAula(ctx, turmaID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aulas := []model.DataChamada{}
+	diasSemAula := []model.DiaSemAula{}
+	for mes := time.Date(dataInicio.Year(), dataInicio.Month(), 1, 0, 0, 0, 0, time.UTC); !mes.After(dataFim); mes = mes.AddDate(0, 1, 0) {
+		aulasMes, semAulaMes, err := r.datasDeAulaDoMes(ctx, turmaID, mes.Year(), mes.Month(), calcularDatasDoMes(mes.Year(), mes.Month(), diasDeAula...))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, aula := range aulasMes {
+			if aula.Data >= inicio && aula.Data <= fim {
+				aulas = append(aulas, aula)
+			}
+		}
+		for _, dia := range semAulaMes {
+			if dia.Data >= inicio && dia.Data <= fim {
+				diasSemAula = append(diasSemAula, dia)
+			}
+		}
+	}
+
+	return aulas, diasSemAula, nil
+}
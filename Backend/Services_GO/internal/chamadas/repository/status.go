@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"sysocial/internal/chamadas/model"
+)
+
+// ========== STATUS DE PRESENÇA ==========
+
+// GetStatusPresenca lista os status de presença configurados (ativos e inativos), na ordem de exibição
+func (r *ChamadasRepository) GetStatusPresenca(ctx context.Context) ([]model.StatusPresenca, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT codigo, nome, categoria, ativo
+		FROM status_presenca
+		ORDER BY ordem, codigo`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar status de presença: %w", err)
+	}
+	defer rows.Close()
+
+	status := []model.StatusPresenca{}
+	for rows.Next() {
+		var s model.StatusPresenca
+		if err := rows.Scan(&s.Codigo, &s.Nome, &s.Categoria, &s.Ativo); err != nil {
+			return nil, fmt.Errorf("erro ao escanear status de presença: %w", err)
+		}
+		status = append(status, s)
+	}
+
+	return status, rows.Err()
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sysocial/internal/chamadas/model"
+)
+
+// Erros do calendário institucional
+var (
+	ErrEventoInvalido      = errors.New("evento do calendário inválido")
+	ErrEventoNaoEncontrado = errors.New("evento do calendário não encontrado")
+)
+
+// ListEventosCalendario lista feriados, recessos, cancelamentos e aulas extras no período
+func (s *ChamadasService) ListEventosCalendario(ctx context.Context, filtro model.CalendarioFiltro) ([]model.EventoCalendario, error) {
+	if filtro.DataInicio > filtro.DataFim {
+		return nil, fmt.Errorf("%w: dataInicio posterior a dataFim", ErrPeriodoInvalido)
+	}
+	return s.repo.ListEventosCalendario(ctx, filtro)
+}
+
+// CreateEventoCalendario cadastra um evento no calendário (somente administradores)
+func (s *ChamadasService) CreateEventoCalendario(ctx context.Context, payload model.EventoCalendarioPayload, usuarioID int) (int, error) {
+	if err := s.validarEventoCalendario(ctx, &payload); err != nil {
+		return 0, err
+	}
+
+	s.logger.Infof("Cadastrando %s de %s a %s no calendário: %s", payload.Tipo, payload.DataInicio, payload.DataFim, payload.Motivo)
+	return s.repo.CreateEventoCalendario(ctx, payload, usuarioID)
+}
+
+// UpdateEventoCalendario substitui os dados de um evento do calendário (somente administradores)
+func (s *ChamadasService) UpdateEventoCalendario(ctx context.Context, id int, payload model.EventoCalendarioPayload) error {
+	evento, err := s.repo.GetEventoCalendarioByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if evento == nil {
+		return ErrEventoNaoEncontrado
+	}
+
+	if err := s.validarEventoCalendario(ctx, &payload); err != nil {
+		return err
+	}
+
+	s.logger.Infof("Atualizando evento do calendário ID: %d", id)
+	return s.repo.UpdateEventoCalendario(ctx, id, payload)
+}
+
+// DeleteEventoCalendario remove um evento do calendário (somente administradores)
+func (s *ChamadasService) DeleteEventoCalendario(ctx context.Context, id int) error {
+	evento, err := s.repo.GetEventoCalendarioByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if evento == nil {
+		return ErrEventoNaoEncontrado
+	}
+
+	s.logger.Infof("Excluindo evento do calendário ID: %d (%s %s)", id, evento.Tipo, evento.DataInicio)
+	return s.repo.DeleteEventoCalendario(ctx, id)
+}
+
+// validarEventoCalendario aplica as regras de escopo e período de cada tipo de evento:
+// feriados e recessos valem para todas as turmas; cancelamentos e aulas extras exigem a turma,
+// e a aula extra é de um único dia dentro do período letivo da turma
+func (s *ChamadasService) validarEventoCalendario(ctx context.Context, payload *model.EventoCalendarioPayload) error {
+	if payload.DataFim == "" {
+		payload.DataFim = payload.DataInicio
+	}
+	if payload.DataFim < payload.DataInicio {
+		return fmt.Errorf("%w: dataFim anterior a dataInicio", ErrEventoInvalido)
+	}
+
+	switch payload.Tipo {
+	case model.EventoFeriado, model.EventoRecesso:
+		if payload.TurmaID != nil {
+			return fmt.Errorf("%w: feriados e recessos valem para todas as turmas (não informe turmaId)", ErrEventoInvalido)
+		}
+		return nil
+	case model.EventoCancelamento, model.EventoAulaExtra:
+		if payload.TurmaID == nil {
+			return fmt.Errorf("%w: informe a turma (turmaId) do cancelamento ou da aula extra", ErrEventoInvalido)
+		}
+	default:
+		return fmt.Errorf("%w: tipo %s desconhecido", ErrEventoInvalido, payload.Tipo)
+	}
+
+	existe, err := s.repo.VerificaTurmaExiste(ctx, *payload.TurmaID)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar turma: %w", err)
+	}
+	if !existe {
+		return fmt.Errorf("%w: turma %d não encontrada", ErrEventoInvalido, *payload.TurmaID)
+	}
+
+	if payload.Tipo == model.EventoAulaExtra {
+		if payload.DataFim != payload.DataInicio {
+			return fmt.Errorf("%w: a aula extra deve ser de um único dia", ErrEventoInvalido)
+		}
+		dataValida, err := s.repo.CheckTurmaDateRange(ctx, *payload.TurmaID, payload.DataInicio)
+		if err != nil {
+			return fmt.Errorf("erro ao validar data da turma: %w", err)
+		}
+		if !dataValida {
+			return fmt.Errorf("%w: a aula extra está fora do período letivo da turma", ErrEventoInvalido)
+		}
+	}
+
+	return nil
+}
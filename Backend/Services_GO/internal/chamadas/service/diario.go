@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sysocial/internal/chamadas/model"
+	"sysocial/internal/chamadas/repository"
+	"sysocial/internal/shared/export"
+)
+
+// Erros do diário de classe
+var (
+	ErrChamadaNaoEncontrada = errors.New("chamada não encontrada")
+	ErrDiarioNaoEncontrado  = errors.New("diário de classe não registrado")
+	ErrDiarioInvalido       = errors.New("diário de classe inválido")
+)
+
+// GetDiarioAula busca o diário de classe da chamada, com os anexos
+func (s *ChamadasService) GetDiarioAula(ctx context.Context, chamadaID int) (*model.DiarioAula, error) {
+	diario, err := s.repo.GetDiarioAula(ctx, chamadaID)
+	if err != nil {
+		return nil, err
+	}
+	if diario == nil {
+		return nil, ErrChamadaNaoEncontrada
+	}
+	if !diario.Registrado {
+		return nil, ErrDiarioNaoEncontrado
+	}
+
+	diarios := []model.DiarioAula{*diario}
+	if err := s.anexarArquivosDiario(ctx, diarios); err != nil {
+		return nil, err
+	}
+	return &diarios[0], nil
+}
+
+// SalvarDiarioAula registra ou substitui o diário da chamada, pelas mesmas regras do lançamento de presenças:
+// quem lança a turma, a partir do dia da aula e enquanto o mês estiver aberto. Devolve true quando o diário foi criado.
+func (s *ChamadasService) SalvarDiarioAula(ctx context.Context, chamadaID int, payload model.DiarioAulaPayload, usuario model.Usuario) (*model.DiarioAula, bool, error) {
+	payload.Tema = strings.TrimSpace(payload.Tema)
+	if payload.Tema == "" {
+		return nil, false, fmt.Errorf("%w: informe o tema da aula", ErrDiarioInvalido)
+	}
+
+	chamada, err := s.chamadaDoDiario(ctx, chamadaID)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.verificarPermissao(ctx, usuario, chamada.TurmaID, chamada.DataAula); err != nil {
+		return nil, false, err
+	}
+	if err := s.verificarLancamento(ctx, chamada); err != nil {
+		return nil, false, err
+	}
+
+	criado, err := s.repo.SaveDiarioAula(ctx, chamadaID, payload, usuario.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.logger.Infof("Diário de classe da chamada ID %d gravado pelo usuário ID %d", chamadaID, usuario.ID)
+	diario, err := s.GetDiarioAula(ctx, chamadaID)
+	return diario, criado, err
+}
+
+// DeleteDiarioAula apaga o diário da chamada e os seus anexos (mesmas regras de edição das presenças)
+func (s *ChamadasService) DeleteDiarioAula(ctx context.Context, chamadaID int, usuario model.Usuario) error {
+	chamada, err := s.chamadaDoDiario(ctx, chamadaID)
+	if err != nil {
+		return err
+	}
+	if err := s.verificarPermissao(ctx, usuario, chamada.TurmaID, chamada.DataAula); err != nil {
+		return err
+	}
+	if err := s.verificarEdicao(ctx, chamada.TurmaID, chamada.DataAula); err != nil {
+		return err
+	}
+
+	removido, err := s.repo.DeleteDiarioAula(ctx, chamadaID)
+	if err != nil {
+		return err
+	}
+	if !removido {
+		return ErrDiarioNaoEncontrado
+	}
+
+	s.logger.Infof("Diário de classe da chamada ID %d apagado pelo usuário ID %d", chamadaID, usuario.ID)
+	return nil
+}
+
+// GetDiarioTurma monta a linha do tempo da turma: todas as chamadas do período em ordem de data, com o diário
+// de cada uma (registrado = false nas aulas ainda sem diário)
+func (s *ChamadasService) GetDiarioTurma(ctx context.Context, turmaID int, filtro model.DiarioFiltro) ([]model.DiarioAula, error) {
+	if filtro.DataInicio != "" && filtro.DataFim != "" && filtro.DataInicio > filtro.DataFim {
+		return nil, fmt.Errorf("%w: dataInicio posterior a dataFim", ErrPeriodoInvalido)
+	}
+
+	existe, err := s.repo.VerificaTurmaExiste(ctx, turmaID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar turma: %w", err)
+	}
+	if !existe {
+		return nil, ErrTurmaNaoEncontrada
+	}
+
+	diarios, err := s.repo.GetDiariosTurma(ctx, turmaID, filtro.DataInicio, filtro.DataFim)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.anexarArquivosDiario(ctx, diarios); err != nil {
+		return nil, err
+	}
+	return diarios, nil
+}
+
+// ExportDiarioMensal monta a planilha do conteúdo ministrado no mês (AAAAMM) de uma turma ou de todas as turmas
+// de um curso: uma linha por aula com diário registrado
+func (s *ChamadasService) ExportDiarioMensal(ctx context.Context, escopo string, id int, anoMes string) (*export.Table, error) {
+	inicio, fim, err := intervaloMes(anoMes)
+	if err != nil {
+		return nil, err
+	}
+	turmaIDs, err := s.turmasDoEscopo(ctx, escopo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	table := &export.Table{
+		Sheet:   "Diário " + anoMes,
+		Headers: []string{"Data", "Curso", "Turma", "Tema", "Conteúdo", "Atividades", "Materiais", "Observações", "Registrado por"},
+	}
+	for _, turmaID := range turmaIDs {
+		diarios, err := s.diariosRegistrados(ctx, turmaID, inicio, fim)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range diarios {
+			table.Rows = append(table.Rows, []string{
+				formatarData(d.DataAula), d.CursoNome, d.TurmaNome, d.Tema, d.Conteudo, d.Atividades,
+				d.Materiais, d.Observacoes, d.RegistradoPorNome,
+			})
+		}
+	}
+
+	s.logger.Infof("Exportando diário de classe de %s ID %d (%s): %d aulas", escopo, id, anoMes, len(table.Rows))
+	return table, nil
+}
+
+// diariosRegistrados lista apenas as aulas da turma no período que já têm diário
+func (s *ChamadasService) diariosRegistrados(ctx context.Context, turmaID int, dataInicio, dataFim string) ([]model.DiarioAula, error) {
+	diarios, err := s.repo.GetDiariosTurma(ctx, turmaID, dataInicio, dataFim)
+	if err != nil {
+		return nil, err
+	}
+
+	registrados := diarios[:0]
+	for _, d := range diarios {
+		if d.Registrado {
+			registrados = append(registrados, d)
+		}
+	}
+	return registrados, nil
+}
+
+// chamadaDoDiario busca a chamada do diário (ErrChamadaNaoEncontrada quando não existe)
+func (s *ChamadasService) chamadaDoDiario(ctx context.Context, chamadaID int) (*model.Chamada, error) {
+	diario, err := s.repo.GetDiarioAula(ctx, chamadaID)
+	if err != nil {
+		return nil, err
+	}
+	if diario == nil {
+		return nil, ErrChamadaNaoEncontrada
+	}
+	return &model.Chamada{ID: diario.ChamadaID, TurmaID: diario.TurmaID, DataAula: diario.DataAula}, nil
+}
+
+// anexarArquivosDiario preenche os anexos (file-service, entidade_pai = "diario_aula") dos diários registrados
+func (s *ChamadasService) anexarArquivosDiario(ctx context.Context, diarios []model.DiarioAula) error {
+	ids := make([]int, 0, len(diarios))
+	for _, d := range diarios {
+		if d.Registrado {
+			ids = append(ids, d.ChamadaID)
+		}
+	}
+
+	anexos, err := s.repo.GetAnexos(ctx, repository.EntidadeAnexoDiario, ids)
+	if err != nil {
+		return err
+	}
+	for i := range diarios {
+		diarios[i].Anexos = anexos[diarios[i].ChamadaID]
+		if diarios[i].Anexos == nil {
+			diarios[i].Anexos = []model.Anexo{}
+		}
+	}
+	return nil
+}
+
+// intervaloMes devolve o primeiro e o último dia do mês AAAAMM (AAAA-MM-DD)
+func intervaloMes(anoMes string) (string, string, error) {
+	inicio, err := time.Parse("200601", anoMes)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: use AAAAMM (ex: 202511)", ErrPeriodoInvalido)
+	}
+	return inicio.Format("2006-01-02"), inicio.AddDate(0, 1, -1).Format("2006-01-02"), nil
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"sysocial/internal/chamadas/model"
+)
+
+// ErrPeriodoInvalido indica datas de início/fim inválidas nos relatórios de frequência
+var ErrPeriodoInvalido = errors.New("período inválido")
+
+const (
+	DiasPeriodoPadrao = 90  // Período padrão dos relatórios quando dataInicio não é informada
+	DefaultRiscoLimit = 100 // Máximo de alunos devolvidos no relatório de risco sem limit explícito
+)
+
+// GetRelatorioFrequencia calcula a frequência por aluno (em cada turma), por turma e por curso no período,
+// com as sequências de faltas de cada aluno. Só entram aulas com presença lançada.
+func (s *ChamadasService) GetRelatorioFrequencia(ctx context.Context, filtro model.FrequenciaFiltro) (*model.RelatorioFrequencia, error) {
+	if err := normalizarPeriodo(&filtro); err != nil {
+		return nil, err
+	}
+
+	registros, err := s.repo.GetRegistrosFrequencia(ctx, filtro, false)
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.carregarStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alunos := consolidarAlunos(registros, status)
+	relatorio := &model.RelatorioFrequencia{
+		DataInicio: filtro.DataInicio,
+		DataFim:    filtro.DataFim,
+		Alunos:     alunos,
+		Turmas:     []model.FrequenciaTurma{},
+		Cursos:     []model.FrequenciaCurso{},
+	}
+
+	// Turmas e cursos somam os totais dos alunos (registros já vêm ordenados por curso e turma)
+	aulasPorTurma := make(map[int]map[string]bool)
+	for _, reg := range registros {
+		if aulasPorTurma[reg.TurmaID] == nil {
+			aulasPorTurma[reg.TurmaID] = make(map[string]bool)
+		}
+		aulasPorTurma[reg.TurmaID][reg.DataAula] = true
+	}
+
+	alunosPorCurso := make(map[int]map[int]bool)
+	for _, aluno := range alunos {
+		n := len(relatorio.Turmas)
+		if n == 0 || relatorio.Turmas[n-1].TurmaID != aluno.TurmaID {
+			relatorio.Turmas = append(relatorio.Turmas, model.FrequenciaTurma{
+				TurmaID:   aluno.TurmaID,
+				TurmaNome: aluno.TurmaNome,
+				CursoID:   aluno.CursoID,
+				CursoNome: aluno.CursoNome,
+				Aulas:     len(aulasPorTurma[aluno.TurmaID]),
+			})
+		}
+		turma := &relatorio.Turmas[len(relatorio.Turmas)-1]
+		turma.Alunos++
+		somarResumo(&turma.ResumoFrequencia, aluno.ResumoFrequencia)
+
+		n = len(relatorio.Cursos)
+		if n == 0 || relatorio.Cursos[n-1].CursoID != aluno.CursoID {
+			relatorio.Cursos = append(relatorio.Cursos, model.FrequenciaCurso{CursoID: aluno.CursoID, CursoNome: aluno.CursoNome})
+			alunosPorCurso[aluno.CursoID] = make(map[int]bool)
+		}
+		curso := &relatorio.Cursos[len(relatorio.Cursos)-1]
+		if turma.Alunos == 1 {
+			curso.Turmas++
+		}
+		alunosPorCurso[aluno.CursoID][aluno.AlunoID] = true
+		curso.Alunos = len(alunosPorCurso[aluno.CursoID])
+		somarResumo(&curso.ResumoFrequencia, aluno.ResumoFrequencia)
+	}
+
+	for i := range relatorio.Turmas {
+		calcularPercentual(&relatorio.Turmas[i].ResumoFrequencia)
+	}
+	for i := range relatorio.Cursos {
+		calcularPercentual(&relatorio.Cursos[i].ResumoFrequencia)
+	}
+
+	s.logger.Infof("Relatório de frequência %s a %s: %d cursos, %d turmas, %d alunos",
+		filtro.DataInicio, filtro.DataFim, len(relatorio.Cursos), len(relatorio.Turmas), len(relatorio.Alunos))
+	return relatorio, nil
+}
+
+// GetRiscoEvasao lista os alunos ativos em risco de evasão, do maior para o menor risco. Um aluno entra na lista
+// quando a frequência fica abaixo do mínimo (com aulas suficientes para avaliar) ou quando acumula faltas seguidas
+// até a última aula registrada. Critérios não informados usam a configuração do serviço.
+func (s *ChamadasService) GetRiscoEvasao(ctx context.Context, filtro model.FrequenciaFiltro, query model.RiscoEvasaoQuery) (*model.RelatorioRisco, error) {
+	if err := normalizarPeriodo(&filtro); err != nil {
+		return nil, err
+	}
+
+	criterios := model.CriteriosRisco{
+		FrequenciaMinima:   s.frequencia.Minima,
+		FaltasConsecutivas: s.frequencia.FaltasConsecutivas,
+		MinimoAulas:        s.frequencia.MinimoAulas,
+	}
+	if query.FrequenciaMinima > 0 {
+		criterios.FrequenciaMinima = query.FrequenciaMinima
+	}
+	if query.FaltasConsecutivas > 0 {
+		criterios.FaltasConsecutivas = query.FaltasConsecutivas
+	}
+	if query.MinimoAulas > 0 {
+		criterios.MinimoAulas = query.MinimoAulas
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultRiscoLimit
+	}
+
+	registros, err := s.repo.GetRegistrosFrequencia(ctx, filtro, true)
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.carregarStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alunos := []model.AlunoRisco{}
+	for _, aluno := range consolidarAlunos(registros, status) {
+		if risco, ok := avaliarRisco(aluno, criterios); ok {
+			alunos = append(alunos, risco)
+		}
+	}
+
+	sort.SliceStable(alunos, func(i, j int) bool {
+		if alunos[i].Pontuacao != alunos[j].Pontuacao {
+			return alunos[i].Pontuacao > alunos[j].Pontuacao
+		}
+		if alunos[i].FaltasConsecutivasAtuais != alunos[j].FaltasConsecutivasAtuais {
+			return alunos[i].FaltasConsecutivasAtuais > alunos[j].FaltasConsecutivasAtuais
+		}
+		return alunos[i].AlunoNome < alunos[j].AlunoNome
+	})
+
+	relatorio := &model.RelatorioRisco{
+		DataInicio: filtro.DataInicio,
+		DataFim:    filtro.DataFim,
+		Criterios:  criterios,
+		Total:      len(alunos),
+		Alunos:     alunos,
+	}
+	if len(alunos) > query.Limit {
+		relatorio.Alunos = alunos[:query.Limit]
+	}
+
+	s.logger.Infof("Relatório de risco de evasão %s a %s: %d alunos em risco", filtro.DataInicio, filtro.DataFim, relatorio.Total)
+	return relatorio, nil
+}
+
+// normalizarPeriodo aplica o período padrão (últimos DiasPeriodoPadrao dias até hoje) e valida a ordem das datas
+func normalizarPeriodo(filtro *model.FrequenciaFiltro) error {
+	fim := time.Now()
+	if filtro.DataFim != "" {
+		data, err := time.Parse("2006-01-02", filtro.DataFim)
+		if err != nil {
+			return fmt.Errorf("%w: dataFim deve estar no formato AAAA-MM-DD", ErrPeriodoInvalido)
+		}
+		fim = data
+	}
+
+	inicio := fim.AddDate(0, 0, -DiasPeriodoPadrao)
+	if filtro.DataInicio != "" {
+		data, err := time.Parse("2006-01-02", filtro.DataInicio)
+		if err != nil {
+			return fmt.Errorf("%w: dataInicio deve estar no formato AAAA-MM-DD", ErrPeriodoInvalido)
+		}
+		inicio = data
+	}
+
+	if inicio.After(fim) {
+		return fmt.Errorf("%w: dataInicio posterior a dataFim", ErrPeriodoInvalido)
+	}
+
+	filtro.DataInicio = inicio.Format("2006-01-02")
+	filtro.DataFim = fim.Format("2006-01-02")
+	return nil
+}
+
+// consolidarAlunos agrupa os registros (ordenados por turma, aluno e data) em uma linha por aluno e turma,
+// contando cada status pela sua categoria. Só faltas sem justificativa formam a sequência de faltas; faltas
+// justificadas e dispensas não a interrompem nem a aumentam.
+func consolidarAlunos(registros []model.RegistroFrequencia, status *statusPresenca) []model.FrequenciaAluno {
+	alunos := []model.FrequenciaAluno{}
+	for i, reg := range registros {
+		if i == 0 || reg.AlunoID != registros[i-1].AlunoID || reg.TurmaID != registros[i-1].TurmaID {
+			alunos = append(alunos, model.FrequenciaAluno{
+				AlunoID:   reg.AlunoID,
+				AlunoNome: reg.AlunoNome,
+				TurmaID:   reg.TurmaID,
+				TurmaNome: reg.TurmaNome,
+				CursoID:   reg.CursoID,
+				CursoNome: reg.CursoNome,
+			})
+		}
+
+		aluno := &alunos[len(alunos)-1]
+		switch status.categoria(reg.Presente) {
+		case model.CategoriaPresente:
+			data := reg.DataAula
+			aluno.Presencas++
+			aluno.FaltasConsecutivasAtuais = 0
+			aluno.UltimaPresenca = &data
+		case model.CategoriaAusente:
+			aluno.Faltas++
+			aluno.FaltasConsecutivasAtuais++
+			if aluno.FaltasConsecutivasAtuais > aluno.MaiorSequenciaFaltas {
+				aluno.MaiorSequenciaFaltas = aluno.FaltasConsecutivasAtuais
+			}
+		case model.CategoriaJustificado:
+			aluno.FaltasJustificadas++
+		case model.CategoriaDispensado:
+			aluno.Dispensas++
+		}
+	}
+
+	for i := range alunos {
+		calcularPercentual(&alunos[i].ResumoFrequencia)
+	}
+	return alunos
+}
+
+// avaliarRisco aplica os critérios ao aluno; a pontuação soma o quanto a frequência ficou abaixo do mínimo
+// (proporcionalmente) com a razão entre as faltas seguidas atuais e o limite
+func avaliarRisco(aluno model.FrequenciaAluno, criterios model.CriteriosRisco) (model.AlunoRisco, bool) {
+	risco := model.AlunoRisco{FrequenciaAluno: aluno, Motivos: []string{}}
+
+	minima := float64(criterios.FrequenciaMinima)
+	if aluno.Frequencia != nil && aluno.AulasRegistradas >= criterios.MinimoAulas && *aluno.Frequencia < minima {
+		risco.Motivos = append(risco.Motivos, fmt.Sprintf("Frequência de %s%% abaixo do mínimo de %d%%",
+			strings.Replace(fmt.Sprintf("%.1f", *aluno.Frequencia), ".", ",", 1), criterios.FrequenciaMinima))
+		risco.Pontuacao += (minima - *aluno.Frequencia) / minima
+	}
+
+	// Sem limite de faltas seguidas (zero) o critério não se aplica
+	if criterios.FaltasConsecutivas > 0 && aluno.FaltasConsecutivasAtuais >= criterios.FaltasConsecutivas {
+		risco.Motivos = append(risco.Motivos, fmt.Sprintf("%d faltas consecutivas", aluno.FaltasConsecutivasAtuais))
+		risco.Pontuacao += float64(aluno.FaltasConsecutivasAtuais) / float64(criterios.FaltasConsecutivas)
+	}
+
+	risco.Pontuacao = math.Round(risco.Pontuacao*100) / 100
+	return risco, len(risco.Motivos) > 0
+}
+
+// somarResumo acumula os totais de um resumo em outro (o percentual é recalculado depois)
+func somarResumo(total *model.ResumoFrequencia, parcial model.ResumoFrequencia) {
+	total.AulasRegistradas += parcial.AulasRegistradas
+	total.Presencas += parcial.Presencas
+	total.Faltas += parcial.Faltas
+	total.FaltasJustificadas += parcial.FaltasJustificadas
+	total.Dispensas += parcial.Dispensas
+}
+
+// calcularPercentual preenche aulas registradas e frequência (presenças / aulas, uma casa decimal)
+func calcularPercentual(resumo *model.ResumoFrequencia) {
+	resumo.AulasRegistradas = resumo.Presencas + resumo.Faltas + resumo.FaltasJustificadas
+	if resumo.AulasRegistradas == 0 {
+		resumo.Frequencia = nil
+		return
+	}
+	frequencia := math.Round(float64(resumo.Presencas)*1000/float64(resumo.AulasRegistradas)) / 10
+	resumo.Frequencia = &frequencia
+}
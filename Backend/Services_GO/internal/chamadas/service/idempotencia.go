@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Erros de idempotência
+var (
+	ErrIdempotenciaConflito    = errors.New("Idempotency-Key já usada com outro conteúdo")
+	ErrIdempotenciaEmAndamento = errors.New("requisição com esta Idempotency-Key ainda em processamento")
+)
+
+// ValidadeIdempotencia tempo durante o qual uma Idempotency-Key devolve o resultado original
+const ValidadeIdempotencia = 24 * time.Hour
+
+// PrazoAndamentoIdempotencia tempo depois do qual uma chave ainda sem resultado (o serviço caiu entre a gravação e
+// o registro do resultado) pode ser reaproveitada; os lançamentos de presença são upserts, então repetir é seguro
+const PrazoAndamentoIdempotencia = 2 * time.Minute
+
+// Operações protegidas por Idempotency-Key (a mesma chave pode ser usada em operações diferentes)
+const (
+	OperacaoCreatePresencas = "presencas.create"
+	OperacaoUpsertPresencas = "presencas.upsert"
+)
+
+// executarIdempotente executa a operação uma única vez por chave do usuário: reenvios com o mesmo corpo recebem o resultado
+// guardado (repetida = true) sem executar de novo. Sem chave, apenas executa. Se a operação falhar, a chave
+// é liberada para que o reenvio seja processado normalmente.
+func (s *ChamadasService) executarIdempotente(ctx context.Context, chave, operacao string, usuarioID int, payload, resultado interface{}, executar func() error) (bool, error) {
+	if chave == "" {
+		return false, executar()
+	}
+
+	corpo, err := json.Marshal(payload)
+	if err != nil {
+		return false, fmt.Errorf("erro ao serializar requisição: %w", err)
+	}
+	soma := sha256.Sum256(corpo)
+	hash := hex.EncodeToString(soma[:])
+
+	registro, reservada, err := s.repo.ReservarIdempotencia(ctx, chave, operacao, usuarioID, hash, ValidadeIdempotencia, PrazoAndamentoIdempotencia)
+	if err != nil {
+		return false, err
+	}
+
+	if !reservada {
+		if registro.HashCorpo != hash {
+			return false, ErrIdempotenciaConflito
+		}
+		if registro.Resultado == nil {
+			return false, ErrIdempotenciaEmAndamento
+		}
+		if err := json.Unmarshal([]byte(*registro.Resultado), resultado); err != nil {
+			return false, fmt.Errorf("erro ao ler resultado guardado: %w", err)
+		}
+		s.logger.Infof("Requisição repetida (%s, Idempotency-Key %s): devolvendo resultado original", operacao, chave)
+		return true, nil
+	}
+
+	if err := executar(); err != nil {
+		if errLiberar := s.repo.LiberarIdempotencia(ctx, chave, operacao, usuarioID); errLiberar != nil {
+			s.logger.Errorf("Falha ao liberar Idempotency-Key %s: %v", chave, errLiberar)
+		}
+		return false, err
+	}
+
+	dados, err := json.Marshal(resultado)
+	if err == nil {
+		err = s.repo.ConcluirIdempotencia(ctx, chave, operacao, usuarioID, string(dados))
+	}
+	if err != nil {
+		// A operação já foi gravada: um reenvio receberá 409 até PrazoAndamentoIdempotencia e depois é reexecutado
+		s.logger.Errorf("Falha ao guardar resultado da Idempotency-Key %s: %v", chave, err)
+	}
+
+	return false, nil
+}
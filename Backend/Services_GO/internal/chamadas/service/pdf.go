@@ -0,0 +1,291 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"sysocial/internal/chamadas/model"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Tipos de PDF de frequência
+const (
+	PDFFolha     = "folha"     // Folha em branco para a chamada em papel
+	PDFRelatorio = "relatorio" // Frequência lançada, com totais e percentual por aluno
+)
+
+var mesesPT = [...]string{"", "Janeiro", "Fevereiro", "Março", "Abril", "Maio", "Junho",
+	"Julho", "Agosto", "Setembro", "Outubro", "Novembro", "Dezembro"}
+
+// Layout em milímetros (A4 paisagem)
+const (
+	pdfMargem      = 10.0
+	pdfAlturaLinha = 6.5
+	pdfLarguraNum  = 8.0
+	pdfLarguraNome = 72.0
+	pdfLarguraTot  = 10.0
+	pdfLarguraPct  = 14.0
+)
+
+// GerarPDFFrequencia gera a folha de chamada (tipo "folha") ou o relatório de frequência (tipo "relatorio")
+// de uma turma no mês AAAAMM; o relatório termina com o conteúdo ministrado (diário de classe) do mês.
+// A renderização usa apenas fontes embutidas, sem dependências externas.
+func (s *ChamadasService) GerarPDFFrequencia(ctx context.Context, tipo string, turmaID int, anoMes string) ([]byte, error) {
+	if tipo != PDFFolha && tipo != PDFRelatorio {
+		return nil, fmt.Errorf("tipo de PDF inválido: %s", tipo)
+	}
+
+	freq, err := s.repo.GetFrequenciaTurmaMes(ctx, turmaID, anoMes)
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.carregarStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var diarios []model.DiarioAula
+	if tipo == PDFRelatorio {
+		inicio, fim, err := intervaloMes(anoMes)
+		if err != nil {
+			return nil, err
+		}
+		diarios, err = s.diariosRegistrados(ctx, turmaID, inicio, fim)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pdf, err := s.renderPDFFrequencia(freq, tipo, anoMes, status, diarios)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("PDF de frequência (%s) gerado para turma ID %d (%s): %d alunos, %d datas", tipo, turmaID, anoMes, len(freq.Alunos), len(freq.Datas))
+	return pdf, nil
+}
+
+// renderPDFFrequencia desenha o documento em A4 paisagem, repetindo cabeçalho e títulos das colunas a cada página
+func (s *ChamadasService) renderPDFFrequencia(freq *model.FrequenciaTurmaMes, tipo, anoMes string, status *statusPresenca, diarios []model.DiarioAula) ([]byte, error) {
+	datas := make([]string, 0, len(freq.Datas))
+	for _, d := range freq.Datas {
+		datas = append(datas, d.Data)
+	}
+
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pdfMargem, pdfMargem, pdfMargem)
+	pdf.SetAutoPageBreak(false, pdfMargem)
+	pdf.AliasNbPages("")
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // cp1252: acentos do português nas fontes padrão
+
+	titulo := "Lista de Frequência"
+	if tipo == PDFRelatorio {
+		titulo = "Relatório de Frequência"
+	}
+	mes, _ := strconv.Atoi(anoMes[4:])
+	subtitulo := fmt.Sprintf("%s - %s | %s/%s", freq.CursoNome, freq.TurmaNome, mesesPT[mes], anoMes[:4])
+
+	geradoEm := time.Now().Format("02/01/2006 15:04")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargem)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, tr(fmt.Sprintf("Gerado em %s - página %d de {nb}", geradoEm, pdf.PageNo())), "", 0, "R", false, 0, "")
+	})
+
+	// Largura das colunas de data: divide o espaço restante, limitada para não ficar larga demais
+	pageW, pageH := pdf.GetPageSize()
+	larguraUtil := pageW - 2*pdfMargem - pdfLarguraNum - pdfLarguraNome
+	if tipo == PDFRelatorio {
+		larguraUtil -= 3*pdfLarguraTot + pdfLarguraPct
+	}
+	larguraData := 12.0
+	if len(datas) > 0 && larguraUtil/float64(len(datas)) < larguraData {
+		larguraData = larguraUtil / float64(len(datas))
+	}
+
+	cabecalhoTabela := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		pdf.CellFormat(pdfLarguraNum, pdfAlturaLinha, tr("Nº"), "1", 0, "C", true, 0, "")
+		pdf.CellFormat(pdfLarguraNome, pdfAlturaLinha, "Aluno", "1", 0, "L", true, 0, "")
+		for _, d := range datas {
+			t, _ := time.Parse("2006-01-02", d)
+			pdf.CellFormat(larguraData, pdfAlturaLinha, t.Format("02/01"), "1", 0, "C", true, 0, "")
+		}
+		if tipo == PDFRelatorio {
+			for _, h := range []string{"P", "F", "J"} {
+				pdf.CellFormat(pdfLarguraTot, pdfAlturaLinha, h, "1", 0, "C", true, 0, "")
+			}
+			pdf.CellFormat(pdfLarguraPct, pdfAlturaLinha, "Freq.", "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+
+	novaPagina := func() {
+		pdf.AddPage()
+		s.cabecalhoInstituicao(pdf, tr, titulo, subtitulo)
+		cabecalhoTabela()
+	}
+
+	novaPagina()
+	for i, aluno := range freq.Alunos {
+		if pdf.GetY()+pdfAlturaLinha > pageH-2*pdfMargem {
+			novaPagina()
+		}
+
+		pdf.CellFormat(pdfLarguraNum, pdfAlturaLinha, strconv.Itoa(i+1), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(pdfLarguraNome, pdfAlturaLinha, tr(truncar(aluno.AlunoNome, 40)), "1", 0, "L", false, 0, "")
+		for _, d := range datas {
+			valor := ""
+			if tipo == PDFRelatorio {
+				valor = codigoPresenca(aluno.Presencas[d].Present)
+			}
+			if valor == "" && !aluno.MatriculadoEm(d) {
+				valor = foraDaMatricula
+			}
+			pdf.CellFormat(larguraData, pdfAlturaLinha, valor, "1", 0, "C", false, 0, "")
+		}
+		if tipo == PDFRelatorio {
+			presentes, faltas, justificadas := contarPresencas(aluno, datas, status)
+			for _, total := range []int{presentes, faltas, justificadas} {
+				pdf.CellFormat(pdfLarguraTot, pdfAlturaLinha, strconv.Itoa(total), "1", 0, "C", false, 0, "")
+			}
+			pdf.CellFormat(pdfLarguraPct, pdfAlturaLinha, percentualFrequencia(presentes, faltas, justificadas), "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	if len(freq.Alunos) == 0 {
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.CellFormat(0, pdfAlturaLinha, tr("Nenhum aluno matriculado nesta turma."), "", 1, "L", false, 0, "")
+	}
+
+	// Legenda e assinaturas
+	if pdf.GetY()+30 > pageH-2*pdfMargem {
+		pdf.AddPage()
+		s.cabecalhoInstituicao(pdf, tr, titulo, subtitulo)
+	}
+	pdf.Ln(4)
+	pdf.SetFont("Helvetica", "", 8)
+	legenda := "Legenda: " + status.legenda() + ", " + foraDaMatricula + " = fora do período de matrícula."
+	if tipo == PDFRelatorio {
+		legenda += " Totais pela categoria do status: P = presenças, F = faltas, J = faltas justificadas."
+		legenda += " Freq. = presenças / aulas com chamada lançada (dispensas não contam)."
+	}
+	pdf.CellFormat(0, 5, tr(legenda), "", 1, "L", false, 0, "")
+	if len(freq.DiasSemAula) > 0 {
+		dias := make([]string, 0, len(freq.DiasSemAula))
+		for _, dia := range freq.DiasSemAula {
+			t, _ := time.Parse("2006-01-02", dia.Data)
+			dias = append(dias, fmt.Sprintf("%s (%s)", t.Format("02/01"), dia.Motivo))
+		}
+		pdf.MultiCell(0, 5, tr("Sem aula: "+strings.Join(dias, "; ")), "", "L", false)
+	}
+
+	pdf.Ln(12)
+	larguraAssinatura := 80.0
+	y := pdf.GetY()
+	pdf.Line(pdfMargem, y, pdfMargem+larguraAssinatura, y)
+	pdf.Line(pageW-pdfMargem-larguraAssinatura, y, pageW-pdfMargem, y)
+	pdf.SetXY(pdfMargem, y+1)
+	pdf.CellFormat(larguraAssinatura, 5, "Professor(a)", "", 0, "C", false, 0, "")
+	pdf.SetX(pageW - pdfMargem - larguraAssinatura)
+	pdf.CellFormat(larguraAssinatura, 5, tr("Coordenação"), "", 1, "C", false, 0, "")
+
+	if len(diarios) > 0 {
+		s.renderDiarioPDF(pdf, tr, subtitulo, diarios)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("erro ao gerar PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderDiarioPDF lista o conteúdo ministrado em cada aula do mês (diário de classe), a partir de uma nova página
+func (s *ChamadasService) renderDiarioPDF(pdf *fpdf.Fpdf, tr func(string) string, subtitulo string, diarios []model.DiarioAula) {
+	titulo := "Conteúdo Ministrado"
+	_, pageH := pdf.GetPageSize()
+	pdf.AddPage()
+	s.cabecalhoInstituicao(pdf, tr, titulo, subtitulo)
+
+	for _, diario := range diarios {
+		if pdf.GetY()+25 > pageH-2*pdfMargem {
+			pdf.AddPage()
+			s.cabecalhoInstituicao(pdf, tr, titulo, subtitulo)
+		}
+
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetFillColor(230, 230, 230)
+		pdf.CellFormat(0, pdfAlturaLinha, tr(formatarData(diario.DataAula)+" - "+diario.Tema), "1", 1, "L", true, 0, "")
+
+		pdf.SetFont("Helvetica", "", 9)
+		for _, campo := range []struct{ nome, texto string }{
+			{"Conteúdo", diario.Conteudo},
+			{"Atividades", diario.Atividades},
+			{"Materiais", diario.Materiais},
+			{"Observações", diario.Observacoes},
+		} {
+			if strings.TrimSpace(campo.texto) != "" {
+				pdf.MultiCell(0, 5, tr(campo.nome+": "+campo.texto), "", "L", false)
+			}
+		}
+		if diario.RegistradoPorNome != "" {
+			pdf.SetFont("Helvetica", "I", 8)
+			pdf.CellFormat(0, 5, tr("Registrado por "+diario.RegistradoPorNome), "", 1, "R", false, 0, "")
+		}
+		pdf.Ln(2)
+	}
+}
+
+// cabecalhoInstituicao imprime logo (se configurado), nome e endereço da instituição e o título do documento
+func (s *ChamadasService) cabecalhoInstituicao(pdf *fpdf.Fpdf, tr func(string) string, titulo, subtitulo string) {
+	x := pdfMargem
+	if s.instituicao.Logo != "" {
+		if _, err := os.Stat(s.instituicao.Logo); err == nil {
+			pdf.ImageOptions(s.instituicao.Logo, pdfMargem, pdfMargem, 0, 16, false, fpdf.ImageOptions{ReadDpi: true}, 0, "")
+			x += 30
+		}
+	}
+
+	pdf.SetXY(x, pdfMargem)
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 7, tr(s.instituicao.Nome), "", 2, "L", false, 0, "")
+	if s.instituicao.Endereco != "" {
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 5, tr(s.instituicao.Endereco), "", 2, "L", false, 0, "")
+	}
+
+	pdf.SetXY(pdfMargem, pdfMargem+18)
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 6, tr(titulo), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, tr(subtitulo), "", 1, "C", false, 0, "")
+	pdf.Ln(2)
+}
+
+// percentualFrequencia calcula presenças sobre aulas com chamada lançada ("-" quando não há lançamentos)
+func percentualFrequencia(presentes, faltas, justificadas int) string {
+	total := presentes + faltas + justificadas
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", float64(presentes)*100/float64(total))
+}
+
+// truncar limita o texto a max caracteres (nomes longos não quebram a tabela)
+func truncar(texto string, max int) string {
+	runes := []rune(texto)
+	if len(runes) <= max {
+		return texto
+	}
+	return string(runes[:max-1]) + "…"
+}
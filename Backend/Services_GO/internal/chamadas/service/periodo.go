@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sysocial/internal/chamadas/model"
+)
+
+// ErrPeriodoBloqueado indica chamada ou presença em um mês fechado para edição (ou aula futura)
+var ErrPeriodoBloqueado = errors.New("período bloqueado para edição")
+
+// GetSituacaoPeriodo informa se as chamadas da turma no mês (AAAAMM) ainda podem ser lançadas/editadas
+func (s *ChamadasService) GetSituacaoPeriodo(ctx context.Context, turmaID int, anoMes string) (*model.SituacaoPeriodo, error) {
+	if _, err := time.Parse("200601", anoMes); err != nil {
+		return nil, fmt.Errorf("%w: use AAAAMM (ex: 202511)", ErrPeriodoInvalido)
+	}
+
+	existe, err := s.repo.VerificaTurmaExiste(ctx, turmaID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar turma: %w", err)
+	}
+	if !existe {
+		return nil, ErrTurmaNaoEncontrada
+	}
+
+	return s.situacaoPeriodo(ctx, turmaID, anoMes)
+}
+
+// FecharPeriodo fecha o mês da turma antes do fim da janela de edição. Só meses já encerrados podem ser fechados.
+func (s *ChamadasService) FecharPeriodo(ctx context.Context, turmaID int, anoMes string, usuarioID int) (*model.SituacaoPeriodo, error) {
+	inicio, err := time.Parse("200601", anoMes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: use AAAAMM (ex: 202511)", ErrPeriodoInvalido)
+	}
+	if !hoje().After(inicio.AddDate(0, 1, -1)) {
+		return nil, fmt.Errorf("%w: o mês %s ainda não terminou", ErrPeriodoInvalido, anoMes)
+	}
+
+	existe, err := s.repo.VerificaTurmaExiste(ctx, turmaID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar turma: %w", err)
+	}
+	if !existe {
+		return nil, ErrTurmaNaoEncontrada
+	}
+
+	if err := s.repo.FecharPeriodoChamada(ctx, turmaID, anoMes, usuarioID); err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Mês %s da turma ID %d fechado pelo usuário ID %d", anoMes, turmaID, usuarioID)
+	return s.situacaoPeriodo(ctx, turmaID, anoMes)
+}
+
+// ReabrirPeriodo libera a edição do mês da turma até payload.Ate (padrão: hoje + dias de reabertura configurados)
+func (s *ChamadasService) ReabrirPeriodo(ctx context.Context, turmaID int, anoMes string, payload model.ReabrirPeriodoPayload, usuarioID int) (*model.SituacaoPeriodo, error) {
+	if _, err := time.Parse("200601", anoMes); err != nil {
+		return nil, fmt.Errorf("%w: use AAAAMM (ex: 202511)", ErrPeriodoInvalido)
+	}
+
+	ate := payload.Ate
+	if ate == "" {
+		ate = hoje().AddDate(0, 0, s.chamada.DiasReabertura).Format("2006-01-02")
+	}
+	if ate < hoje().Format("2006-01-02") {
+		return nil, fmt.Errorf("%w: a reabertura deve valer até hoje ou uma data futura", ErrPeriodoInvalido)
+	}
+
+	existe, err := s.repo.VerificaTurmaExiste(ctx, turmaID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar turma: %w", err)
+	}
+	if !existe {
+		return nil, ErrTurmaNaoEncontrada
+	}
+
+	if err := s.repo.ReabrirPeriodoChamada(ctx, turmaID, anoMes, ate, payload.Motivo, usuarioID); err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Mês %s da turma ID %d reaberto até %s pelo usuário ID %d: %s", anoMes, turmaID, ate, usuarioID, payload.Motivo)
+	return s.situacaoPeriodo(ctx, turmaID, anoMes)
+}
+
+// verificarEdicao bloqueia alterações de chamadas e presenças da turma em meses fechados para edição
+func (s *ChamadasService) verificarEdicao(ctx context.Context, turmaID int, dataAula string) error {
+	data, err := time.Parse("2006-01-02", dataAula)
+	if err != nil {
+		return fmt.Errorf("data da aula inválida: %s", dataAula)
+	}
+
+	situacao, err := s.situacaoPeriodo(ctx, turmaID, data.Format("200601"))
+	if err != nil {
+		return err
+	}
+	if !situacao.Editavel {
+		return fmt.Errorf("%w: chamadas de %s: %s", ErrPeriodoBloqueado, data.Format("01/2006"), situacao.Motivo)
+	}
+
+	return nil
+}
+
+// verificarLancamento aplica verificarEdicao e impede lançar presenças antes do dia da aula
+func (s *ChamadasService) verificarLancamento(ctx context.Context, chamada *model.Chamada) error {
+	if chamada.DataAula > hoje().Format("2006-01-02") {
+		return fmt.Errorf("%w: a aula de %s ainda não aconteceu", ErrPeriodoBloqueado, chamada.DataAula)
+	}
+	return s.verificarEdicao(ctx, chamada.TurmaID, chamada.DataAula)
+}
+
+// situacaoPeriodo busca o fechamento/reabertura do mês e calcula a situação atual
+func (s *ChamadasService) situacaoPeriodo(ctx context.Context, turmaID int, anoMes string) (*model.SituacaoPeriodo, error) {
+	registro, err := s.repo.GetPeriodoChamada(ctx, turmaID, anoMes)
+	if err != nil {
+		return nil, err
+	}
+
+	situacao := calcularSituacaoPeriodo(turmaID, anoMes, registro, s.chamada.DiasEdicao, hoje())
+	return &situacao, nil
+}
+
+// calcularSituacaoPeriodo aplica as regras de edição: sem registro, o mês fica aberto até diasEdicao dias após
+// o seu fim; FECHADO bloqueia; REABERTO libera até reabertoAte e depois volta a bloquear
+func calcularSituacaoPeriodo(turmaID int, anoMes string, registro *model.PeriodoChamada, diasEdicao int, hoje time.Time) model.SituacaoPeriodo {
+	situacao := model.SituacaoPeriodo{TurmaID: turmaID, AnoMes: anoMes, Situacao: model.PeriodoFechado}
+	hojeStr := hoje.Format("2006-01-02")
+
+	if registro != nil {
+		situacao.FechadoPor, situacao.FechadoEm = registro.FechadoPor, registro.FechadoEm
+		situacao.ReabertoPor, situacao.ReabertoEm = registro.ReabertoPor, registro.ReabertoEm
+
+		if registro.Situacao == model.PeriodoReaberto && registro.ReabertoAte != nil {
+			if hojeStr <= *registro.ReabertoAte {
+				situacao.Situacao = model.PeriodoReaberto
+				situacao.Editavel = true
+				situacao.EditavelAte = registro.ReabertoAte
+				situacao.Motivo = registro.Motivo
+				return situacao
+			}
+			situacao.Motivo = "reabertura encerrada em " + formatarData(*registro.ReabertoAte)
+			return situacao
+		}
+
+		situacao.Motivo = "mês fechado"
+		if registro.FechadoEm != nil {
+			situacao.Motivo += " em " + formatarData((*registro.FechadoEm)[:10])
+		}
+		return situacao
+	}
+
+	inicio, _ := time.Parse("200601", anoMes)
+	prazo := inicio.AddDate(0, 1, diasEdicao-1).Format("2006-01-02")
+	if hojeStr > prazo {
+		situacao.Motivo = "prazo de edição encerrado em " + formatarData(prazo)
+		return situacao
+	}
+
+	situacao.Situacao = model.PeriodoAberto
+	situacao.Editavel = true
+	situacao.EditavelAte = &prazo
+	return situacao
+}
+
+// hoje devolve a data atual (meia-noite UTC), base das regras de edição
+func hoje() time.Time {
+	agora := time.Now()
+	return time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// formatarData converte AAAA-MM-DD para DD/MM/AAAA nas mensagens
+func formatarData(data string) string {
+	t, err := time.Parse("2006-01-02", data)
+	if err != nil {
+		return data
+	}
+	return t.Format("02/01/2006")
+}
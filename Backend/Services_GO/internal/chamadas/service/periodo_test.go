@@ -0,0 +1,98 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"sysocial/internal/chamadas/model"
+)
+
+func TestCalcularSituacaoPeriodo(t *testing.T) {
+	data := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	texto := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		registro    *model.PeriodoChamada
+		hoje        string
+		situacao    string
+		editavel    bool
+		editavelAte string
+		motivo      string
+	}{
+		{
+			name: "sem registro, durante o mês", hoje: "2025-03-15",
+			situacao: model.PeriodoAberto, editavel: true, editavelAte: "2025-04-10",
+		},
+		{
+			name: "sem registro, último dia da janela", hoje: "2025-04-10",
+			situacao: model.PeriodoAberto, editavel: true, editavelAte: "2025-04-10",
+		},
+		{
+			name: "sem registro, janela encerrada", hoje: "2025-04-11",
+			situacao: model.PeriodoFechado, motivo: "prazo de edição encerrado em 10/04/2025",
+		},
+		{
+			name:     "fechado manualmente",
+			registro: &model.PeriodoChamada{Situacao: model.PeriodoFechado, FechadoEm: texto("2025-03-31T18:00:00")},
+			hoje:     "2025-04-02",
+			situacao: model.PeriodoFechado, motivo: "mês fechado em 31/03/2025",
+		},
+		{
+			name:     "fechado sem data",
+			registro: &model.PeriodoChamada{Situacao: model.PeriodoFechado},
+			hoje:     "2025-04-02",
+			situacao: model.PeriodoFechado, motivo: "mês fechado",
+		},
+		{
+			name:     "reaberto dentro do prazo",
+			registro: &model.PeriodoChamada{Situacao: model.PeriodoReaberto, ReabertoAte: texto("2025-05-05"), Motivo: "correção"},
+			hoje:     "2025-05-05",
+			situacao: model.PeriodoReaberto, editavel: true, editavelAte: "2025-05-05", motivo: "correção",
+		},
+		{
+			name:     "reabertura encerrada",
+			registro: &model.PeriodoChamada{Situacao: model.PeriodoReaberto, ReabertoAte: texto("2025-05-05")},
+			hoje:     "2025-05-06",
+			situacao: model.PeriodoFechado, motivo: "reabertura encerrada em 05/05/2025",
+		},
+		{
+			name:     "reaberto sem prazo conta como fechado",
+			registro: &model.PeriodoChamada{Situacao: model.PeriodoReaberto},
+			hoje:     "2025-04-02",
+			situacao: model.PeriodoFechado, motivo: "mês fechado",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calcularSituacaoPeriodo(7, "202503", tt.registro, 10, data(tt.hoje))
+
+			if got.TurmaID != 7 || got.AnoMes != "202503" {
+				t.Errorf("turma/mês = %d/%s, want 7/202503", got.TurmaID, got.AnoMes)
+			}
+			if got.Situacao != tt.situacao {
+				t.Errorf("Situacao = %s, want %s", got.Situacao, tt.situacao)
+			}
+			if got.Editavel != tt.editavel {
+				t.Errorf("Editavel = %v, want %v", got.Editavel, tt.editavel)
+			}
+			ate := ""
+			if got.EditavelAte != nil {
+				ate = *got.EditavelAte
+			}
+			if ate != tt.editavelAte {
+				t.Errorf("EditavelAte = %q, want %q", ate, tt.editavelAte)
+			}
+			if got.Motivo != tt.motivo {
+				t.Errorf("Motivo = %q, want %q", got.Motivo, tt.motivo)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sysocial/internal/chamadas/model"
+	"sysocial/internal/shared/middleware"
+)
+
+// ErrSemPermissao indica um usuário que não pode lançar chamadas ou presenças da turma
+var ErrSemPermissao = errors.New("sem permissão para lançar chamadas da turma")
+
+// verificarPermissao libera administradores em qualquer turma; os demais usuários (professores e regulares) só
+// lançam chamadas e presenças das turmas às quais estão atribuídos (titular ou substituto) na data da aula
+func (s *ChamadasService) verificarPermissao(ctx context.Context, usuario model.Usuario, turmaID int, dataAula string) error {
+	switch usuario.Tipo {
+	case middleware.TipoAdmin:
+		return nil
+	case middleware.TipoProfessor, middleware.TipoUsuario:
+		atribuido, err := s.repo.ProfessorAtribuido(ctx, turmaID, usuario.ID, dataAula)
+		if err != nil {
+			return err
+		}
+		if !atribuido {
+			return fmt.Errorf("%w: o usuário não está atribuído à turma %d em %s", ErrSemPermissao, turmaID, formatarData(dataAula))
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: usuário não identificado", ErrSemPermissao)
+	}
+}
+
+// autorizarChamada busca a chamada e aplica verificarPermissao à sua turma e data
+func (s *ChamadasService) autorizarChamada(ctx context.Context, usuario model.Usuario, chamadaID int) error {
+	chamada, err := s.repo.GetChamadaByID(ctx, chamadaID)
+	if err != nil {
+		return fmt.Errorf("chamada não encontrada: %w", err)
+	}
+	return s.verificarPermissao(ctx, usuario, chamada.TurmaID, chamada.DataAula)
+}
@@ -2,24 +2,37 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"sysocial/internal/chamadas/model"
 	"sysocial/internal/chamadas/repository"
+	"sysocial/internal/shared/config"
+	"sysocial/internal/shared/export"
 	"sysocial/internal/shared/logger"
+	"time"
 )
 
+// ErrAlunoSemMatricula indica presença lançada para aluno sem matrícula vigente na turma na data da aula
+var ErrAlunoSemMatricula = errors.New("aluno(s) sem matrícula na turma")
+
 type ChamadasService struct {
-	repo   *repository.ChamadasRepository
-	logger logger.Logger
+	repo        *repository.ChamadasRepository
+	logger      logger.Logger
+	instituicao config.InstituicaoConfig
+	frequencia  config.FrequenciaConfig
+	chamada     config.ChamadaConfig
 }
 
-func NewChamadasService(repo *repository.ChamadasRepository, logger logger.Logger) *ChamadasService {
-	return &ChamadasService{repo: repo, logger: logger}
+func NewChamadasService(repo *repository.ChamadasRepository, logger logger.Logger, instituicao config.InstituicaoConfig, frequencia config.FrequenciaConfig, chamada config.ChamadaConfig) *ChamadasService {
+	return &ChamadasService{repo: repo, logger: logger, instituicao: instituicao, frequencia: frequencia, chamada: chamada}
 }
 
 // ========== MÉTODOS PARA CHAMADA ==========
 
-func (s *ChamadasService) CreateChamada(ctx context.Context, payload model.CreateChamadaPayload) (int, error) {
+func (s *ChamadasService) CreateChamada(ctx context.Context, payload model.CreateChamadaPayload, usuario model.Usuario) (int, error) {
 	// 1. Validar se turma existe
 	existe, err := s.repo.VerificaTurmaExiste(ctx, payload.TurmaID)
 	if err != nil {
@@ -42,15 +55,48 @@ func (s *ChamadasService) CreateChamada(ctx context.Context, payload model.Creat
 		return 0, fmt.Errorf("data da aula está fora do período letivo da turma")
 	}
 
+	// 3. Validar se o calendário institucional não suspende a aula nesta data
+	semAula, err := s.repo.VerificaDiaSemAula(ctx, payload.TurmaID, payload.DataAula)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao consultar calendário: %w", err)
+	}
+	if semAula != nil {
+		return 0, fmt.Errorf("não há aula nesta data (%s: %s)", semAula.Tipo, semAula.Motivo)
+	}
+
+	// 4. Validar se o usuário pode lançar chamadas da turma
+	if err := s.verificarPermissao(ctx, usuario, payload.TurmaID, payload.DataAula); err != nil {
+		return 0, err
+	}
+
+	// 5. Validar se o mês ainda está aberto para edição
+	if err := s.verificarEdicao(ctx, payload.TurmaID, payload.DataAula); err != nil {
+		return 0, err
+	}
+
+	// 6. Uma chamada por turma e data (constraint chamada_turma_data_key)
+	existente, err := s.repo.GetChamadaByTurmaData(ctx, payload.TurmaID, payload.DataAula)
+	if err != nil {
+		return 0, err
+	}
+	if existente != nil {
+		return 0, fmt.Errorf("%w: já existe chamada para a turma nesta data (ID %d)", ErrChamadaInvalida, existente.ID)
+	}
+
 	s.logger.Infof("Criando chamada para turma ID: %d, data: %s", payload.TurmaID, payload.DataAula)
-	return s.repo.CreateChamada(ctx, payload)
+	id, err := s.repo.CreateChamada(ctx, payload, usuario.ID)
+	if errors.Is(err, repository.ErrChamadaDuplicada) {
+		// Criada por uma requisição simultânea depois da verificação acima
+		return 0, fmt.Errorf("%w: %v", ErrChamadaInvalida, err)
+	}
+	return id, err
 }
 
 func (s *ChamadasService) GetChamadasByTurmaID(ctx context.Context, turmaID int) ([]model.Chamada, error) {
 	return s.repo.GetChamadasByTurmaID(ctx, turmaID)
 }
 
-func (s *ChamadasService) UpdateChamada(ctx context.Context, id int, payload model.UpdateChamadaPayload) error {
+func (s *ChamadasService) UpdateChamada(ctx context.Context, id int, payload model.UpdateChamadaPayload, usuario model.Usuario) error {
 	// Se turmaID está sendo atualizado, verificar se existe
 	if payload.TurmaID != nil {
 		existe, err := s.repo.VerificaTurmaExiste(ctx, *payload.TurmaID)
@@ -62,15 +108,59 @@ func (s *ChamadasService) UpdateChamada(ctx context.Context, id int, payload mod
 		}
 	}
 
-	if payload.TurmaID != nil && payload.DataAula != nil {
-		dataValida, err := s.repo.CheckTurmaDateRange(ctx, *payload.TurmaID, *payload.DataAula)
-		if err != nil || !dataValida {
-			return fmt.Errorf("data da aula está fora do período letivo da turma")
+	// A chamada só pode ser alterada por quem lança a turma e enquanto o seu mês estiver aberto para edição
+	atual, err := s.repo.GetChamadaByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.verificarPermissao(ctx, usuario, atual.TurmaID, atual.DataAula); err != nil {
+		return err
+	}
+	if err := s.verificarEdicao(ctx, atual.TurmaID, atual.DataAula); err != nil {
+		return err
+	}
+
+	// Ao mudar turma ou data, o destino precisa estar no período letivo da turma, aberto e sem outra chamada
+	if payload.TurmaID != nil || payload.DataAula != nil {
+		turmaID, dataAula := atual.TurmaID, atual.DataAula
+		if payload.TurmaID != nil {
+			turmaID = *payload.TurmaID
+		}
+		if payload.DataAula != nil {
+			dataAula = *payload.DataAula
+		}
+
+		dataValida, err := s.repo.CheckTurmaDateRange(ctx, turmaID, dataAula)
+		if err != nil {
+			return fmt.Errorf("erro ao validar data da turma: %w", err)
+		}
+		if !dataValida {
+			return fmt.Errorf("%w: data da aula está fora do período letivo da turma", ErrChamadaInvalida)
+		}
+
+		if err := s.verificarPermissao(ctx, usuario, turmaID, dataAula); err != nil {
+			return err
+		}
+		if err := s.verificarEdicao(ctx, turmaID, dataAula); err != nil {
+			return err
+		}
+
+		existente, err := s.repo.GetChamadaByTurmaData(ctx, turmaID, dataAula)
+		if err != nil {
+			return err
+		}
+		if existente != nil && existente.ID != id {
+			return fmt.Errorf("%w: já existe chamada para a turma nesta data (ID %d)", ErrChamadaInvalida, existente.ID)
 		}
 	}
 
 	s.logger.Infof("Atualizando chamada ID: %d", id)
-	return s.repo.UpdateChamada(ctx, id, payload)
+	err = s.repo.UpdateChamada(ctx, id, payload)
+	if errors.Is(err, repository.ErrChamadaDuplicada) {
+		// Outra requisição levou uma chamada para a mesma turma e data depois da verificação acima
+		return fmt.Errorf("%w: %v", ErrChamadaInvalida, err)
+	}
+	return err
 }
 
 // ========== MÉTODOS PARA PRESENÇA ==========
@@ -82,54 +172,294 @@ func (s *ChamadasService) GetPresencasByChamadaID(ctx context.Context, chamadaID
 		return nil, fmt.Errorf("chamada não encontrada: %w", err)
 	}
 
-	return s.repo.GetPresencasByChamadaID(ctx, chamadaID)
+	presencas, err := s.repo.GetPresencasByChamadaID(ctx, chamadaID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Documentos de justificativa enviados ao file-service (entidade_pai = "presenca")
+	ids := make([]int, 0, len(presencas))
+	for _, p := range presencas {
+		ids = append(ids, p.ID)
+	}
+	anexos, err := s.repo.GetAnexos(ctx, repository.EntidadeAnexoPresenca, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range presencas {
+		presencas[i].Anexos = anexos[presencas[i].ID]
+		if presencas[i].Anexos == nil {
+			presencas[i].Anexos = []model.Anexo{}
+		}
+	}
+
+	return presencas, nil
 }
 
-func (s *ChamadasService) CreatePresencas(ctx context.Context, payload model.CreatePresencasPayload) error {
-	// Verificar se chamada existe
-	_, err := s.repo.GetChamadaByID(ctx, payload.ChamadaID)
+// CreatePresencas grava as presenças da chamada; com Idempotency-Key, reenvios devolvem o resultado original
+func (s *ChamadasService) CreatePresencas(ctx context.Context, payload model.CreatePresencasPayload, chave string, usuario model.Usuario) (*model.ResultadoPresencas, error) {
+	// A permissão é conferida antes da idempotência: um reenvio também precisa partir de quem lança a turma
+	if err := s.autorizarChamada(ctx, usuario, payload.ChamadaID); err != nil {
+		return nil, err
+	}
+
+	// Os códigos são gravados normalizados ("fj" -> "J"); a chave de idempotência compara o payload normalizado
+	status, err := s.carregarStatus(ctx)
 	if err != nil {
-		return fmt.Errorf("chamada não encontrada: %w", err)
+		return nil, err
+	}
+	for i, presenca := range payload.Presencas {
+		codigo, err := status.validar(presenca.Presente)
+		if err != nil {
+			return nil, fmt.Errorf("aluno %d: %w", presenca.AlunoID, err)
+		}
+		payload.Presencas[i].Presente = codigo
 	}
 
-	// Validar se todos os alunos existem
-	for _, presenca := range payload.Presencas {
-		existe, err := s.repo.VerificaAlunoExiste(ctx, presenca.AlunoID)
+	resultado := &model.ResultadoPresencas{ChamadaID: payload.ChamadaID, Quantidade: len(payload.Presencas)}
+	repetida, err := s.executarIdempotente(ctx, chave, OperacaoCreatePresencas, usuario.ID, payload, resultado, func() error {
+		// Verificar se chamada existe e se ainda pode receber lançamentos
+		chamada, err := s.repo.GetChamadaByID(ctx, payload.ChamadaID)
 		if err != nil {
-			return fmt.Errorf("erro ao verificar aluno %d: %w", presenca.AlunoID, err)
+			return fmt.Errorf("chamada não encontrada: %w", err)
 		}
-		if !existe {
-			return fmt.Errorf("aluno %d não encontrado ou inativo", presenca.AlunoID)
+		if err := s.verificarLancamento(ctx, chamada); err != nil {
+			return err
+		}
+
+		// Validar se todos os alunos estavam matriculados na turma na data da aula
+		alunos := make([]int, 0, len(payload.Presencas))
+		for _, presenca := range payload.Presencas {
+			alunos = append(alunos, presenca.AlunoID)
+		}
+		if err := s.verificarMatriculas(ctx, chamada, alunos); err != nil {
+			return err
 		}
+
+		s.logger.Infof("Criando %d presenças para chamada ID: %d", len(payload.Presencas), payload.ChamadaID)
+		return s.repo.CreatePresencas(ctx, payload.ChamadaID, payload.Presencas)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	s.logger.Infof("Criando %d presenças para chamada ID: %d", len(payload.Presencas), payload.ChamadaID)
-	return s.repo.CreatePresencas(ctx, payload.ChamadaID, payload.Presencas)
+	resultado.Repetida = repetida
+	return resultado, nil
 }
 
-func (s *ChamadasService) DeletePresencasByChamadaID(ctx context.Context, chamadaID int) error {
-	// Verificar se chamada existe
-	_, err := s.repo.GetChamadaByID(ctx, chamadaID)
+// verificarMatriculas exige que todos os alunos tenham matrícula vigente na turma na data da aula
+func (s *ChamadasService) verificarMatriculas(ctx context.Context, chamada *model.Chamada, alunos []int) error {
+	semMatricula, err := s.repo.GetAlunosSemMatricula(ctx, chamada.TurmaID, chamada.DataAula, alunos)
+	if err != nil {
+		return err
+	}
+	if len(semMatricula) > 0 {
+		return fmt.Errorf("%w em %s: %s", ErrAlunoSemMatricula, formatarData(chamada.DataAula), joinIDs(semMatricula))
+	}
+	return nil
+}
+
+func (s *ChamadasService) DeletePresencasByChamadaID(ctx context.Context, chamadaID int, usuario model.Usuario) error {
+	// Verificar se chamada existe, se o usuário lança a turma e se o mês ainda está aberto para edição
+	chamada, err := s.repo.GetChamadaByID(ctx, chamadaID)
 	if err != nil {
 		return fmt.Errorf("chamada não encontrada: %w", err)
 	}
+	if err := s.verificarPermissao(ctx, usuario, chamada.TurmaID, chamada.DataAula); err != nil {
+		return err
+	}
+	if err := s.verificarEdicao(ctx, chamada.TurmaID, chamada.DataAula); err != nil {
+		return err
+	}
 
 	s.logger.Infof("Deletando presenças da chamada ID: %d", chamadaID)
 	return s.repo.DeletePresencasByChamadaID(ctx, chamadaID)
 }
 
-// GetChamadasPorTurmaMes busca chamadas por turma e mês/ano
-func (s *ChamadasService) GetChamadasPorTurmaMes(ctx context.Context, turmaID int, anoMes string, usuarioID int) (*model.ChamadasPorTurmaMesResponse, error) {
+// GetChamadasPorTurmaMes busca chamadas por turma e mês/ano (somente leitura: não abre chamadas)
+func (s *ChamadasService) GetChamadasPorTurmaMes(ctx context.Context, turmaID int, anoMes string) (*model.ChamadasPorTurmaMesResponse, error) {
 	s.logger.Infof("Buscando chamadas para turma ID: %d, mês/ano: %s", turmaID, anoMes)
-	return s.repo.GetChamadasPorTurmaMes(ctx, turmaID, anoMes, usuarioID)
+	result, err := s.repo.GetChamadasPorTurmaMes(ctx, turmaID, anoMes)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Periodo, err = s.situacaoPeriodo(ctx, turmaID, anoMes)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-// UpsertPresencas cria ou atualiza múltiplas presenças
-func (s *ChamadasService) UpsertPresencas(ctx context.Context, payload model.UpsertPresencasPayload) error {
+// UpsertPresencas cria ou atualiza múltiplas presenças; com Idempotency-Key, reenvios devolvem o resultado original
+func (s *ChamadasService) UpsertPresencas(ctx context.Context, payload model.UpsertPresencasPayload, chave string, usuario model.Usuario) (*model.ResultadoPresencas, error) {
 	if len(payload.Records) == 0 {
-		return fmt.Errorf("lista de registros não pode estar vazia")
+		return nil, fmt.Errorf("lista de registros não pode estar vazia")
+	}
+	if err := s.autorizarChamada(ctx, usuario, payload.ChamadaID); err != nil {
+		return nil, err
+	}
+
+	status, err := s.carregarStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, record := range payload.Records {
+		codigo, err := status.validar(record.Present)
+		if err != nil {
+			return nil, fmt.Errorf("aluno %d: %w", record.IDEstudante, err)
+		}
+		payload.Records[i].Present = codigo
+	}
+
+	resultado := &model.ResultadoPresencas{ChamadaID: payload.ChamadaID, Quantidade: len(payload.Records)}
+	repetida, err := s.executarIdempotente(ctx, chave, OperacaoUpsertPresencas, usuario.ID, payload, resultado, func() error {
+		chamada, err := s.repo.GetChamadaByID(ctx, payload.ChamadaID)
+		if err != nil {
+			return fmt.Errorf("chamada não encontrada: %w", err)
+		}
+		if err := s.verificarLancamento(ctx, chamada); err != nil {
+			return err
+		}
+
+		alunos := make([]int, 0, len(payload.Records))
+		for _, record := range payload.Records {
+			alunos = append(alunos, record.IDEstudante)
+		}
+		if err := s.verificarMatriculas(ctx, chamada, alunos); err != nil {
+			return err
+		}
+
+		s.logger.Infof("Processando %d registros de presença para chamada ID: %d", len(payload.Records), payload.ChamadaID)
+		return s.repo.UpsertPresencas(ctx, payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resultado.Repetida = repetida
+	return resultado, nil
+}
+
+// ========== EXPORTAÇÃO ==========
+
+// Tipos de escopo aceitos na exportação de frequência
+const (
+	EscopoTurma = "turma"
+	EscopoCurso = "curso"
+)
+
+// foraDaMatricula marca, em planilhas e PDFs, as aulas em que o aluno não estava matriculado na turma
+const foraDaMatricula = "-"
+
+// ExportFrequenciaMensal monta a planilha de frequência do mês (AAAAMM) de uma turma ou de todas as turmas de um curso:
+// uma linha por aluno matriculado em algum dia do mês, uma coluna por data de aula ("-" fora da matrícula) e os
+// totais de presenças (P), faltas (F) e faltas justificadas (J) pela categoria de cada status. Não cria chamadas.
+func (s *ChamadasService) ExportFrequenciaMensal(ctx context.Context, escopo string, id int, anoMes string) (*export.Table, error) {
+	status, err := s.carregarStatus(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	s.logger.Infof("Processando %d registros de presença para chamada ID: %d", len(payload.Records), payload.ChamadaID)
-	return s.repo.UpsertPresencas(ctx, payload)
+	turmaIDs, err := s.turmasDoEscopo(ctx, escopo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var turmas []*model.FrequenciaTurmaMes
+	datasSet := make(map[string]bool)
+	for _, turmaID := range turmaIDs {
+		freq, err := s.repo.GetFrequenciaTurmaMes(ctx, turmaID, anoMes)
+		if err != nil {
+			return nil, err
+		}
+		turmas = append(turmas, freq)
+		for _, d := range freq.Datas {
+			datasSet[d.Data] = true
+		}
+	}
+
+	// Colunas de data: união das datas de todas as turmas (no curso, cada turma preenche apenas as suas)
+	datas := make([]string, 0, len(datasSet))
+	for d := range datasSet {
+		datas = append(datas, d)
+	}
+	sort.Strings(datas)
+
+	headers := []string{"Aluno", "Curso", "Turma"}
+	for _, d := range datas {
+		t, _ := time.Parse("2006-01-02", d)
+		headers = append(headers, t.Format("02/01"))
+	}
+	headers = append(headers, "P", "F", "J")
+
+	table := &export.Table{Sheet: "Frequência " + anoMes, Headers: headers}
+	for _, turma := range turmas {
+		datasTurma := make(map[string]bool, len(turma.Datas))
+		for _, d := range turma.Datas {
+			datasTurma[d.Data] = true
+		}
+		for _, aluno := range turma.Alunos {
+			row := []string{aluno.AlunoNome, turma.CursoNome, turma.TurmaNome}
+			for _, d := range datas {
+				codigo := codigoPresenca(aluno.Presencas[d].Present)
+				if codigo == "" && datasTurma[d] && !aluno.MatriculadoEm(d) {
+					codigo = foraDaMatricula
+				}
+				row = append(row, codigo)
+			}
+			presentes, faltas, justificadas := contarPresencas(aluno, datas, status)
+			row = append(row, strconv.Itoa(presentes), strconv.Itoa(faltas), strconv.Itoa(justificadas))
+			table.Rows = append(table.Rows, row)
+		}
+	}
+
+	s.logger.Infof("Exportando frequência de %s ID %d (%s): %d alunos, %d datas", escopo, id, anoMes, len(table.Rows), len(datas))
+	return table, nil
+}
+
+// turmasDoEscopo devolve a turma (escopo "turma") ou as turmas do curso (escopo "curso") de uma exportação
+func (s *ChamadasService) turmasDoEscopo(ctx context.Context, escopo string, id int) ([]int, error) {
+	if escopo != EscopoCurso {
+		return []int{id}, nil
+	}
+
+	ids, err := s.repo.GetTurmasByCurso(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("curso não encontrado ou sem turmas")
+	}
+	return ids, nil
+}
+
+// joinIDs formata uma lista de IDs para mensagens de erro ("3, 7, 12")
+func joinIDs(ids []int) string {
+	partes := make([]string, 0, len(ids))
+	for _, id := range ids {
+		partes = append(partes, strconv.Itoa(id))
+	}
+	return strings.Join(partes, ", ")
+}
+
+// codigoPresenca normaliza o código gravado em presenca.presente ("F " -> "F")
+func codigoPresenca(presente string) string {
+	return strings.ToUpper(strings.TrimSpace(presente))
+}
+
+// contarPresencas totaliza presenças, faltas e faltas justificadas do aluno nas datas informadas pela categoria
+// de cada status (atraso conta como presença; dispensas e códigos desconhecidos não entram nos totais)
+func contarPresencas(aluno model.AlunoPresencas, datas []string, status *statusPresenca) (presentes, faltas, justificadas int) {
+	for _, d := range datas {
+		switch status.categoria(aluno.Presencas[d].Present) {
+		case model.CategoriaPresente:
+			presentes++
+		case model.CategoriaAusente:
+			faltas++
+		case model.CategoriaJustificado:
+			justificadas++
+		}
+	}
+	return presentes, faltas, justificadas
 }
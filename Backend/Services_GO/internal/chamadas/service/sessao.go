@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sysocial/internal/chamadas/model"
+)
+
+// Erros da abertura de chamadas
+var (
+	ErrChamadaInvalida    = errors.New("chamada inválida")
+	ErrTurmaNaoEncontrada = errors.New("turma não encontrada")
+)
+
+// AbrirChamada abre a chamada (sessão de aula) da turma na data, atribuída a quem abriu. A data precisa ser
+// um dia de aula da turma: dia da grade de horários dentro do período letivo, sem feriado, recesso ou
+// cancelamento, ou uma aula extra do calendário. Abrir uma chamada já aberta devolve a existente.
+// Professores só abrem chamadas das turmas às quais estão atribuídos na data.
+func (s *ChamadasService) AbrirChamada(ctx context.Context, payload model.AbrirChamadaPayload, usuario model.Usuario) (*model.AbrirChamadaResponse, error) {
+	inicio, fim, err := s.repo.GetPeriodoTurma(ctx, payload.TurmaID)
+	if err != nil {
+		return nil, err
+	}
+	if inicio == "" {
+		return nil, ErrTurmaNaoEncontrada
+	}
+	if payload.DataAula < inicio || payload.DataAula > fim {
+		return nil, fmt.Errorf("%w: data da aula está fora do período letivo da turma (%s a %s)", ErrChamadaInvalida, inicio, fim)
+	}
+
+	aulas, diasSemAula, err := s.repo.GetDatasDeAula(ctx, payload.TurmaID, payload.DataAula, payload.DataAula)
+	if err != nil {
+		return nil, err
+	}
+	if len(diasSemAula) > 0 {
+		return nil, fmt.Errorf("%w: não há aula nesta data (%s: %s)", ErrChamadaInvalida, diasSemAula[0].Tipo, diasSemAula[0].Motivo)
+	}
+	if len(aulas) == 0 {
+		return nil, fmt.Errorf("%w: %s não é dia de aula da turma (confira a grade de horários ou cadastre uma aula extra)", ErrChamadaInvalida, payload.DataAula)
+	}
+
+	if err := s.verificarPermissao(ctx, usuario, payload.TurmaID, payload.DataAula); err != nil {
+		return nil, err
+	}
+	if err := s.verificarEdicao(ctx, payload.TurmaID, payload.DataAula); err != nil {
+		return nil, err
+	}
+
+	id, criada, err := s.repo.AbrirChamada(ctx, payload.TurmaID, payload.DataAula, usuario.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if criada {
+		s.logger.Infof("Chamada ID %d aberta para turma ID: %d, data: %s, usuário ID: %d", id, payload.TurmaID, payload.DataAula, usuario.ID)
+	}
+	return &model.AbrirChamadaResponse{ID: id, TurmaID: payload.TurmaID, DataAula: payload.DataAula, Criada: criada}, nil
+}
+
+// GerarChamadas abre, de uma vez, as chamadas de todas as datas de aula da turma no período informado
+// (limitado ao período letivo da turma). Datas que já têm chamada são mantidas, então pode ser repetida.
+// Datas em meses fechados para edição ou em que o usuário não está atribuído à turma são puladas e devolvidas em
+// Bloqueadas; sem atribuição em nenhuma data, ErrSemPermissao.
+func (s *ChamadasService) GerarChamadas(ctx context.Context, payload model.GerarChamadasPayload, usuario model.Usuario) (*model.GerarChamadasResponse, error) {
+	inicioTurma, fimTurma, err := s.repo.GetPeriodoTurma(ctx, payload.TurmaID)
+	if err != nil {
+		return nil, err
+	}
+	if inicioTurma == "" {
+		return nil, ErrTurmaNaoEncontrada
+	}
+
+	inicio, fim := inicioTurma, fimTurma
+	if payload.DataInicio > inicio {
+		inicio = payload.DataInicio
+	}
+	if payload.DataFim != "" && payload.DataFim < fim {
+		fim = payload.DataFim
+	}
+	if inicio > fim {
+		return nil, fmt.Errorf("%w: o período informado não coincide com o período letivo da turma (%s a %s)", ErrPeriodoInvalido, inicioTurma, fimTurma)
+	}
+
+	aulas, diasSemAula, err := s.repo.GetDatasDeAula(ctx, payload.TurmaID, inicio, fim)
+	if err != nil {
+		return nil, err
+	}
+
+	datas := make([]string, 0, len(aulas))
+	bloqueadas := []model.DataBloqueada{}
+	semPermissao := 0
+	edicaoMes := make(map[string]error) // A situação de edição é do mês: consulta uma vez por mês
+	verificarMes := func(data string) error {
+		if err, ok := edicaoMes[data[:7]]; ok {
+			return err
+		}
+		err := s.verificarEdicao(ctx, payload.TurmaID, data)
+		edicaoMes[data[:7]] = err
+		return err
+	}
+	for _, aula := range aulas {
+		err := s.verificarPermissao(ctx, usuario, payload.TurmaID, aula.Data)
+		if err == nil {
+			err = verificarMes(aula.Data)
+		}
+		switch {
+		case err == nil:
+			datas = append(datas, aula.Data)
+		case errors.Is(err, ErrSemPermissao):
+			semPermissao++
+			bloqueadas = append(bloqueadas, model.DataBloqueada{Data: aula.Data, Motivo: err.Error()})
+		case errors.Is(err, ErrPeriodoBloqueado):
+			bloqueadas = append(bloqueadas, model.DataBloqueada{Data: aula.Data, Motivo: err.Error()})
+		default:
+			return nil, err
+		}
+	}
+	if len(aulas) > 0 && semPermissao == len(aulas) {
+		return nil, fmt.Errorf("%w: o usuário não está atribuído à turma %d no período", ErrSemPermissao, payload.TurmaID)
+	}
+
+	criadas, err := s.repo.CreateChamadas(ctx, payload.TurmaID, datas, usuario.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Chamadas geradas para turma ID %d (%s a %s): %d criadas de %d datas de aula (%d bloqueadas)",
+		payload.TurmaID, inicio, fim, criadas, len(aulas), len(bloqueadas))
+	return &model.GerarChamadasResponse{
+		TurmaID:     payload.TurmaID,
+		DataInicio:  inicio,
+		DataFim:     fim,
+		Aulas:       len(aulas),
+		Criadas:     criadas,
+		Existentes:  len(datas) - criadas,
+		DiasSemAula: diasSemAula,
+		Bloqueadas:  bloqueadas,
+	}, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sysocial/internal/chamadas/model"
+)
+
+// ErrStatusInvalido indica um código de presença vazio, desconhecido ou inativo em status_presenca
+var ErrStatusInvalido = errors.New("status de presença inválido")
+
+// aliasesStatus são códigos antigos aceitos no lançamento e gravados com o código atual
+var aliasesStatus = map[string]string{"FJ": "J"}
+
+// statusPresenca guarda os status configurados na ordem de exibição, indexados pelo código
+type statusPresenca struct {
+	lista   []model.StatusPresenca
+	codigos map[string]model.StatusPresenca
+}
+
+// ListStatusPresenca lista os status de presença configurados, com a categoria usada na frequência
+func (s *ChamadasService) ListStatusPresenca(ctx context.Context) ([]model.StatusPresenca, error) {
+	return s.repo.GetStatusPresenca(ctx)
+}
+
+// carregarStatus busca os status de presença configurados
+func (s *ChamadasService) carregarStatus(ctx context.Context) (*statusPresenca, error) {
+	lista, err := s.repo.GetStatusPresenca(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &statusPresenca{lista: lista, codigos: make(map[string]model.StatusPresenca, len(lista))}
+	for _, st := range lista {
+		status.codigos[st.Codigo] = st
+	}
+	return status, nil
+}
+
+// categoria devolve a categoria do código gravado (ex: "F " -> AUSENTE); "" para códigos vazios ou desconhecidos,
+// que não entram nos totais
+func (st *statusPresenca) categoria(presente string) string {
+	return st.codigos[normalizarStatus(presente)].Categoria
+}
+
+// validar normaliza o código informado no lançamento e confere se é um status ativo
+func (st *statusPresenca) validar(presente string) (string, error) {
+	codigo := normalizarStatus(presente)
+	if codigo == "" {
+		return "", fmt.Errorf("%w: informe o status (%s)", ErrStatusInvalido, st.ativos())
+	}
+	if status, ok := st.codigos[codigo]; !ok || !status.Ativo {
+		return "", fmt.Errorf("%w: %q (use %s)", ErrStatusInvalido, presente, st.ativos())
+	}
+	return codigo, nil
+}
+
+// ativos lista os códigos aceitos no lançamento, para as mensagens de erro
+func (st *statusPresenca) ativos() string {
+	codigos := make([]string, 0, len(st.lista))
+	for _, status := range st.lista {
+		if status.Ativo {
+			codigos = append(codigos, status.Codigo)
+		}
+	}
+	return strings.Join(codigos, ", ")
+}
+
+// legenda descreve os status ativos para planilhas e PDFs (ex: "P = presente, F = falta")
+func (st *statusPresenca) legenda() string {
+	partes := make([]string, 0, len(st.lista))
+	for _, status := range st.lista {
+		if status.Ativo {
+			partes = append(partes, status.Codigo+" = "+strings.ToLower(status.Nome))
+		}
+	}
+	return strings.Join(partes, ", ")
+}
+
+// normalizarStatus padroniza o código (maiúsculas, sem espaços) e converte os códigos antigos
+func normalizarStatus(presente string) string {
+	codigo := codigoPresenca(presente)
+	if atual, ok := aliasesStatus[codigo]; ok {
+		return atual
+	}
+	return codigo
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"sysocial/internal/cursosturmas/model"
 	"sysocial/internal/cursosturmas/service"
+	"sysocial/internal/shared/validation"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,7 +26,7 @@ func (h *CursosTurmasHandler) CreateCurso(c *gin.Context) {
 	var payload model.CreateCursoPayload
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
@@ -80,7 +82,7 @@ func (h *CursosTurmasHandler) UpdateCurso(c *gin.Context) {
 
 	var payload model.UpdateCursoPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
@@ -136,12 +138,16 @@ func (h *CursosTurmasHandler) CreateTurma(c *gin.Context) {
 	var payload model.CreateTurmaPayload
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
 	id, err := h.service.CreateTurma(c.Request.Context(), payload)
 	if err != nil {
+		if errors.Is(err, service.ErrHorarioInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Horários inválidos", "details": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar turma", "details": err.Error()})
 		return
 	}
@@ -210,12 +216,20 @@ func (h *CursosTurmasHandler) UpdateTurma(c *gin.Context) {
 
 	var payload model.UpdateTurmaPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
 	err = h.service.UpdateTurma(c.Request.Context(), id, payload)
 	if err != nil {
+		if errors.Is(err, service.ErrHorarioInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Horários inválidos", "details": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrVagasAbaixoOcupacao) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar turma", "details": err.Error()})
 		return
 	}
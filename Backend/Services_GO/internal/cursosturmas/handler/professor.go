@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"sysocial/internal/cursosturmas/model"
+	"sysocial/internal/cursosturmas/service"
+	"sysocial/internal/shared/middleware"
+	"sysocial/internal/shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========== HANDLERS PARA PROFESSORES DA TURMA ==========
+
+// GetProfessoresTurma GET /api/v1/turmas/:id/professores
+func (h *CursosTurmasHandler) GetProfessoresTurma(c *gin.Context) {
+	turmaID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	professores, err := h.service.GetProfessoresTurma(c.Request.Context(), turmaID)
+	if err != nil {
+		respondProfessorError(c, "Erro ao listar professores da turma", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, professores)
+}
+
+// AtribuirProfessor POST /api/v1/turmas/:id/professores
+func (h *CursosTurmasHandler) AtribuirProfessor(c *gin.Context) {
+	turmaID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var payload model.CreateProfessorTurmaPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	atribuicao, err := h.service.AtribuirProfessor(c.Request.Context(), turmaID, payload, middleware.UserID(c))
+	if err != nil {
+		respondProfessorError(c, "Erro ao atribuir professor", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Professor atribuído com sucesso",
+		"atribuicao": atribuicao,
+	})
+}
+
+// UpdateAtribuicaoProfessor PUT /api/v1/turmas/:id/professores/:atribuicaoId
+func (h *CursosTurmasHandler) UpdateAtribuicaoProfessor(c *gin.Context) {
+	turmaID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	atribuicaoID, err := strconv.Atoi(c.Param("atribuicaoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da atribuição inválido"})
+		return
+	}
+
+	var payload model.UpdateProfessorTurmaPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	atribuicao, err := h.service.UpdateAtribuicaoProfessor(c.Request.Context(), turmaID, atribuicaoID, payload)
+	if err != nil {
+		respondProfessorError(c, "Erro ao atualizar atribuição", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Atribuição atualizada com sucesso",
+		"atribuicao": atribuicao,
+	})
+}
+
+// RemoverAtribuicaoProfessor DELETE /api/v1/turmas/:id/professores/:atribuicaoId
+func (h *CursosTurmasHandler) RemoverAtribuicaoProfessor(c *gin.Context) {
+	turmaID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	atribuicaoID, err := strconv.Atoi(c.Param("atribuicaoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da atribuição inválido"})
+		return
+	}
+
+	if err := h.service.RemoverAtribuicaoProfessor(c.Request.Context(), turmaID, atribuicaoID); err != nil {
+		respondProfessorError(c, "Erro ao remover atribuição", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Atribuição removida com sucesso"})
+}
+
+// GetMinhasTurmas GET /api/v1/turmas/minhas?data=AAAA-MM-DD
+func (h *CursosTurmasHandler) GetMinhasTurmas(c *gin.Context) {
+	usuarioID := middleware.UserID(c)
+	if usuarioID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	var query struct {
+		Data string `form:"data" binding:"omitempty,data"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	turmas, err := h.service.GetMinhasTurmas(c.Request.Context(), usuarioID, query.Data)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao listar turmas do professor", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, turmas)
+}
+
+// respondProfessorError converte os erros das atribuições no status HTTP adequado
+func respondProfessorError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrTurmaNaoEncontrada), errors.Is(err, service.ErrAtribuicaoNaoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{"error": message, "details": err.Error()})
+	case errors.Is(err, service.ErrAtribuicaoInvalida):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message, "details": err.Error()})
+	case errors.Is(err, service.ErrConflitoAtribuicao):
+		c.JSON(http.StatusConflict, gin.H{"error": message, "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
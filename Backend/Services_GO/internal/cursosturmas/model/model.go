@@ -1,6 +1,7 @@
 package model
 
-// Curso representa a tabela curso
+// Curso representa a tabela curso. VagasTotais e VagasRestantes são derivadas das turmas (soma de vagas_turma e
+// desconto das matrículas ATIVO), nunca informadas diretamente
 type Curso struct {
 	ID             int    `json:"id" db:"id_curso"`
 	Nome           string `json:"nome" db:"nome"`
@@ -11,58 +12,66 @@ type Curso struct {
 
 // Turma representa a tabela turma
 type Turma struct {
-	ID         int    `json:"id" db:"id_turma"`
-	CursoID    int    `json:"cursoId" db:"cursos_id_curso"`
-	CursoNome  string `json:"cursoNome" db:"curso_nome"` // Nome do curso (via JOIN)
-	DiaSemana  string `json:"diaSemana" db:"dia_semana"`
-	VagasTurma int    `json:"vagasTurma" db:"vagas_turma"`
-	NomeTurma  string `json:"nomeTurma" db:"nome_turma"`
-	Descricao  string `json:"descricao" db:"descricao"`
-	HoraInicio string `json:"horaInicio" db:"hora_inicio"`
-	HoraFim    string `json:"horaFim" db:"hora_fim"`
-	DataInicio string `json:"dataInicio" db:"data_inicio"` // Formato: YYYY-MM-DD
-	DataFim    string `json:"dataFim" db:"data_fim"`       // Formato: YYYY-MM-DD
+	ID         int            `json:"id" db:"id_turma"`
+	CursoID    int            `json:"cursoId" db:"cursos_id_curso"`
+	CursoNome  string         `json:"cursoNome" db:"curso_nome"` // Nome do curso (via JOIN)
+	DiaSemana  string         `json:"diaSemana" db:"dia_semana"` // Resumo da grade (ex: "Segunda-feira, Quarta-feira")
+	VagasTurma int            `json:"vagasTurma" db:"vagas_turma"`
+	NomeTurma  string         `json:"nomeTurma" db:"nome_turma"`
+	Descricao  string         `json:"descricao" db:"descricao"`
+	HoraInicio string         `json:"horaInicio" db:"hora_inicio"` // Início mais cedo da grade
+	HoraFim    string         `json:"horaFim" db:"hora_fim"`       // Término mais tarde da grade
+	DataInicio string         `json:"dataInicio" db:"data_inicio"` // Formato: YYYY-MM-DD
+	DataFim    string         `json:"dataFim" db:"data_fim"`       // Formato: YYYY-MM-DD
+	Horarios   []HorarioTurma `json:"horarios"`                    // Grade semanal (tabela turma_horario)
 }
 
-// CreateCursoPayload payload para criar um curso
+// HorarioTurma representa a tabela turma_horario: um encontro semanal da turma
+type HorarioTurma struct {
+	ID         int    `json:"id,omitempty" db:"id_horario"`
+	DiaSemana  string `json:"diaSemana" db:"dia_semana" binding:"required,diasemana"` // Nome do dia (gravado como 0 = domingo ... 6 = sábado)
+	HoraInicio string `json:"horaInicio" db:"hora_inicio" binding:"omitempty,hora"`
+	HoraFim    string `json:"horaFim" db:"hora_fim" binding:"omitempty,hora"`
+}
+
+// CreateCursoPayload payload para criar um curso (as vagas vêm das turmas)
 type CreateCursoPayload struct {
-	Nome        string `json:"nome" binding:"required"`
-	VagasTotais int    `json:"vagasTotais" binding:"required,min=1"`
-	Ativo       bool   `json:"ativo"`
+	Nome  string `json:"nome" binding:"required,max=20"`
+	Ativo bool   `json:"ativo"`
 }
 
-// UpdateCursoPayload payload para atualizar um curso
+// UpdateCursoPayload payload para atualizar um curso (as vagas vêm das turmas)
 type UpdateCursoPayload struct {
-	Nome           string `json:"nome"`
-	VagasTotais    *int   `json:"vagasTotais"`
-	Ativo          *bool  `json:"ativo"`
-	VagasRestantes *int   `json:"vagasRestantes"`
+	Nome  string `json:"nome" binding:"max=20"`
+	Ativo *bool  `json:"ativo"`
 }
 
 // CreateTurmaPayload payload para criar uma turma
 type CreateTurmaPayload struct {
-	CursoID    int    `json:"cursoId" binding:"required"`
-	DiaSemana  string `json:"diaSemana" binding:"required"`
-	VagasTurma int    `json:"vagasTurma" binding:"required,min=1"`
-	NomeTurma  string `json:"nomeTurma" binding:"required"`
-	Descricao  string `json:"descricao"`
-	HoraInicio string `json:"horaInicio"`
-	HoraFim    string `json:"horaFim"`
-	DataInicio string `json:"dataInicio" binding:"required"` // Formato: YYYY-MM-DD
-	DataFim    string `json:"dataFim" binding:"required"`    // Formato: YYYY-MM-DD
+	CursoID    int            `json:"cursoId" binding:"required"`
+	DiaSemana  string         `json:"diaSemana" binding:"omitempty,diasemana"` // Turma de um único horário (use horarios para vários)
+	VagasTurma int            `json:"vagasTurma" binding:"required,min=1"`
+	NomeTurma  string         `json:"nomeTurma" binding:"required"`
+	Descricao  string         `json:"descricao"`
+	HoraInicio string         `json:"horaInicio" binding:"omitempty,hora"`
+	HoraFim    string         `json:"horaFim" binding:"omitempty,hora"`
+	DataInicio string         `json:"dataInicio" binding:"required,data"` // Formato: YYYY-MM-DD
+	DataFim    string         `json:"dataFim" binding:"required,data"`    // Formato: YYYY-MM-DD
+	Horarios   []HorarioTurma `json:"horarios" binding:"omitempty,dive"`  // Grade semanal; substitui diaSemana/horaInicio/horaFim
 }
 
 // UpdateTurmaPayload payload para atualizar uma turma
 type UpdateTurmaPayload struct {
-	CursoID    *int    `json:"cursoId"`
-	DiaSemana  string  `json:"diaSemana"`
-	VagasTurma *int    `json:"vagasTurma"`
-	NomeTurma  string  `json:"nomeTurma"`
-	Descricao  *string `json:"descricao"`
-	HoraInicio *string `json:"horaInicio"`
-	HoraFim    *string `json:"horaFim"`
-	DataInicio *string `json:"dataInicio"` // Formato: YYYY-MM-DD
-	DataFim    *string `json:"dataFim"`    // Formato: YYYY-MM-DD
+	CursoID    *int           `json:"cursoId"`
+	DiaSemana  string         `json:"diaSemana" binding:"omitempty,diasemana"` // Só para turmas de um único horário
+	VagasTurma *int           `json:"vagasTurma" binding:"omitempty,min=1"`
+	NomeTurma  string         `json:"nomeTurma"`
+	Descricao  *string        `json:"descricao"`
+	HoraInicio *string        `json:"horaInicio" binding:"omitempty,hora"` // "" limpa o horário
+	HoraFim    *string        `json:"horaFim" binding:"omitempty,hora"`
+	DataInicio *string        `json:"dataInicio" binding:"omitempty,data"` // Formato: YYYY-MM-DD
+	DataFim    *string        `json:"dataFim" binding:"omitempty,data"`    // Formato: YYYY-MM-DD
+	Horarios   []HorarioTurma `json:"horarios" binding:"omitempty,dive"`   // Grade completa (substitui a atual); ausente = mantém
 }
 
 // CursoComTurmas representa um curso com suas turmas
@@ -76,3 +85,44 @@ type AlunoSimplificado struct {
 	ID   int    `json:"id" db:"id_aluno"`
 	Nome string `json:"nome" db:"nome_completo"`
 }
+
+// Papéis do professor na turma (turma_professor.papel)
+const (
+	PapelTitular    = "TITULAR"
+	PapelSubstituto = "SUBSTITUTO"
+)
+
+// ProfessorTurma representa a tabela turma_professor: a atribuição de um professor à turma em um período
+type ProfessorTurma struct {
+	ID         int     `json:"id" db:"id_atribuicao"`
+	TurmaID    int     `json:"turmaId" db:"turma_id_turma"`
+	UsuarioID  int     `json:"usuarioId" db:"usuario_id_usuario"`
+	Nome       string  `json:"nome" db:"nome"` // Nome do professor (via JOIN com usuarios)
+	Papel      string  `json:"papel" db:"papel"`
+	DataInicio string  `json:"dataInicio" db:"data_inicio"` // Formato: YYYY-MM-DD
+	DataFim    *string `json:"dataFim" db:"data_fim"`       // Formato: YYYY-MM-DD; nulo = até o fim da turma
+	Vigente    bool    `json:"vigente"`                     // Atribuição válida hoje
+}
+
+// CreateProfessorTurmaPayload payload para atribuir um professor à turma
+type CreateProfessorTurmaPayload struct {
+	UsuarioID  int    `json:"usuarioId" binding:"required,min=1"`
+	Papel      string `json:"papel" binding:"required,oneof=TITULAR SUBSTITUTO"`
+	DataInicio string `json:"dataInicio" binding:"omitempty,data"` // Padrão: início da turma
+	DataFim    string `json:"dataFim" binding:"omitempty,data"`    // Vazio = até o fim da turma
+}
+
+// UpdateProfessorTurmaPayload payload para alterar o papel ou o período de uma atribuição
+type UpdateProfessorTurmaPayload struct {
+	Papel      string  `json:"papel" binding:"omitempty,oneof=TITULAR SUBSTITUTO"`
+	DataInicio *string `json:"dataInicio" binding:"omitempty,data"`
+	DataFim    *string `json:"dataFim" binding:"omitempty,data"` // "" remove o fim (até o fim da turma)
+}
+
+// MinhaTurma é uma turma do professor autenticado, com o papel e o período da atribuição
+type MinhaTurma struct {
+	Turma
+	Papel            string  `json:"papel"`
+	AtribuicaoInicio string  `json:"atribuicaoInicio"`
+	AtribuicaoFim    *string `json:"atribuicaoFim"`
+}
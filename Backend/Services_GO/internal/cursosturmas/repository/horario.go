@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"sysocial/internal/cursosturmas/model"
+	"sysocial/internal/shared/validation"
+
+	"github.com/lib/pq"
+)
+
+// ========== GRADE DE HORÁRIOS (turma_horario) ==========
+
+// GetHorariosTurma busca a grade semanal de uma turma (segunda a domingo, por horário)
+func (r *CursosTurmasRepository) GetHorariosTurma(ctx context.Context, turmaID int) ([]model.HorarioTurma, error) {
+	horarios, err := r.getHorarios(ctx, []int{turmaID})
+	if err != nil {
+		return nil, err
+	}
+	return horarios[turmaID], nil
+}
+
+// carregarHorarios preenche a grade de várias turmas com uma única consulta
+func (r *CursosTurmasRepository) carregarHorarios(ctx context.Context, turmas []model.Turma) error {
+	if len(turmas) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(turmas))
+	for _, t := range turmas {
+		ids = append(ids, t.ID)
+	}
+
+	horarios, err := r.getHorarios(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range turmas {
+		turmas[i].Horarios = horarios[turmas[i].ID]
+		if turmas[i].Horarios == nil {
+			turmas[i].Horarios = []model.HorarioTurma{}
+		}
+	}
+	return nil
+}
+
+// getHorarios agrupa por turma os horários das turmas informadas
+func (r *CursosTurmasRepository) getHorarios(ctx context.Context, turmaIDs []int) (map[int][]model.HorarioTurma, error) {
+	query := `
+		SELECT id_horario, turma_id_turma, dia_semana,
+		       COALESCE(to_char(hora_inicio, 'HH24:MI'), ''), COALESCE(to_char(hora_fim, 'HH24:MI'), '')
+		FROM turma_horario
+		WHERE turma_id_turma = ANY($1)
+		ORDER BY turma_id_turma, (dia_semana + 6) % 7, hora_inicio NULLS FIRST`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(turmaIDs))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar horários da turma: %w", err)
+	}
+	defer rows.Close()
+
+	horarios := make(map[int][]model.HorarioTurma)
+	for rows.Next() {
+		var h model.HorarioTurma
+		var turmaID, dia int
+		if err := rows.Scan(&h.ID, &turmaID, &dia, &h.HoraInicio, &h.HoraFim); err != nil {
+			return nil, fmt.Errorf("erro ao escanear horário: %w", err)
+		}
+		if dia >= 0 && dia <= 6 {
+			h.DiaSemana = validation.NomeDiaSemana(time.Weekday(dia))
+		}
+		horarios[turmaID] = append(horarios[turmaID], h)
+	}
+
+	return horarios, rows.Err()
+}
+
+// salvarHorarios substitui a grade da turma dentro da transação
+func salvarHorarios(ctx context.Context, tx *sql.Tx, turmaID int, horarios []model.HorarioTurma) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM turma_horario WHERE turma_id_turma = $1`, turmaID); err != nil {
+		return fmt.Errorf("erro ao limpar horários da turma: %w", err)
+	}
+
+	query := `
+		INSERT INTO turma_horario (turma_id_turma, dia_semana, hora_inicio, hora_fim)
+		VALUES ($1, $2, $3, $4)`
+	for _, h := range horarios {
+		dia, ok := validation.ParseDiaSemana(h.DiaSemana)
+		if !ok {
+			return fmt.Errorf("dia da semana inválido: %s", h.DiaSemana)
+		}
+		if _, err := tx.ExecContext(ctx, query, turmaID, int(dia), nullString(h.HoraInicio), nullString(h.HoraFim)); err != nil {
+			return fmt.Errorf("erro ao salvar horário da turma: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// resumoHorarios calcula os campos de resumo de turma (dia_semana, hora_inicio, hora_fim) a partir da grade
+// já ordenada: os dias sem repetição, o início mais cedo e o término mais tarde
+func resumoHorarios(horarios []model.HorarioTurma) (string, interface{}, interface{}) {
+	var dias []string
+	var horaInicio, horaFim string
+	for i, h := range horarios {
+		if i == 0 || h.DiaSemana != horarios[i-1].DiaSemana {
+			dias = append(dias, h.DiaSemana)
+		}
+		if h.HoraInicio != "" && (horaInicio == "" || h.HoraInicio < horaInicio) {
+			horaInicio = h.HoraInicio
+		}
+		if h.HoraFim != "" && h.HoraFim > horaFim {
+			horaFim = h.HoraFim
+		}
+	}
+
+	return strings.Join(dias, ", "), nullString(horaInicio), nullString(horaFim)
+}
+
+// nullString converte "" em NULL
+func nullString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
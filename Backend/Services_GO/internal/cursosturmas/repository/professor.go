@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"sysocial/internal/cursosturmas/model"
+)
+
+// ========== PROFESSORES DA TURMA (turma_professor) ==========
+
+const selectProfessorTurma = `
+	SELECT tp.id_atribuicao, tp.turma_id_turma, tp.usuario_id_usuario, u.nome, tp.papel,
+	       to_char(tp.data_inicio, 'YYYY-MM-DD'), to_char(tp.data_fim, 'YYYY-MM-DD'),
+	       tp.data_inicio <= CURRENT_DATE AND (tp.data_fim IS NULL OR tp.data_fim >= CURRENT_DATE)
+	FROM turma_professor tp
+	INNER JOIN usuarios u ON u.id_usuario = tp.usuario_id_usuario`
+
+// GetProfessoresTurma lista os professores atribuídos à turma (titulares primeiro, por início da atribuição)
+func (r *CursosTurmasRepository) GetProfessoresTurma(ctx context.Context, turmaID int) ([]model.ProfessorTurma, error) {
+	query := selectProfessorTurma + `
+		WHERE tp.turma_id_turma = $1
+		ORDER BY tp.papel DESC, tp.data_inicio, u.nome`
+
+	rows, err := r.db.QueryContext(ctx, query, turmaID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar professores da turma: %w", err)
+	}
+	defer rows.Close()
+
+	professores := []model.ProfessorTurma{}
+	for rows.Next() {
+		professor, err := scanProfessorTurma(rows)
+		if err != nil {
+			return nil, err
+		}
+		professores = append(professores, *professor)
+	}
+
+	return professores, rows.Err()
+}
+
+// GetProfessorTurma busca uma atribuição da turma (nil quando não existe)
+func (r *CursosTurmasRepository) GetProfessorTurma(ctx context.Context, turmaID, id int) (*model.ProfessorTurma, error) {
+	query := selectProfessorTurma + `
+		WHERE tp.turma_id_turma = $1 AND tp.id_atribuicao = $2`
+
+	professor, err := scanProfessorTurma(r.db.QueryRowContext(ctx, query, turmaID, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return professor, err
+}
+
+// CreateProfessorTurma grava a atribuição do professor à turma
+func (r *CursosTurmasRepository) CreateProfessorTurma(ctx context.Context, turmaID int, payload model.CreateProfessorTurmaPayload, criadoPor int) (int, error) {
+	query := `
+		INSERT INTO turma_professor (turma_id_turma, usuario_id_usuario, papel, data_inicio, data_fim, criado_por)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id_atribuicao`
+
+	var criadoPorID interface{}
+	if criadoPor > 0 {
+		criadoPorID = criadoPor
+	}
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query, turmaID, payload.UsuarioID, payload.Papel,
+		payload.DataInicio, nullString(payload.DataFim), criadoPorID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao atribuir professor à turma: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateProfessorTurma grava o papel e o período da atribuição
+func (r *CursosTurmasRepository) UpdateProfessorTurma(ctx context.Context, professor model.ProfessorTurma) error {
+	dataFim := ""
+	if professor.DataFim != nil {
+		dataFim = *professor.DataFim
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE turma_professor SET papel = $3, data_inicio = $4, data_fim = $5
+		WHERE turma_id_turma = $1 AND id_atribuicao = $2`,
+		professor.TurmaID, professor.ID, professor.Papel, professor.DataInicio, nullString(dataFim),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar atribuição do professor: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteProfessorTurma remove a atribuição; devolve false quando ela não existe na turma
+func (r *CursosTurmasRepository) DeleteProfessorTurma(ctx context.Context, turmaID, id int) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM turma_professor WHERE turma_id_turma = $1 AND id_atribuicao = $2`,
+		turmaID, id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover atribuição do professor: %w", err)
+	}
+
+	afetadas, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover atribuição do professor: %w", err)
+	}
+	return afetadas > 0, nil
+}
+
+// GetAtribuicoesSobrepostas busca as atribuições da turma que se sobrepõem ao período informado
+// (dataFim vazio = sem fim), ignorando a atribuição ignorarID
+func (r *CursosTurmasRepository) GetAtribuicoesSobrepostas(ctx context.Context, turmaID int, dataInicio, dataFim string, ignorarID int) ([]model.ProfessorTurma, error) {
+	query := selectProfessorTurma + `
+		WHERE tp.turma_id_turma = $1 AND tp.id_atribuicao <> $2
+		  AND tp.data_inicio <= COALESCE($4::date, 'infinity'::date)
+		  AND COALESCE(tp.data_fim, 'infinity'::date) >= $3::date
+		ORDER BY tp.data_inicio`
+
+	rows, err := r.db.QueryContext(ctx, query, turmaID, ignorarID, dataInicio, nullString(dataFim))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar atribuições da turma: %w", err)
+	}
+	defer rows.Close()
+
+	var professores []model.ProfessorTurma
+	for rows.Next() {
+		professor, err := scanProfessorTurma(rows)
+		if err != nil {
+			return nil, err
+		}
+		professores = append(professores, *professor)
+	}
+
+	return professores, rows.Err()
+}
+
+// GetTipoUsuario busca o tipo do usuário (A, U ou P); "" quando o usuário não existe
+func (r *CursosTurmasRepository) GetTipoUsuario(ctx context.Context, usuarioID int) (string, error) {
+	var tipo string
+	err := r.db.QueryRowContext(ctx, `SELECT tipo FROM usuarios WHERE id_usuario = $1`, usuarioID).Scan(&tipo)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	return tipo, nil
+}
+
+// GetPeriodoTurma busca início e fim da turma (AAAA-MM-DD); strings vazias quando a turma não existe
+func (r *CursosTurmasRepository) GetPeriodoTurma(ctx context.Context, turmaID int) (string, string, error) {
+	var inicio, fim sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		`SELECT to_char(data_inicio, 'YYYY-MM-DD'), to_char(data_fim, 'YYYY-MM-DD') FROM turma WHERE id_turma = $1`,
+		turmaID,
+	).Scan(&inicio, &fim)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("erro ao buscar período da turma: %w", err)
+	}
+	return inicio.String, fim.String, nil
+}
+
+// GetTurmasDoProfessor lista as turmas às quais o usuário está atribuído na data (AAAA-MM-DD)
+func (r *CursosTurmasRepository) GetTurmasDoProfessor(ctx context.Context, usuarioID int, data string) ([]model.MinhaTurma, error) {
+	query := `
+		SELECT t.id_turma, t.cursos_id_curso, c.nome as curso_nome, t.dia_semana, t.vagas_turma, t.nome_turma,
+		       t.descricao, t.hora_inicio, t.hora_fim, t.data_inicio, t.data_fim,
+		       tp.papel, to_char(tp.data_inicio, 'YYYY-MM-DD'), to_char(tp.data_fim, 'YYYY-MM-DD')
+		FROM turma_professor tp
+		INNER JOIN turma t ON t.id_turma = tp.turma_id_turma
+		INNER JOIN curso c ON t.cursos_id_curso = c.id_curso
+		WHERE tp.usuario_id_usuario = $1
+		  AND tp.data_inicio <= $2::date
+		  AND (tp.data_fim IS NULL OR tp.data_fim >= $2::date)
+		ORDER BY c.nome, t.nome_turma`
+
+	rows, err := r.db.QueryContext(ctx, query, usuarioID, data)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar turmas do professor: %w", err)
+	}
+	defer rows.Close()
+
+	var minhas []model.MinhaTurma
+	var turmas []model.Turma
+	for rows.Next() {
+		var minha model.MinhaTurma
+		var descricao, horaInicio, horaFim, dataInicio, dataFim, atribuicaoFim sql.NullString
+
+		err := rows.Scan(
+			&minha.ID,
+			&minha.CursoID,
+			&minha.CursoNome,
+			&minha.DiaSemana,
+			&minha.VagasTurma,
+			&minha.NomeTurma,
+			&descricao,
+			&horaInicio,
+			&horaFim,
+			&dataInicio,
+			&dataFim,
+			&minha.Papel,
+			&minha.AtribuicaoInicio,
+			&atribuicaoFim,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear turma: %w", err)
+		}
+
+		minha.Descricao = descricao.String
+		minha.HoraInicio = horaInicio.String
+		minha.HoraFim = horaFim.String
+		minha.DataInicio = dataInicio.String
+		minha.DataFim = dataFim.String
+		if atribuicaoFim.Valid {
+			minha.AtribuicaoFim = &atribuicaoFim.String
+		}
+
+		minhas = append(minhas, minha)
+		turmas = append(turmas, minha.Turma)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.carregarHorarios(ctx, turmas); err != nil {
+		return nil, err
+	}
+	for i := range minhas {
+		minhas[i].Horarios = turmas[i].Horarios
+	}
+
+	return minhas, nil
+}
+
+// scanProfessorTurma lê uma linha de selectProfessorTurma
+func scanProfessorTurma(row interface{ Scan(...interface{}) error }) (*model.ProfessorTurma, error) {
+	var professor model.ProfessorTurma
+	var dataFim sql.NullString
+	err := row.Scan(
+		&professor.ID, &professor.TurmaID, &professor.UsuarioID, &professor.Nome, &professor.Papel,
+		&professor.DataInicio, &dataFim, &professor.Vigente,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao escanear professor da turma: %w", err)
+	}
+
+	if dataFim.Valid {
+		professor.DataFim = &dataFim.String
+	}
+	return &professor, nil
+}
@@ -3,10 +3,16 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"sysocial/internal/cursosturmas/model"
+	"sysocial/internal/shared/vagas"
 )
 
+// ErrVagasAbaixoOcupacao indica uma turma com menos vagas do que a ocupação (matrículas ATIVO e ofertas pendentes
+// da lista de espera)
+var ErrVagasAbaixoOcupacao = errors.New("vagas da turma abaixo da ocupação")
+
 type CursosTurmasRepository struct {
 	db *sql.DB
 }
@@ -17,7 +23,7 @@ func NewCursosTurmasRepository(db *sql.DB) *CursosTurmasRepository {
 
 // ========== MÉTODOS PARA CURSO ==========
 
-// CreateCurso cria um novo curso
+// CreateCurso cria um novo curso, sem vagas até receber turmas
 func (r *CursosTurmasRepository) CreateCurso(ctx context.Context, payload model.CreateCursoPayload) (int, error) {
 	ativo := payload.Ativo
 	if !payload.Ativo {
@@ -26,16 +32,11 @@ func (r *CursosTurmasRepository) CreateCurso(ctx context.Context, payload model.
 
 	query := `
 		INSERT INTO curso (nome, vagas_totais, ativo, vagas_restantes)
-		VALUES ($1, $2, $3, $4)
+		VALUES ($1, 0, $2, 0)
 		RETURNING id_curso`
 
 	var id int
-	err := r.db.QueryRowContext(ctx, query,
-		payload.Nome,
-		payload.VagasTotais,
-		ativo,
-		payload.VagasTotais, // vagas_restantes inicia igual a vagas_totais
-	).Scan(&id)
+	err := r.db.QueryRowContext(ctx, query, payload.Nome, ativo).Scan(&id)
 
 	if err != nil {
 		return 0, fmt.Errorf("erro ao criar curso: %w", err)
@@ -102,7 +103,7 @@ func (r *CursosTurmasRepository) GetAllCursos(ctx context.Context) ([]model.Curs
 	return cursos, nil
 }
 
-// UpdateCurso atualiza um curso
+// UpdateCurso atualiza nome e situação do curso (as vagas são derivadas das turmas)
 func (r *CursosTurmasRepository) UpdateCurso(ctx context.Context, id int, payload model.UpdateCursoPayload) error {
 	// Buscar curso atual
 	curso, err := r.GetCursoByID(ctx, id)
@@ -116,40 +117,17 @@ func (r *CursosTurmasRepository) UpdateCurso(ctx context.Context, id int, payloa
 		nome = payload.Nome
 	}
 
-	vagasTotais := curso.VagasTotais
-	if payload.VagasTotais != nil {
-		vagasTotais = *payload.VagasTotais
-		// Se vagas_totais mudou, ajustar vagas_restantes
-		if vagasTotais > curso.VagasTotais {
-			// Aumentou vagas, ajustar restantes
-			curso.VagasRestantes += (vagasTotais - curso.VagasTotais)
-		} else if vagasTotais < curso.VagasTotais {
-			// Diminuiu vagas, ajustar restantes (não pode ficar negativo)
-			diferenca := curso.VagasTotais - vagasTotais
-			if curso.VagasRestantes > diferenca {
-				curso.VagasRestantes -= diferenca
-			} else {
-				curso.VagasRestantes = 0
-			}
-		}
-	}
-
 	ativo := curso.Ativo
 	if payload.Ativo != nil {
 		ativo = *payload.Ativo
 	}
 
-	vagasRestantes := curso.VagasRestantes
-	if payload.VagasRestantes != nil {
-		vagasRestantes = *payload.VagasRestantes
-	}
-
 	query := `
 		UPDATE curso
-		SET nome = $1, vagas_totais = $2, ativo = $3, vagas_restantes = $4
-		WHERE id_curso = $5`
+		SET nome = $1, ativo = $2
+		WHERE id_curso = $3`
 
-	_, err = r.db.ExecContext(ctx, query, nome, vagasTotais, ativo, vagasRestantes, id)
+	_, err = r.db.ExecContext(ctx, query, nome, ativo, id)
 	if err != nil {
 		return fmt.Errorf("erro ao atualizar curso: %w", err)
 	}
@@ -195,15 +173,22 @@ func (r *CursosTurmasRepository) CreateTurma(ctx context.Context, payload model.
 		RETURNING id_turma`
 
 	var id int
-	var descricao, horaInicio, horaFim interface{}
+	var descricao interface{}
 	
 	if payload.Descricao != "" { descricao = payload.Descricao }
-	if payload.HoraInicio != "" { horaInicio = payload.HoraInicio }
-	if payload.HoraFim != "" { horaFim = payload.HoraFim }
 
-	err = r.db.QueryRowContext(ctx, query,
+	// dia_semana, hora_inicio e hora_fim guardam o resumo da grade (turma_horario)
+	diaSemana, horaInicio, horaFim := resumoHorarios(payload.Horarios)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query,
 		payload.CursoID,
-		payload.DiaSemana,
+		diaSemana,
 		payload.VagasTurma,
 		payload.NomeTurma,
 		descricao,
@@ -217,6 +202,19 @@ func (r *CursosTurmasRepository) CreateTurma(ctx context.Context, payload model.
 		return 0, fmt.Errorf("erro ao criar turma: %w", err)
 	}
 
+	if err := salvarHorarios(ctx, tx, id, payload.Horarios); err != nil {
+		return 0, err
+	}
+
+	// As vagas da turma entram nas vagas do curso
+	if err := vagas.RecalcularCursos(ctx, tx, []int{payload.CursoID}); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
 	return id, nil
 }
 
@@ -259,6 +257,14 @@ func (r *CursosTurmasRepository) GetTurmaByID(ctx context.Context, id int) (*mod
 	if dataInicio.Valid { turma.DataInicio = dataInicio.String }
 	if dataFim.Valid { turma.DataFim = dataFim.String }
 
+	turma.Horarios, err = r.GetHorariosTurma(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if turma.Horarios == nil {
+		turma.Horarios = []model.HorarioTurma{}
+	}
+
 	return &turma, nil
 }
 
@@ -309,6 +315,10 @@ func (r *CursosTurmasRepository) GetTurmasByCursoID(ctx context.Context, cursoID
 		turmas = append(turmas, turma)
 	}
 
+	if err := r.carregarHorarios(ctx, turmas); err != nil {
+		return nil, err
+	}
+
 	return turmas, nil
 }
 
@@ -358,10 +368,15 @@ func (r *CursosTurmasRepository) GetAllTurmas(ctx context.Context) ([]model.Turm
 		turmas = append(turmas, turma)
 	}
 
+	if err := r.carregarHorarios(ctx, turmas); err != nil {
+		return nil, err
+	}
+
 	return turmas, nil
 }
 
-// UpdateTurma atualiza uma turma
+// UpdateTurma atualiza uma turma; as vagas não podem ficar abaixo da ocupação (vagas.OcupacaoTurma) e as vagas do
+// curso (e do curso anterior, quando a turma muda de curso) são recalculadas
 func (r *CursosTurmasRepository) UpdateTurma(ctx context.Context, id int, payload model.UpdateTurmaPayload) error {
 	turma, err := r.GetTurmaByID(ctx, id)
 	if err != nil { return err }
@@ -373,9 +388,6 @@ func (r *CursosTurmasRepository) UpdateTurma(ctx context.Context, id int, payloa
 		cursoID = *payload.CursoID
 	}
 
-	diaSemana := turma.DiaSemana
-	if payload.DiaSemana != "" { diaSemana = payload.DiaSemana }
-
 	vagasTurma := turma.VagasTurma
 	if payload.VagasTurma != nil { vagasTurma = *payload.VagasTurma }
 
@@ -385,14 +397,6 @@ func (r *CursosTurmasRepository) UpdateTurma(ctx context.Context, id int, payloa
 	descricao := turma.Descricao
 	if payload.Descricao != nil { descricao = *payload.Descricao }
 
-	horaInicio := turma.HoraInicio
-	if payload.HoraInicio != nil { horaInicio = *payload.HoraInicio }
-
-	horaFim := turma.HoraFim
-	if payload.HoraFim != nil {
-		horaFim = *payload.HoraFim
-	}
-
 	dataInicio := turma.DataInicio
 	if payload.DataInicio != nil {
 		dataInicio = *payload.DataInicio
@@ -408,27 +412,81 @@ func (r *CursosTurmasRepository) UpdateTurma(ctx context.Context, id int, payloa
 		SET cursos_id_curso = $1, dia_semana = $2, vagas_turma = $3, nome_turma = $4, descricao = $5, hora_inicio = $6, hora_fim = $7, data_inicio = $8, data_fim = $9
 		WHERE id_turma = $10`
 
-	var descricaoVal, horaInicioVal, horaFimVal interface{}
+	var descricaoVal interface{}
 	if descricao != "" { descricaoVal = descricao }
-	if horaInicio != "" { horaInicioVal = horaInicio }
-	if horaFim != "" { horaFimVal = horaFim }
 
-	_, err = r.db.ExecContext(ctx, query, cursoID, diaSemana, vagasTurma, nomeTurma, descricaoVal, horaInicioVal, horaFimVal, dataInicio, dataFim, id)
+	// Resumo da grade: mantido quando a grade não muda, recalculado quando "horarios" é enviado
+	diaSemana, horaInicio, horaFim := turma.DiaSemana, nullString(turma.HoraInicio), nullString(turma.HoraFim)
+	if payload.Horarios != nil {
+		diaSemana, horaInicio, horaFim = resumoHorarios(payload.Horarios)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	if vagasTurma < turma.VagasTurma {
+		// Trava a turma: matrículas e ofertas simultâneas esperam a nova capacidade. As ofertas pendentes da lista
+		// de espera contam como as matrículas: a vaga já está prometida ao aluno até ele responder
+		var ocupadas int
+		err = tx.QueryRowContext(ctx, `SELECT `+vagas.OcupacaoTurma+` FROM turma t WHERE t.id_turma = $1 FOR UPDATE`, id).Scan(&ocupadas)
+		if err != nil {
+			return fmt.Errorf("erro ao contar a ocupação da turma: %w", err)
+		}
+		if vagasTurma < ocupadas {
+			return fmt.Errorf("%w: a turma tem %d vagas ocupadas (matrículas ativas e ofertas da lista de espera)", ErrVagasAbaixoOcupacao, ocupadas)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, query, cursoID, diaSemana, vagasTurma, nomeTurma, descricaoVal, horaInicio, horaFim, dataInicio, dataFim, id)
 	if err != nil {
 		return fmt.Errorf("erro ao atualizar turma: %w", err)
 	}
 
+	if payload.Horarios != nil {
+		if err := salvarHorarios(ctx, tx, id, payload.Horarios); err != nil {
+			return err
+		}
+	}
+
+	if err := vagas.RecalcularCursos(ctx, tx, []int{turma.CursoID, cursoID}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
 	return nil
 }
 
-// DeleteTurma deleta uma turma
+// DeleteTurma deleta uma turma e tira as vagas dela do curso
 func (r *CursosTurmasRepository) DeleteTurma(ctx context.Context, id int) error {
-	query := `DELETE FROM turma WHERE id_turma = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	var cursoID int
+	err = tx.QueryRowContext(ctx, `DELETE FROM turma WHERE id_turma = $1 RETURNING cursos_id_curso`, id).Scan(&cursoID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("erro ao deletar turma: %w", err)
 	}
 
+	if err := vagas.RecalcularCursos(ctx, tx, []int{cursoID}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
 	return nil
 }
 
@@ -450,7 +508,7 @@ func (r *CursosTurmasRepository) GetCursoComTurmas(ctx context.Context, cursoID
 	}, nil
 }
 
-// GetAlunosByTurmaID busca todos os alunos de uma turma (apenas ID e Nome)
+// GetAlunosByTurmaID busca os alunos com matrícula vigente hoje na turma (apenas ID e Nome)
 func (r *CursosTurmasRepository) GetAlunosByTurmaID(ctx context.Context, turmaID int) ([]model.AlunoSimplificado, error) {
 	// Verificar se a turma existe
 	_, err := r.GetTurmaByID(ctx, turmaID)
@@ -462,8 +520,9 @@ func (r *CursosTurmasRepository) GetAlunosByTurmaID(ctx context.Context, turmaID
 		SELECT DISTINCT a.id_aluno, a.nome_completo
 		FROM aluno a
 		INNER JOIN matricula m ON a.id_aluno = m.aluno_id_aluno
-		WHERE m.turmas_id_turma = $1
-		  AND a.ativo = true
+		WHERE m.turmas_id_turma = $1 AND m.status <> 'PRE_INSCRITO'
+		  AND m.data_inicio <= CURRENT_DATE
+		  AND (m.data_fim IS NULL OR m.data_fim >= CURRENT_DATE)
 		ORDER BY a.nome_completo`
 
 	rows, err := r.db.QueryContext(ctx, query, turmaID)
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"sysocial/internal/cursosturmas/model"
+	"sysocial/internal/shared/validation"
+)
+
+// ErrHorarioInvalido indica uma grade de horários inconsistente (dia repetido, sobreposição, término antes do início)
+var ErrHorarioInvalido = errors.New("horário da turma inválido")
+
+// horarioLegado converte os campos diaSemana/horaInicio/horaFim em uma grade de um único horário
+func horarioLegado(diaSemana, horaInicio, horaFim string) []model.HorarioTurma {
+	if diaSemana == "" {
+		return nil
+	}
+	return []model.HorarioTurma{{DiaSemana: diaSemana, HoraInicio: horaInicio, HoraFim: horaFim}}
+}
+
+// normalizarHorarios valida a grade e a devolve ordenada (segunda a domingo, por horário) com os nomes canônicos dos dias.
+// Dois horários no mesmo dia não podem se sobrepor; sem horário definido, o dia não pode se repetir.
+func normalizarHorarios(horarios []model.HorarioTurma) ([]model.HorarioTurma, error) {
+	if len(horarios) == 0 {
+		return nil, fmt.Errorf("%w: informe ao menos um dia da semana", ErrHorarioInvalido)
+	}
+
+	normalizados := make([]model.HorarioTurma, 0, len(horarios))
+	dias := make([]int, 0, len(horarios))
+	for _, h := range horarios {
+		dia, ok := validation.ParseDiaSemana(h.DiaSemana)
+		if !ok {
+			return nil, fmt.Errorf("%w: dia da semana inválido: %s", ErrHorarioInvalido, h.DiaSemana)
+		}
+		h.DiaSemana = validation.NomeDiaSemana(dia)
+		if h.HoraInicio != "" && h.HoraFim != "" && horaMinuto(h.HoraFim) <= horaMinuto(h.HoraInicio) {
+			return nil, fmt.Errorf("%w: %s termina antes de começar (%s-%s)", ErrHorarioInvalido, h.DiaSemana, h.HoraInicio, h.HoraFim)
+		}
+		normalizados = append(normalizados, h)
+		dias = append(dias, (int(dia)+6)%7) // segunda = 0 ... domingo = 6
+	}
+
+	indices := make([]int, len(normalizados))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		if dias[indices[a]] != dias[indices[b]] {
+			return dias[indices[a]] < dias[indices[b]]
+		}
+		return horaMinuto(normalizados[indices[a]].HoraInicio) < horaMinuto(normalizados[indices[b]].HoraInicio)
+	})
+
+	ordenados := make([]model.HorarioTurma, 0, len(normalizados))
+	for i, idx := range indices {
+		atual := normalizados[idx]
+		if i > 0 && dias[indices[i-1]] == dias[idx] {
+			anterior := ordenados[len(ordenados)-1]
+			if !horarioDefinido(anterior) || !horarioDefinido(atual) {
+				return nil, fmt.Errorf("%w: %s repetido sem horário de início e fim", ErrHorarioInvalido, atual.DiaSemana)
+			}
+			if horaMinuto(atual.HoraInicio) < horaMinuto(anterior.HoraFim) {
+				return nil, fmt.Errorf("%w: horários sobrepostos em %s (%s-%s e %s-%s)", ErrHorarioInvalido, atual.DiaSemana,
+					anterior.HoraInicio, anterior.HoraFim, atual.HoraInicio, atual.HoraFim)
+			}
+		}
+		ordenados = append(ordenados, atual)
+	}
+
+	return ordenados, nil
+}
+
+// horarioDefinido indica se o encontro tem início e fim
+func horarioDefinido(h model.HorarioTurma) bool {
+	return h.HoraInicio != "" && h.HoraFim != ""
+}
+
+// horaMinuto reduz "HH:MM:SS" a "HH:MM" para comparar horários
+func horaMinuto(hora string) string {
+	if len(hora) > 5 {
+		return hora[:5]
+	}
+	return hora
+}
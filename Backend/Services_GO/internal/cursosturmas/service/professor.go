@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sysocial/internal/cursosturmas/model"
+	"sysocial/internal/shared/middleware"
+)
+
+// Erros das atribuições de professores
+var (
+	ErrTurmaNaoEncontrada      = errors.New("turma não encontrada")
+	ErrAtribuicaoNaoEncontrada = errors.New("atribuição não encontrada")
+	ErrAtribuicaoInvalida      = errors.New("atribuição de professor inválida")
+	ErrConflitoAtribuicao      = errors.New("conflito de atribuição")
+)
+
+const formatoData = "2006-01-02"
+
+// GetProfessoresTurma lista os professores (titulares e substitutos) atribuídos à turma
+func (s *CursosTurmasService) GetProfessoresTurma(ctx context.Context, turmaID int) ([]model.ProfessorTurma, error) {
+	inicio, _, err := s.repo.GetPeriodoTurma(ctx, turmaID)
+	if err != nil {
+		return nil, err
+	}
+	if inicio == "" {
+		return nil, ErrTurmaNaoEncontrada
+	}
+
+	return s.repo.GetProfessoresTurma(ctx, turmaID)
+}
+
+// AtribuirProfessor atribui um professor ou operador (os que só lançam chamadas das turmas atribuídas) à turma.
+// Sem dataInicio, a atribuição começa no início da turma; sem dataFim, vale até o fim da turma.
+func (s *CursosTurmasService) AtribuirProfessor(ctx context.Context, turmaID int, payload model.CreateProfessorTurmaPayload, usuarioID int) (*model.ProfessorTurma, error) {
+	inicioTurma, _, err := s.repo.GetPeriodoTurma(ctx, turmaID)
+	if err != nil {
+		return nil, err
+	}
+	if inicioTurma == "" {
+		return nil, ErrTurmaNaoEncontrada
+	}
+	if payload.DataInicio == "" {
+		payload.DataInicio = inicioTurma
+	}
+
+	tipo, err := s.repo.GetTipoUsuario(ctx, payload.UsuarioID)
+	if err != nil {
+		return nil, err
+	}
+	if tipo == "" {
+		return nil, fmt.Errorf("%w: usuário %d não encontrado", ErrAtribuicaoInvalida, payload.UsuarioID)
+	}
+	if tipo != middleware.TipoProfessor && tipo != middleware.TipoUsuario {
+		return nil, fmt.Errorf("%w: o usuário %d não é professor nem operador", ErrAtribuicaoInvalida, payload.UsuarioID)
+	}
+
+	atribuicao := model.ProfessorTurma{TurmaID: turmaID, UsuarioID: payload.UsuarioID, Papel: payload.Papel, DataInicio: payload.DataInicio}
+	if payload.DataFim != "" {
+		atribuicao.DataFim = &payload.DataFim
+	}
+	if err := s.validarAtribuicao(ctx, atribuicao); err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.CreateProfessorTurma(ctx, turmaID, payload, usuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Professor ID %d atribuído à turma ID %d como %s (atribuição ID %d)", payload.UsuarioID, turmaID, payload.Papel, id)
+	return s.repo.GetProfessorTurma(ctx, turmaID, id)
+}
+
+// UpdateAtribuicaoProfessor altera o papel ou o período de uma atribuição da turma
+func (s *CursosTurmasService) UpdateAtribuicaoProfessor(ctx context.Context, turmaID, id int, payload model.UpdateProfessorTurmaPayload) (*model.ProfessorTurma, error) {
+	atribuicao, err := s.repo.GetProfessorTurma(ctx, turmaID, id)
+	if err != nil {
+		return nil, err
+	}
+	if atribuicao == nil {
+		return nil, ErrAtribuicaoNaoEncontrada
+	}
+
+	if payload.Papel != "" {
+		atribuicao.Papel = payload.Papel
+	}
+	if payload.DataInicio != nil && *payload.DataInicio != "" {
+		atribuicao.DataInicio = *payload.DataInicio
+	}
+	if payload.DataFim != nil {
+		atribuicao.DataFim = payload.DataFim
+		if *payload.DataFim == "" {
+			atribuicao.DataFim = nil
+		}
+	}
+	if err := s.validarAtribuicao(ctx, *atribuicao); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateProfessorTurma(ctx, *atribuicao); err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Atribuição ID %d da turma ID %d atualizada", id, turmaID)
+	return s.repo.GetProfessorTurma(ctx, turmaID, id)
+}
+
+// RemoverAtribuicaoProfessor remove a atribuição do professor à turma
+func (s *CursosTurmasService) RemoverAtribuicaoProfessor(ctx context.Context, turmaID, id int) error {
+	removida, err := s.repo.DeleteProfessorTurma(ctx, turmaID, id)
+	if err != nil {
+		return err
+	}
+	if !removida {
+		return ErrAtribuicaoNaoEncontrada
+	}
+
+	s.logger.Infof("Atribuição ID %d removida da turma ID %d", id, turmaID)
+	return nil
+}
+
+// GetMinhasTurmas lista as turmas às quais o usuário está atribuído na data (AAAA-MM-DD; padrão: hoje)
+func (s *CursosTurmasService) GetMinhasTurmas(ctx context.Context, usuarioID int, data string) ([]model.MinhaTurma, error) {
+	if data == "" {
+		data = time.Now().Format(formatoData)
+	}
+
+	turmas, err := s.repo.GetTurmasDoProfessor(ctx, usuarioID, data)
+	if err != nil {
+		return nil, err
+	}
+	if turmas == nil {
+		turmas = []model.MinhaTurma{}
+	}
+	return turmas, nil
+}
+
+// validarAtribuicao confere o período e os conflitos: a turma tem no máximo um titular por vez e o mesmo
+// professor não pode ter duas atribuições sobrepostas na turma
+func (s *CursosTurmasService) validarAtribuicao(ctx context.Context, atribuicao model.ProfessorTurma) error {
+	dataFim := ""
+	if atribuicao.DataFim != nil {
+		dataFim = *atribuicao.DataFim
+	}
+	if dataFim != "" && dataFim < atribuicao.DataInicio {
+		return fmt.Errorf("%w: data de fim deve ser posterior à data de início", ErrAtribuicaoInvalida)
+	}
+
+	sobrepostas, err := s.repo.GetAtribuicoesSobrepostas(ctx, atribuicao.TurmaID, atribuicao.DataInicio, dataFim, atribuicao.ID)
+	if err != nil {
+		return err
+	}
+	for _, outra := range sobrepostas {
+		if outra.UsuarioID == atribuicao.UsuarioID {
+			return fmt.Errorf("%w: %s já está atribuído à turma neste período (atribuição ID %d)", ErrConflitoAtribuicao, outra.Nome, outra.ID)
+		}
+		if atribuicao.Papel == model.PapelTitular && outra.Papel == model.PapelTitular {
+			return fmt.Errorf("%w: %s já é titular da turma a partir de %s (atribuição ID %d); encerre a atribuição antes",
+				ErrConflitoAtribuicao, outra.Nome, outra.DataInicio, outra.ID)
+		}
+	}
+
+	return nil
+}
@@ -8,6 +8,9 @@ import (
 	"sysocial/internal/shared/logger"
 )
 
+// ErrVagasAbaixoOcupacao indica uma redução de vagas da turma abaixo das matrículas ativas e ofertas pendentes
+var ErrVagasAbaixoOcupacao = repository.ErrVagasAbaixoOcupacao
+
 type CursosTurmasService struct {
 	repo   *repository.CursosTurmasRepository
 	logger logger.Logger
@@ -23,9 +26,6 @@ func (s *CursosTurmasService) CreateCurso(ctx context.Context, payload model.Cre
 	if payload.Nome == "" {
 		return 0, fmt.Errorf("nome do curso é obrigatório")
 	}
-	if payload.VagasTotais <= 0 {
-		return 0, fmt.Errorf("vagas totais deve ser maior que zero")
-	}
 
 	s.logger.Infof("Criando curso: %s", payload.Nome)
 	return s.repo.CreateCurso(ctx, payload)
@@ -40,13 +40,6 @@ func (s *CursosTurmasService) GetAllCursos(ctx context.Context) ([]model.Curso,
 }
 
 func (s *CursosTurmasService) UpdateCurso(ctx context.Context, id int, payload model.UpdateCursoPayload) error {
-	if payload.VagasTotais != nil && *payload.VagasTotais <= 0 {
-		return fmt.Errorf("vagas totais deve ser maior que zero")
-	}
-	if payload.VagasRestantes != nil && *payload.VagasRestantes < 0 {
-		return fmt.Errorf("vagas restantes não pode ser negativo")
-	}
-
 	s.logger.Infof("Atualizando curso ID: %d", id)
 	return s.repo.UpdateCurso(ctx, id, payload)
 }
@@ -62,9 +55,6 @@ func (s *CursosTurmasService) CreateTurma(ctx context.Context, payload model.Cre
 	if payload.NomeTurma == "" {
 		return 0, fmt.Errorf("nome da turma é obrigatório")
 	}
-	if payload.DiaSemana == "" {
-		return 0, fmt.Errorf("dia da semana é obrigatório")
-	}
 	if payload.VagasTurma <= 0 {
 		return 0, fmt.Errorf("vagas da turma deve ser maior que zero")
 	}
@@ -74,6 +64,20 @@ func (s *CursosTurmasService) CreateTurma(ctx context.Context, payload model.Cre
 	if payload.DataFim == "" {
 		return 0, fmt.Errorf("data de fim é obrigatória")
 	}
+	if payload.DataFim < payload.DataInicio {
+		return 0, fmt.Errorf("data de fim deve ser posterior à data de início")
+	}
+
+	// Grade de horários: "horarios" ou, em turmas de um único horário, diaSemana/horaInicio/horaFim
+	horarios := payload.Horarios
+	if len(horarios) == 0 {
+		horarios = horarioLegado(payload.DiaSemana, payload.HoraInicio, payload.HoraFim)
+	}
+	horarios, err := normalizarHorarios(horarios)
+	if err != nil {
+		return 0, err
+	}
+	payload.Horarios = horarios
 
 	s.logger.Infof("Criando turma: %s para curso ID: %d", payload.NomeTurma, payload.CursoID)
 	return s.repo.CreateTurma(ctx, payload)
@@ -103,6 +107,40 @@ func (s *CursosTurmasService) UpdateTurma(ctx context.Context, id int, payload m
 		}
 	}
 
+	// Os campos diaSemana/horaInicio/horaFim só alteram turmas de um único horário; a grade completa vem em "horarios"
+	if payload.Horarios == nil && (payload.DiaSemana != "" || payload.HoraInicio != nil || payload.HoraFim != nil) {
+		atuais, err := s.repo.GetHorariosTurma(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(atuais) > 1 {
+			return fmt.Errorf("%w: a turma tem %d horários; envie a grade completa em horarios", ErrHorarioInvalido, len(atuais))
+		}
+
+		horario := model.HorarioTurma{}
+		if len(atuais) == 1 {
+			horario = atuais[0]
+		}
+		if payload.DiaSemana != "" {
+			horario.DiaSemana = payload.DiaSemana
+		}
+		if payload.HoraInicio != nil {
+			horario.HoraInicio = *payload.HoraInicio
+		}
+		if payload.HoraFim != nil {
+			horario.HoraFim = *payload.HoraFim
+		}
+		payload.Horarios = []model.HorarioTurma{horario}
+	}
+
+	if payload.Horarios != nil {
+		horarios, err := normalizarHorarios(payload.Horarios)
+		if err != nil {
+			return err
+		}
+		payload.Horarios = horarios
+	}
+
 	s.logger.Infof("Atualizando turma ID: %d", id)
 	return s.repo.UpdateTurma(ctx, id, payload)
 }
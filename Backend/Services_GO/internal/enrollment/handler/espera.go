@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"sysocial/internal/enrollment/model"
+	"sysocial/internal/enrollment/service"
+	"sysocial/internal/shared/middleware"
+	"sysocial/internal/shared/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/v1/enrollments/waitlist/classes/:classId
+func (h *EnrollmentHandler) GetClassWaitlist(c *gin.Context) {
+	classID, err := strconv.Atoi(c.Param("classId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da turma inválido"})
+		return
+	}
+
+	entries, err := h.service.GetClassWaitlist(c.Request.Context(), classID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar lista de espera", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"classId": classID, "data": entries})
+}
+
+// GET /api/v1/enrollments/waitlist/offers
+func (h *EnrollmentHandler) GetPendingOffers(c *gin.Context) {
+	entries, err := h.service.GetPendingOffers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar ofertas da lista de espera", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// GET /api/v1/enrollments/:id/waitlist
+func (h *EnrollmentHandler) GetStudentWaitlist(c *gin.Context) {
+	studentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	entries, err := h.service.GetStudentWaitlist(c.Request.Context(), studentID)
+	if errors.Is(err, service.ErrAlunoNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar lista de espera", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"studentId": studentID, "data": entries})
+}
+
+// POST /api/v1/enrollments/:id/waitlist
+func (h *EnrollmentHandler) JoinWaitlist(c *gin.Context) {
+	studentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var payload model.JoinWaitlistPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	entry, err := h.service.JoinWaitlist(c.Request.Context(), studentID, payload, middleware.UserID(c))
+	if err != nil {
+		h.waitlistError(c, err, "Erro ao incluir na lista de espera")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Aluno incluído na lista de espera", "data": entry})
+}
+
+// DELETE /api/v1/enrollments/:id/waitlist/:entryId
+func (h *EnrollmentHandler) LeaveWaitlist(c *gin.Context) {
+	studentID, entryID, ok := waitlistParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.LeaveWaitlist(c.Request.Context(), studentID, entryID); err != nil {
+		h.waitlistError(c, err, "Erro ao remover da lista de espera")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Aluno removido da lista de espera"})
+}
+
+// POST /api/v1/enrollments/:id/waitlist/:entryId/accept
+func (h *EnrollmentHandler) AcceptWaitlistOffer(c *gin.Context) {
+	studentID, entryID, ok := waitlistParams(c)
+	if !ok {
+		return
+	}
+
+	matriculaID, err := h.service.AcceptWaitlistOffer(c.Request.Context(), studentID, entryID, middleware.UserID(c))
+	if err != nil {
+		h.waitlistError(c, err, "Erro ao aceitar a vaga")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Vaga aceita e matrícula realizada", "matriculaId": matriculaID})
+}
+
+// POST /api/v1/enrollments/:id/waitlist/:entryId/decline
+func (h *EnrollmentHandler) DeclineWaitlistOffer(c *gin.Context) {
+	studentID, entryID, ok := waitlistParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeclineWaitlistOffer(c.Request.Context(), studentID, entryID); err != nil {
+		h.waitlistError(c, err, "Erro ao recusar a vaga")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Vaga recusada; oferecida ao próximo da fila"})
+}
+
+// waitlistParams lê :id (aluno) e :entryId (entrada na lista de espera)
+func waitlistParams(c *gin.Context) (int, int, bool) {
+	studentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, 0, false
+	}
+	entryID, err := strconv.Atoi(c.Param("entryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da entrada inválido"})
+		return 0, 0, false
+	}
+	return studentID, entryID, true
+}
+
+// waitlistError traduz os erros da lista de espera em status HTTP
+func (h *EnrollmentHandler) waitlistError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrAlunoNaoEncontrado), errors.Is(err, service.ErrEsperaNaoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrConflitoHorario):
+		respondScheduleConflict(c, err)
+	case errors.Is(err, service.ErrJaNaListaEspera), errors.Is(err, service.ErrTurmaComVagas),
+		errors.Is(err, service.ErrOfertaIndisponivel), errors.Is(err, service.ErrTurmaLotada):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEsperaInvalida):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
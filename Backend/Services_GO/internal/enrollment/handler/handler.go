@@ -1,10 +1,16 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"sysocial/internal/enrollment/model"
 	"sysocial/internal/enrollment/service"
+	"sysocial/internal/shared/cpf"
+	"sysocial/internal/shared/export"
+	"sysocial/internal/shared/middleware"
+	"sysocial/internal/shared/validation"
 
 	"github.com/gin-gonic/gin"
 )
@@ -28,11 +34,23 @@ func (h *EnrollmentHandler) UpdateEnrollment(c *gin.Context) {
 
 	var payload model.NewEnrollmentPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
-	err = h.service.UpdateEnrollment(c.Request.Context(), id, payload)
+	err = h.service.UpdateEnrollment(c.Request.Context(), id, payload, middleware.UserID(c))
+	if errors.Is(err, service.ErrCPFInvalido) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrConflitoHorario) {
+		respondScheduleConflict(c, err)
+		return
+	}
+	if errors.Is(err, service.ErrTurmaLotada) || errors.Is(err, service.ErrMatriculaTrancada) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar matrícula", "details": err.Error()})
 		return
@@ -41,27 +59,154 @@ func (h *EnrollmentHandler) UpdateEnrollment(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Matrícula atualizada/reativada com sucesso"})
 }
 
-// GET /api/v1/enrollments/students?name=...&cpf=...
+// GET /api/v1/enrollments/students?name=...&cpf=...&minAge=...&sort=name&order=asc&limit=20&offset=0
 func (h *EnrollmentHandler) SearchStudents(c *gin.Context) {
 	var filter model.StudentFilter
 	
 	if err := c.ShouldBindQuery(&filter); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Filtros inválidos"})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	if filter.Format == export.FormatCSV || filter.Format == export.FormatXLSX {
+		h.exportStudents(c, filter)
 		return
 	}
 
-	students, err := h.service.SearchStudents(c.Request.Context(), filter)
+	page, err := h.service.SearchStudents(c.Request.Context(), filter)
+	if errors.Is(err, service.ErrFiltroInvalido) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Filtro inválido", "details": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar alunos", "details": err.Error()})
 		return
 	}
 
-	// Retorna lista vazia [] em vez de null se não tiver resultados
-	if students == nil {
-		students = []model.StudentSummary{}
+	students := page.Students
+
+	// CPF completo apenas para Administrador/Operador; demais perfis recebem mascarado
+	if !middleware.CanViewPersonalData(c) {
+		for i := range students {
+			students[i].CPF = cpf.Mask(students[i].CPF)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": students,
+		"pagination": gin.H{
+			"total":  page.Total,
+			"limit":  page.Limit,
+			"offset": page.Offset,
+		},
+	})
+}
+
+// exportStudents responde a busca de alunos como arquivo CSV/XLSX (GET /students?format=csv&columns=...)
+func (h *EnrollmentHandler) exportStudents(c *gin.Context, filter model.StudentFilter) {
+	// CPF completo apenas para Administrador/Operador; demais perfis recebem mascarado
+	table, err := h.service.ExportStudents(c.Request.Context(), filter, !middleware.CanViewPersonalData(c))
+	if errors.Is(err, service.ErrFiltroInvalido) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Filtro inválido", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao exportar alunos", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(filter.Format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.FileName("alunos", filter.Format)))
+	c.Status(http.StatusOK)
+
+	if err := export.Write(c.Writer, filter.Format, *table, ';'); err != nil {
+		// Cabeçalhos já enviados: apenas interrompe a resposta
+		_ = c.Error(err)
+	}
+}
+
+// maxImportFileSize limita o CSV de importação (alguns milhares de linhas cabem com folga)
+const maxImportFileSize = 5 << 20
+
+// POST /api/v1/enrollments/import?dryRun=true&report=csv (multipart/form-data, campo "file")
+// Sem report, responde o resumo em JSON; com report=csv|xlsx, devolve o relatório de erros para download.
+func (h *EnrollmentHandler) ImportEnrollments(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Arquivo CSV obrigatório (campo file)", "details": err.Error()})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Arquivo muito grande", "details": "limite de 5MB"})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	report := c.Query("report")
+	if report != "" && report != export.FormatCSV && report != export.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de relatório inválido", "details": "use csv ou xlsx"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao ler arquivo", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	result, err := h.service.ImportEnrollments(c.Request.Context(), file, dryRun, middleware.UserID(c))
+	if errors.Is(err, service.ErrArquivoInvalido) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Arquivo inválido", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao importar matrículas", "details": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, students)
+	if report != "" {
+		c.Header("Content-Type", export.ContentType(report))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.FileName("importacao_erros", report)))
+		c.Status(http.StatusOK)
+		if err := export.Write(c.Writer, report, service.ImportErrorReport(result), ';'); err != nil {
+			_ = c.Error(err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GET /api/v1/enrollments/import/template
+func (h *EnrollmentHandler) GetImportTemplate(c *gin.Context) {
+	c.Header("Content-Type", export.ContentType(export.FormatCSV))
+	c.Header("Content-Disposition", `attachment; filename="modelo_importacao_matriculas.csv"`)
+	c.Status(http.StatusOK)
+	if err := export.WriteCSV(c.Writer, service.ImportTemplate(), ';'); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// GET /api/v1/search?q=joao&types=student,guardian&limit=20
+func (h *EnrollmentHandler) Search(c *gin.Context) {
+	var query model.SearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	results, err := h.service.Search(c.Request.Context(), query)
+	if errors.Is(err, service.ErrFiltroInvalido) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Filtro inválido", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro na busca", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"query": query.Q, "data": results})
 }
 
 // PATCH /api/v1/enrollments/:id/cancel
@@ -73,7 +218,7 @@ func (h *EnrollmentHandler) CancelEnrollment(c *gin.Context) {
 		return
 	}
 
-	err = h.service.CancelEnrollment(c.Request.Context(), id)
+	err = h.service.CancelEnrollment(c.Request.Context(), id, middleware.UserID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao cancelar matrícula", "details": err.Error()})
 		return
@@ -82,6 +227,133 @@ func (h *EnrollmentHandler) CancelEnrollment(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Matrícula cancelada (inativada) com sucesso"})
 }
 
+// PATCH /api/v1/enrollments/:id/courses/:matriculaId/cancel
+func (h *EnrollmentHandler) CancelCourse(c *gin.Context) {
+	h.endMatricula(c, model.StatusMatriculaCancelado, "Matrícula no curso cancelada com sucesso")
+}
+
+// PATCH /api/v1/enrollments/:id/courses/:matriculaId/suspend
+func (h *EnrollmentHandler) SuspendCourse(c *gin.Context) {
+	h.endMatricula(c, model.StatusMatriculaTrancado, "Matrícula no curso trancada com sucesso")
+}
+
+// PATCH /api/v1/enrollments/:id/courses/:matriculaId/complete
+func (h *EnrollmentHandler) CompleteCourse(c *gin.Context) {
+	h.endMatricula(c, model.StatusMatriculaConcluido, "Matrícula no curso concluída com sucesso")
+}
+
+// PATCH /api/v1/enrollments/:id/courses/:matriculaId/status
+func (h *EnrollmentHandler) ChangeCourseStatus(c *gin.Context) {
+	var payload model.StatusChangePayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+	h.changeMatriculaStatus(c, payload, "Status da matrícula alterado com sucesso")
+}
+
+// endMatricula encerra uma única matrícula do aluno com o status informado (motivo e data efetiva no corpo)
+func (h *EnrollmentHandler) endMatricula(c *gin.Context, status, message string) {
+	var payload model.EndEnrollmentPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+	h.changeMatriculaStatus(c, model.StatusChangePayload{Status: status, EndEnrollmentPayload: payload}, message)
+}
+
+// changeMatriculaStatus aplica a transição de status na matrícula :matriculaId do aluno :id
+func (h *EnrollmentHandler) changeMatriculaStatus(c *gin.Context, payload model.StatusChangePayload, message string) {
+	studentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	matriculaID, err := strconv.Atoi(c.Param("matriculaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da matrícula inválido"})
+		return
+	}
+
+	// Reabrir uma matrícula trancada devolve a nova matrícula (previousMatriculaId = a trancada)
+	novaID, err := h.service.ChangeMatriculaStatus(c.Request.Context(), studentID, matriculaID, payload, middleware.UserID(c))
+	switch {
+	case err == nil && novaID != matriculaID:
+		c.JSON(http.StatusOK, gin.H{"message": message, "matriculaId": novaID, "previousMatriculaId": matriculaID, "status": payload.Status})
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": message, "matriculaId": matriculaID, "status": payload.Status})
+	case errors.Is(err, service.ErrMatriculaNaoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTransicaoInvalida), errors.Is(err, service.ErrTurmaLotada):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMudancaStatusInvalida):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao alterar status da matrícula", "details": err.Error()})
+	}
+}
+
+// POST /api/v1/enrollments/:id/courses/:matriculaId/transfer
+func (h *EnrollmentHandler) TransferCourse(c *gin.Context) {
+	studentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	matriculaID, err := strconv.Atoi(c.Param("matriculaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da matrícula inválido"})
+		return
+	}
+
+	var payload model.TransferPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, validation.Response(err))
+		return
+	}
+
+	novaID, err := h.service.TransferMatricula(c.Request.Context(), studentID, matriculaID, payload, middleware.UserID(c))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusCreated, gin.H{
+			"message":             "Transferência realizada com sucesso",
+			"matriculaId":         novaID,
+			"previousMatriculaId": matriculaID,
+		})
+	case errors.Is(err, service.ErrMatriculaNaoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrConflitoHorario):
+		respondScheduleConflict(c, err)
+	case errors.Is(err, service.ErrTransicaoInvalida), errors.Is(err, service.ErrTurmaLotada):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTransferenciaInvalida), errors.Is(err, service.ErrMudancaStatusInvalida):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao transferir matrícula", "details": err.Error()})
+	}
+}
+
+// GET /api/v1/enrollments/:id/timeline
+func (h *EnrollmentHandler) GetEnrollmentTimeline(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	events, err := h.service.GetEnrollmentTimeline(c.Request.Context(), id)
+	if errors.Is(err, service.ErrAlunoNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar histórico de matrículas", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"studentId": id, "data": events})
+}
+
 // GET /api/v1/enrollments/:id (Para Edição)
 func (h *EnrollmentHandler) GetEnrollment(c *gin.Context) {
 	idStr := c.Param("id")
@@ -102,9 +374,13 @@ func (h *EnrollmentHandler) GetEnrollment(c *gin.Context) {
 
 // GET /api/v1/enrollments/check-cpf?cpf=...
 func (h *EnrollmentHandler) CheckCpf(c *gin.Context) {
-	cpf := c.Query("cpf")
-	
-	exists, err := h.service.CheckCpfAvailability(c.Request.Context(), cpf)
+	studentCPF := c.Query("cpf")
+
+	exists, err := h.service.CheckCpfAvailability(c.Request.Context(), studentCPF)
+	if errors.Is(err, service.ErrCPFInvalido) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar CPF", "details": err.Error()})
 		return
@@ -118,16 +394,28 @@ func (h *EnrollmentHandler) CreateEnrollment(c *gin.Context) {
 	var payload model.NewEnrollmentPayload
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
-	id, err := h.service.CreateEnrollment(c.Request.Context(), payload)
+	id, err := h.service.CreateEnrollment(c.Request.Context(), payload, middleware.UserID(c))
 	if err != nil {
 		if err.Error() == "CPF já cadastrado no sistema" {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()}) // 409 Conflict
 			return
 		}
+		if errors.Is(err, service.ErrCPFInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrTurmaLotada) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrConflitoHorario) {
+			respondScheduleConflict(c, err)
+			return
+		}
 		
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao processar matrícula", "details": err.Error()})
 		return
@@ -149,7 +437,7 @@ func (h *EnrollmentHandler) GetCourseData(c *gin.Context) {
 	c.JSON(http.StatusOK, data)
 }
 
-// GET /api/v1/enrollments/available-courses?shift=manha
+// GET /api/v1/enrollments/available-courses?shift=manha&studentId=12
 func (h *EnrollmentHandler) GetAvailableCourses(c *gin.Context) {
 	schoolShift := c.Query("shift") // Lê o query param '?shift='
 
@@ -158,7 +446,18 @@ func (h *EnrollmentHandler) GetAvailableCourses(c *gin.Context) {
 		return
 	}
 
-	courses, err := h.service.GetAvailableCourses(c.Request.Context(), schoolShift)
+	// studentId (opcional): esconde as turmas que conflitam com as turmas ativas do aluno
+	var studentID int
+	if raw := c.Query("studentId"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "O parâmetro 'studentId' deve ser um ID válido"})
+			return
+		}
+		studentID = id
+	}
+
+	courses, err := h.service.GetAvailableCourses(c.Request.Context(), schoolShift, studentID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar cursos disponíveis", "details": err.Error()})
 		return
@@ -169,13 +468,17 @@ func (h *EnrollmentHandler) GetAvailableCourses(c *gin.Context) {
 
 // GET /api/v1/enrollments/guardian?cpf=...
 func (h *EnrollmentHandler) GetGuardian(c *gin.Context) {
-	cpf := c.Query("cpf")
-	if cpf == "" {
+	guardianCPF := c.Query("cpf")
+	if guardianCPF == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "CPF é obrigatório"})
 		return
 	}
 
-	guardian, err := h.service.GetGuardianByCPF(c.Request.Context(), cpf)
+	guardian, err := h.service.GetGuardianByCPF(c.Request.Context(), guardianCPF)
+	if errors.Is(err, service.ErrCPFInvalido) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar responsável", "details": err.Error()})
 		return
@@ -200,4 +503,14 @@ func (h *EnrollmentHandler) GetGuardian(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, response)
-}
\ No newline at end of file
+}
+
+// respondScheduleConflict responde 409 com os encontros sobrepostos, quando o erro traz a lista
+func respondScheduleConflict(c *gin.Context, err error) {
+	body := gin.H{"error": err.Error()}
+	var conflito *service.ConflictError
+	if errors.As(err, &conflito) {
+		body["conflicts"] = conflito.Conflicts
+	}
+	c.JSON(http.StatusConflict, body)
+}
@@ -2,6 +2,8 @@ package model
 
 import (
 	"database/sql"
+	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -27,71 +29,274 @@ type StudentSummary struct {
 type StudentFilter struct {
 	Name        string `form:"name"`
 	CPF         string `form:"cpf"`
-	Age         string `form:"age"` // Recebe como string para converter
+	Age         int    `form:"age" binding:"omitempty,min=0"`    // Idade exata (atalho para minAge=maxAge)
+	MinAge      int    `form:"minAge" binding:"omitempty,min=0"` // Idades calculadas no banco a partir de data_nascimento
+	MaxAge      int    `form:"maxAge" binding:"omitempty,min=0"`
 	Gender      string `form:"gender"`
 	School      string `form:"school"`
 	SchoolShift string `form:"schoolShift"`
-	Status      string `form:"status"` // "ATIVO", "INATIVO" ou ""
+	Status      string `form:"status" binding:"omitempty,oneof=ATIVO INATIVO"` // "ATIVO", "INATIVO" ou ""
 	Course      string `form:"course"`
 	Class       string `form:"class"`
 	CourseShift string `form:"courseShift"`
+	// Paginação e ordenação
+	Sort   string `form:"sort" binding:"omitempty,oneof=name age enrollmentDate status"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+	// Exportação: format=csv|xlsx devolve o arquivo com todos os registros do filtro (ignora limit/offset)
+	Format  string `form:"format" binding:"omitempty,oneof=json csv xlsx"`
+	Columns string `form:"columns"` // Chaves de StudentExportColumns separadas por vírgula; vazio = padrão
+}
+
+// Padrões da paginação da busca de alunos
+const (
+	DefaultStudentLimit = 20
+	DefaultStudentSort  = "name"
+)
+
+// StudentPage é uma página da busca de alunos com o total de registros do filtro
+type StudentPage struct {
+	Students []StudentSummary
+	Total    int
+	Limit    int
+	Offset   int
+}
+
+// StudentExportRow é uma linha da exportação de alunos (CSV/XLSX)
+type StudentExportRow struct {
+	ID             int
+	FullName       string
+	CPF            string
+	BirthDate      string
+	Age            int
+	Gender         string
+	Phone          string
+	School         string
+	Series         string
+	SchoolShift    string
+	Address        string
+	Status         string
+	EnrollmentDate string
+	Courses        string
+	Classes        string
+	Guardians      string // "Nome (parentesco)", principal primeiro
+	GuardianPhones string // "Nome: telefone / recado (contato)"
+}
+
+// ExportColumn associa a chave aceita em ?columns= ao título da coluna no arquivo
+type ExportColumn struct {
+	Key    string
+	Header string
+}
+
+// StudentExportColumns lista, na ordem do arquivo, as colunas disponíveis para exportação
+var StudentExportColumns = []ExportColumn{
+	{"id", "ID"},
+	{"fullName", "Nome"},
+	{"cpf", "CPF"},
+	{"birthDate", "Data de Nascimento"},
+	{"age", "Idade"},
+	{"gender", "Sexo"},
+	{"phone", "Telefone"},
+	{"school", "Escola"},
+	{"series", "Série"},
+	{"schoolShift", "Turno Escolar"},
+	{"address", "Endereço"},
+	{"status", "Status"},
+	{"enrollmentDate", "Data de Matrícula"},
+	{"courses", "Cursos"},
+	{"classes", "Turmas"},
+	{"guardians", "Responsáveis"},
+	{"guardianPhones", "Telefones dos Responsáveis"},
+}
+
+// DefaultStudentExportColumns são usadas quando ?columns= não é informado
+var DefaultStudentExportColumns = []string{
+	"fullName", "cpf", "age", "gender", "school", "schoolShift", "status",
+	"enrollmentDate", "courses", "classes", "guardians", "guardianPhones",
+}
+
+// Value retorna o valor formatado da coluna informada
+func (r StudentExportRow) Value(key string) string {
+	switch key {
+	case "id":
+		return strconv.Itoa(r.ID)
+	case "fullName":
+		return r.FullName
+	case "cpf":
+		return r.CPF
+	case "birthDate":
+		return r.BirthDate
+	case "age":
+		return strconv.Itoa(r.Age)
+	case "gender":
+		return r.Gender
+	case "phone":
+		return r.Phone
+	case "school":
+		return r.School
+	case "series":
+		return r.Series
+	case "schoolShift":
+		return r.SchoolShift
+	case "address":
+		return r.Address
+	case "status":
+		return r.Status
+	case "enrollmentDate":
+		return r.EnrollmentDate
+	case "courses":
+		return r.Courses
+	case "classes":
+		return r.Classes
+	case "guardians":
+		return r.Guardians
+	case "guardianPhones":
+		return r.GuardianPhones
+	}
+	return ""
+}
+
+// SearchQuery mapeia os parâmetros da busca unificada (GET /api/v1/search?q=...)
+type SearchQuery struct {
+	Q     string `form:"q" binding:"required,min=2,max=100"`
+	Types string `form:"types"` // Lista separada por vírgula (student,guardian,course,turma); vazio = todos
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// Tipos de resultado da busca unificada
+const (
+	SearchTypeStudent  = "student"
+	SearchTypeGuardian = "guardian"
+	SearchTypeCourse   = "course"
+	SearchTypeTurma    = "turma"
+
+	DefaultSearchLimit = 20
+)
+
+// SearchResult é um item da busca unificada, ordenado por relevância (score)
+type SearchResult struct {
+	Type     string  `json:"type"`
+	ID       int     `json:"id"`
+	Title    string  `json:"title"`
+	Subtitle string  `json:"subtitle"`
+	ParentID *int    `json:"parentId,omitempty"` // Curso da turma
+	Score    float64 `json:"score"`
 }
 
 // --- Payloads (JSON vindo do Frontend) ---
 
 // StudentPayload mapeia os dados do aluno vindos do formulário Angular
 type StudentPayload struct {
-	FullName  string `json:"fullName"`
-	BirthDate string `json:"birthDate"` // Formato YYYY-MM-DD
-	CPF       string `json:"cpf"`
-	Phone     string `json:"phone"`
-	Gender    string `json:"gender"`
+	FullName  string `json:"fullName" binding:"required,max=50"`
+	BirthDate string `json:"birthDate" binding:"required,data"` // Formato YYYY-MM-DD
+	CPF       string `json:"cpf" binding:"required,cpf"`
+	Phone     string `json:"phone" binding:"omitempty,telefone"`
+	Gender    string `json:"gender" binding:"omitempty,oneof=M F"` // Opcional: cadastros antigos não têm sexo
 	// Endereço
-	ZipCode      string `json:"zipCode"`
-	Street       string `json:"street"`
-	Number       string `json:"number"`
-	Neighborhood string `json:"neighborhood"`
+	ZipCode      string `json:"zipCode" binding:"omitempty,cep"`
+	Street       string `json:"street" binding:"max=100"`
+	Number       string `json:"number" binding:"omitempty,numeric"`
+	Neighborhood string `json:"neighborhood" binding:"max=50"`
 	// Escolaridade
-	CurrentSchool string `json:"currentSchool"`
-	Series        string `json:"series"`
-	SchoolShift   string `json:"schoolShift"`
+	CurrentSchool string `json:"currentSchool" binding:"max=50"`
+	Series        string `json:"series" binding:"omitempty,numeric"`
+	SchoolShift   string `json:"schoolShift" binding:"required,oneof=manha tarde integral"`
 	Observation   string `json:"observation"`
 	IsActive      bool   `json:"isActive"`
 }
 
 // GuardianPayload mapeia os dados de cada responsável
 type GuardianPayload struct {
-	FullName             string `json:"fullName"`
-	CPF                  string `json:"cpf"`
-	Relationship         string `json:"relationship"`
-	Phone                string `json:"phone"`
+	FullName             string `json:"fullName" binding:"required,max=50"`
+	CPF                  string `json:"cpf" binding:"required,cpf"`
+	Relationship         string `json:"relationship" binding:"required"`
+	Phone                string `json:"phone" binding:"required,telefone"`
 	PhoneContact         string `json:"phoneContact"`
-	MessagePhone1        string `json:"messagePhone1"`
+	MessagePhone1        string `json:"messagePhone1" binding:"omitempty,telefone"`
 	MessagePhone1Contact string `json:"messagePhone1Contact"`
-	MessagePhone2        string `json:"messagePhone2"`
+	MessagePhone2        string `json:"messagePhone2" binding:"omitempty,telefone"`
 	MessagePhone2Contact string `json:"messagePhone2Contact"`
 	IsPrincipal          bool   `json:"isPrincipal"`
 }
 
 // CourseEnrollmentPayload mapeia a seleção de curso e turma
 type CourseEnrollmentPayload struct {
-	CourseID string `json:"courseId"`
-	ClassID  string `json:"classId"`
+	CourseID    string `json:"courseId" binding:"required,numeric"`
+	ClassID     string `json:"classId" binding:"required,numeric"`
+	MatriculaID int    `json:"matriculaId,omitempty"` // Preenchido na leitura; usado nos encerramentos por curso
+}
+
+// EndEnrollmentPayload mapeia o encerramento de uma única matrícula (cancelamento, trancamento ou conclusão)
+type EndEnrollmentPayload struct {
+	Reason        string `json:"reason" binding:"required,max=500"`
+	EffectiveDate string `json:"effectiveDate" binding:"omitempty,data"` // Vazio = hoje
+}
+
+// TransferPayload mapeia a transferência de uma matrícula ativa para outra turma
+type TransferPayload struct {
+	TargetClassID int `json:"targetClassId" binding:"required,min=1"`
+	EndEnrollmentPayload
+}
+
+// StatusChangePayload mapeia uma transição de status qualquer da matrícula. TRANSFERIDO só pela transferência
+// e PRE_INSCRITO só na criação.
+type StatusChangePayload struct {
+	Status string `json:"status" binding:"required,oneof=ATIVO TRANCADO CONCLUIDO CANCELADO EVADIDO"`
+	EndEnrollmentPayload
+}
+
+// JoinWaitlistPayload mapeia a entrada do aluno na lista de espera de uma turma lotada
+type JoinWaitlistPayload struct {
+	ClassID        int    `json:"classId" binding:"required,min=1"`
+	Priority       int    `json:"priority" binding:"min=0,max=10"`  // 0 = ordem de chegada; maior passa na frente
+	PriorityReason string `json:"priorityReason" binding:"max=200"` // Critério da prioridade (ex: vulnerabilidade social)
+	Notes          string `json:"notes" binding:"max=500"`
 }
 
 // DocumentPayload mapeia os metadados dos documentos
 type DocumentPayload struct {
 	ID          int    `json:"id,omitempty"`
-	FileName    string `json:"fileName"`
+	FileName    string `json:"fileName" binding:"required"`
 	Observation string `json:"observation"`
 }
 
 // NewEnrollmentPayload é o objeto raiz recebido no POST
 type NewEnrollmentPayload struct {
 	Student   StudentPayload            `json:"student"`
-	Guardians []GuardianPayload         `json:"guardians"`
-	Courses   []CourseEnrollmentPayload `json:"courses"`
-	Documents []DocumentPayload         `json:"documents"`
+	Guardians []GuardianPayload         `json:"guardians" binding:"required,min=1,dive"`
+	Courses   []CourseEnrollmentPayload `json:"courses" binding:"dive"`
+	Documents []DocumentPayload         `json:"documents" binding:"dive"`
+}
+
+// --- Importação em lote (CSV) ---
+
+// ImportRowError é um problema encontrado em uma linha do arquivo importado
+type ImportRowError struct {
+	Line    int    `json:"line"`   // Linha no arquivo (cabeçalho = 1)
+	Column  string `json:"column"` // Coluna do CSV, quando identificável
+	Message string `json:"message"`
+}
+
+// ImportResult resume o processamento de uma importação (ou simulação, quando DryRun)
+type ImportResult struct {
+	DryRun     bool             `json:"dryRun"`
+	TotalRows  int              `json:"totalRows"`
+	ValidRows  int              `json:"validRows"`
+	Imported   int              `json:"imported"`
+	StudentIDs []int            `json:"studentIds"`
+	Errors     []ImportRowError `json:"errors"`
+}
+
+// ClassInfo traz o curso e as vagas restantes da turma (vagas_turma menos as matrículas ATIVO e as ofertas pendentes
+// da lista de espera), usado para validar importações, transferências e a entrada na lista de espera
+type ClassInfo struct {
+	ClassID        int
+	CourseID       int
+	AvailableSpots int
+	StartDate      string // Período da turma, YYYY-MM-DD
+	EndDate        string
 }
 
 // --- Entidades do Banco de Dados ---
@@ -136,11 +341,72 @@ type StudentGuardianPivot struct {
 }
 
 type Matricula struct {
-	ID            int       `db:"id_matricula"`
-	AlunoID       int       `db:"aluno_id_aluno"`
-	TurmaID       int       `db:"turmas_id_turma"`
-	Status        string    `db:"status"`
-	DataMatricula time.Time `db:"data_matricula"`
+	ID            int        `db:"id_matricula"`
+	AlunoID       int        `db:"aluno_id_aluno"`
+	TurmaID       int        `db:"turmas_id_turma"`
+	Status        string     `db:"status"`
+	DataMatricula time.Time  `db:"data_matricula"`
+	DataInicio    time.Time  `db:"data_inicio"`
+	DataFim       *time.Time `db:"data_fim"`
+}
+
+// Status do ciclo de vida da matrícula (transições permitidas em scripts_sql/matricula_historico.sql)
+const (
+	StatusMatriculaPreInscrito = "PRE_INSCRITO"
+	StatusMatriculaAtivo       = "ATIVO"
+	StatusMatriculaTrancado    = "TRANCADO"
+	StatusMatriculaTransferido = "TRANSFERIDO"
+	StatusMatriculaConcluido   = "CONCLUIDO"
+	StatusMatriculaCancelado   = "CANCELADO"
+	StatusMatriculaEvadido     = "EVADIDO"
+)
+
+// EnrollmentEvent é uma transição de status de uma matrícula na linha do tempo do aluno
+type EnrollmentEvent struct {
+	ID            int     `json:"id"`
+	MatriculaID   int     `json:"matriculaId"`
+	CourseID      int     `json:"courseId"`
+	CourseName    string  `json:"courseName"`
+	ClassID       int     `json:"classId"`
+	ClassName     string  `json:"className"`
+	FromStatus    *string `json:"fromStatus"` // nil = criação da matrícula
+	ToStatus      string  `json:"toStatus"`
+	EffectiveDate string  `json:"effectiveDate"`
+	Reason        string  `json:"reason"`
+	UserID        *int    `json:"userId"`
+	UserName      string  `json:"userName"`
+	RecordedAt    string  `json:"recordedAt"`
+}
+
+// Status da lista de espera (transições em scripts_sql/lista_espera.sql)
+const (
+	StatusEsperaAguardando = "AGUARDANDO"
+	StatusEsperaOfertada   = "OFERTADA"
+	StatusEsperaAceita     = "ACEITA"
+	StatusEsperaRecusada   = "RECUSADA"
+	StatusEsperaExpirada   = "EXPIRADA"
+	StatusEsperaRemovida   = "REMOVIDA"
+)
+
+// WaitlistEntry é a entrada de um aluno na lista de espera de uma turma
+type WaitlistEntry struct {
+	ID             int     `json:"id"`
+	StudentID      int     `json:"studentId"`
+	StudentName    string  `json:"studentName"`
+	CourseID       int     `json:"courseId"`
+	CourseName     string  `json:"courseName"`
+	ClassID        int     `json:"classId"`
+	ClassName      string  `json:"className"`
+	Position       *int    `json:"position"` // Posição na fila (só AGUARDANDO)
+	Priority       int     `json:"priority"`
+	PriorityReason string  `json:"priorityReason"`
+	Notes          string  `json:"notes"`
+	Status         string  `json:"status"`
+	JoinedAt       string  `json:"joinedAt"`
+	OfferedAt      *string `json:"offeredAt"`
+	OfferExpiresAt *string `json:"offerExpiresAt"`
+	AnsweredAt     *string `json:"answeredAt"`
+	MatriculaID    *int    `json:"matriculaId"` // Matrícula criada ao aceitar a oferta
 }
 
 type CourseOption struct {
@@ -152,11 +418,58 @@ type CourseOption struct {
 }
 
 type ClassOption struct {
-	ID          int    `json:"id" db:"id_turma"`
-	Name        string `json:"name" db:"nome_turma"`
-	DayOfWeek   string `json:"dayOfWeek" db:"dia_semana"`
-	StartTime   string `json:"startTime" db:"hora_inicio"`
-	EndTime     string `json:"endTime" db:"hora_fim"`
-	Spots       int    `json:"spots" db:"vagas_turma"`
-	Description string `json:"description" db:"descricao"`
+	ID             int             `json:"id" db:"id_turma"`
+	Name           string          `json:"name" db:"nome_turma"`
+	DayOfWeek      string          `json:"dayOfWeek" db:"dia_semana"` // Resumo da grade (ex: "Segunda-feira, Quarta-feira")
+	StartTime      string          `json:"startTime" db:"hora_inicio"`
+	EndTime        string          `json:"endTime" db:"hora_fim"`
+	Spots          int             `json:"spots" db:"vagas_turma"`
+	AvailableSpots int             `json:"availableSpots"` // vagas_turma menos as matrículas ATIVO e ofertas da lista de espera
+	Description    string          `json:"description" db:"descricao"`
+	Schedule       []ClassSchedule `json:"schedule"` // Grade semanal completa (turma_horario)
+}
+
+// Tipos de conflito de horário
+const (
+	ConflitoTurma = "TURMA" // Com outra turma do aluno
+	ConflitoTurno = "TURNO" // Com o turno escolar do aluno
+)
+
+// ShiftWindow é uma janela do turno escolar (HH:MM), válida de segunda a sexta
+type ShiftWindow struct {
+	Name  string
+	Start string
+	End   string
+}
+
+// ScheduleConflict é um encontro semanal de uma turma que se sobrepõe (mesmo dia, horário e período letivo) a um
+// encontro de outra turma do aluno ou, no tipo TURNO, ao turno escolar (ConflictingClassID = 0)
+type ScheduleConflict struct {
+	Type                 string `json:"type"`
+	ClassID              int    `json:"classId"`
+	ClassName            string `json:"className"`
+	ConflictingClassID   int    `json:"conflictingClassId"`
+	ConflictingClassName string `json:"conflictingClassName"`
+	DayOfWeek            string `json:"dayOfWeek"`
+	StartTime            string `json:"startTime"` // HH:MM ("" se não definido)
+	EndTime              string `json:"endTime"`
+	ConflictingStartTime string `json:"conflictingStartTime"`
+	ConflictingEndTime   string `json:"conflictingEndTime"`
+}
+
+// String descreve o conflito para as mensagens de erro
+func (c ScheduleConflict) String() string {
+	horario := c.StartTime + "-" + c.EndTime
+	if c.StartTime == "" || c.EndTime == "" {
+		horario = "sem horário definido"
+	}
+	return fmt.Sprintf("%s (%s %s) conflita com %s (%s-%s)", c.ClassName, c.DayOfWeek, horario,
+		c.ConflictingClassName, c.ConflictingStartTime, c.ConflictingEndTime)
+}
+
+// ClassSchedule um encontro semanal da turma
+type ClassSchedule struct {
+	DayOfWeek string `json:"dayOfWeek"` // Nome do dia (ex: Segunda-feira)
+	StartTime string `json:"startTime"` // HH:MM ("" se não definido)
+	EndTime   string `json:"endTime"`
 }
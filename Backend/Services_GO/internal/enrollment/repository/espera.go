@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"sysocial/internal/enrollment/model"
+	"sysocial/internal/shared/vagas"
+
+	"github.com/lib/pq"
+)
+
+// ========== LISTA DE ESPERA ==========
+
+// ocupacaoTurma é a ocupação da turma t usada também nas vagas dos cursos
+const ocupacaoTurma = vagas.OcupacaoTurma
+
+// ordemEspera é a ordem da fila: maior prioridade primeiro e, na mesma prioridade, quem entrou antes
+const ordemEspera = `e.prioridade DESC, e.data_entrada, e.id_espera`
+
+// selectEspera lê as entradas da lista de espera; a posição só existe para quem está AGUARDANDO
+const selectEspera = `
+	SELECT e.id_espera, a.id_aluno, a.nome_completo, c.id_curso, c.nome, t.id_turma, t.nome_turma,
+	       CASE WHEN e.status = 'AGUARDANDO' THEN (
+	           SELECT COUNT(*) + 1 FROM lista_espera o
+	           WHERE o.turma_id_turma = e.turma_id_turma AND o.status = 'AGUARDANDO'
+	             AND (o.prioridade > e.prioridade
+	                  OR (o.prioridade = e.prioridade AND (o.data_entrada, o.id_espera) < (e.data_entrada, e.id_espera))))
+	       END,
+	       e.prioridade, COALESCE(e.prioridade_motivo, ''), COALESCE(e.observacoes, ''), e.status,
+	       to_char(e.data_entrada, 'YYYY-MM-DD"T"HH24:MI:SS'),
+	       to_char(e.oferta_em, 'YYYY-MM-DD"T"HH24:MI:SS'),
+	       to_char(e.oferta_expira_em, 'YYYY-MM-DD"T"HH24:MI:SS'),
+	       to_char(e.respondido_em, 'YYYY-MM-DD"T"HH24:MI:SS'),
+	       e.matricula_id_matricula
+	FROM lista_espera e
+	INNER JOIN aluno a ON a.id_aluno = e.aluno_id_aluno
+	INNER JOIN turma t ON t.id_turma = e.turma_id_turma
+	INNER JOIN curso c ON c.id_curso = t.cursos_id_curso`
+
+// GetClassWaitlist lista a fila da turma (ofertas pendentes primeiro, depois quem aguarda, na ordem da fila)
+func (r *EnrollmentRepository) GetClassWaitlist(ctx context.Context, classID int) ([]model.WaitlistEntry, error) {
+	return r.queryWaitlist(ctx, selectEspera+`
+		WHERE e.turma_id_turma = $1 AND e.status IN ('AGUARDANDO', 'OFERTADA')
+		ORDER BY e.status = 'OFERTADA' DESC, `+ordemEspera, classID)
+}
+
+// GetPendingOffers lista as ofertas de vaga ainda no prazo de todas as turmas, das que vencem primeiro
+func (r *EnrollmentRepository) GetPendingOffers(ctx context.Context) ([]model.WaitlistEntry, error) {
+	return r.queryWaitlist(ctx, selectEspera+`
+		WHERE e.status = 'OFERTADA' AND e.oferta_expira_em > now()
+		ORDER BY e.oferta_expira_em, e.id_espera`)
+}
+
+// GetStudentWaitlist lista todas as entradas do aluno em listas de espera, das mais recentes para as mais antigas
+func (r *EnrollmentRepository) GetStudentWaitlist(ctx context.Context, studentID int) ([]model.WaitlistEntry, error) {
+	return r.queryWaitlist(ctx, selectEspera+`
+		WHERE e.aluno_id_aluno = $1
+		ORDER BY e.data_entrada DESC, e.id_espera DESC`, studentID)
+}
+
+// GetWaitlistEntry busca uma entrada do aluno na lista de espera; nil quando não existe (ou é de outro aluno)
+func (r *EnrollmentRepository) GetWaitlistEntry(ctx context.Context, studentID, entryID int) (*model.WaitlistEntry, error) {
+	entries, err := r.queryWaitlist(ctx, selectEspera+`
+		WHERE e.id_espera = $1 AND e.aluno_id_aluno = $2`, entryID, studentID)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// JoinWaitlist coloca o aluno na fila da turma; devolve 0 quando ele já está na fila (AGUARDANDO ou OFERTADA)
+func (r *EnrollmentRepository) JoinWaitlist(ctx context.Context, studentID int, payload model.JoinWaitlistPayload, userID int) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO lista_espera (turma_id_turma, aluno_id_aluno, prioridade, prioridade_motivo, observacoes, registrado_por)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6)
+		ON CONFLICT (turma_id_turma, aluno_id_aluno) WHERE status IN ('AGUARDANDO', 'OFERTADA') DO NOTHING
+		RETURNING id_espera`,
+		payload.ClassID, studentID, payload.Priority, payload.PriorityReason, payload.Notes, nullableUserID(userID)).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("erro ao incluir aluno na lista de espera: %w", err)
+	}
+	return id, nil
+}
+
+// CloseWaitlistEntry encerra a entrada (RECUSADA ou REMOVIDA) se ela ainda estiver no status informado;
+// devolve false quando o status mudou no meio do caminho
+func (r *EnrollmentRepository) CloseWaitlistEntry(ctx context.Context, entryID int, fromStatus, toStatus string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE lista_espera SET status = $3, respondido_em = now()
+		WHERE id_espera = $1 AND status = $2`, entryID, fromStatus, toStatus)
+	if err != nil {
+		return false, fmt.Errorf("erro ao atualizar lista de espera: %w", err)
+	}
+	afetadas, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao atualizar lista de espera: %w", err)
+	}
+	return afetadas > 0, nil
+}
+
+// AcceptWaitlistOffer aceita a oferta ainda no prazo e matricula o aluno na turma a partir de hoje, usando a vaga
+// que estava reservada para ele. Devolve o ID da matrícula, ou 0 quando a oferta já não está pendente (expirou ou
+// foi respondida por outra requisição).
+func (r *EnrollmentRepository) AcceptWaitlistOffer(ctx context.Context, entry model.WaitlistEntry, userID int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE lista_espera SET status = 'ACEITA', respondido_em = now()
+		WHERE id_espera = $1 AND status = 'OFERTADA' AND oferta_expira_em > now()`, entry.ID)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao aceitar oferta da lista de espera: %w", err)
+	}
+	afetadas, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao aceitar oferta da lista de espera: %w", err)
+	}
+	if afetadas == 0 {
+		return 0, nil
+	}
+
+	// A oferta aceita deixa de reservar a vaga e a matrícula passa a ocupá-la
+	if err := reserveClassSpot(ctx, tx, entry.ClassID); err != nil {
+		return 0, err
+	}
+
+	var matriculaID int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO matricula (aluno_id_aluno, turmas_id_turma, status, data_matricula, data_inicio)
+		VALUES ($1, $2, 'ATIVO', CURRENT_DATE, CURRENT_DATE) RETURNING id_matricula`, entry.StudentID, entry.ClassID).Scan(&matriculaID)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao criar matrícula: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE lista_espera SET matricula_id_matricula = $2 WHERE id_espera = $1`, entry.ID, matriculaID); err != nil {
+		return 0, fmt.Errorf("erro ao atualizar lista de espera: %w", err)
+	}
+
+	if err := insertHistorico(ctx, tx, matriculaID, "", model.StatusMatriculaAtivo, "", "Vaga da lista de espera", userID); err != nil {
+		return 0, err
+	}
+	if err := vagas.RecalcularPorTurmas(ctx, tx, []int{entry.ClassID}); err != nil {
+		return 0, err
+	}
+	if err := updateStudentActive(ctx, tx, entry.StudentID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+	return matriculaID, nil
+}
+
+// RemoveStudentWaitlist tira o aluno de todas as filas em que ainda está e devolve as turmas em que ele tinha oferta
+// pendente (vagas que voltam a ficar livres)
+func (r *EnrollmentRepository) RemoveStudentWaitlist(ctx context.Context, studentID int) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE lista_espera SET status = 'REMOVIDA', respondido_em = now()
+		WHERE aluno_id_aluno = $1 AND status IN ('AGUARDANDO', 'OFERTADA')
+		RETURNING turma_id_turma, oferta_expira_em IS NOT NULL`, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao remover aluno das listas de espera: %w", err)
+	}
+	defer rows.Close()
+
+	turmas := []int{}
+	for rows.Next() {
+		var turmaID int
+		var ofertada bool
+		if err := rows.Scan(&turmaID, &ofertada); err != nil {
+			return nil, fmt.Errorf("erro ao escanear lista de espera: %w", err)
+		}
+		if ofertada {
+			turmas = append(turmas, turmaID)
+		}
+	}
+	return turmas, rows.Err()
+}
+
+// OfferWaitlistSpots expira as ofertas vencidas e oferece as vagas livres das turmas aos próximos da fila, com prazo
+// de horasOferta horas para responder. classIDs nil processa todas as turmas com fila (varredura). Devolve as
+// ofertas feitas e quantas ofertas expiraram.
+func (r *EnrollmentRepository) OfferWaitlistSpots(ctx context.Context, classIDs []int, horasOferta int) ([]model.WaitlistEntry, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. Ofertas vencidas: o aluno perde a vez e a turma entra no processamento
+	expirarSQL := `
+		UPDATE lista_espera SET status = 'EXPIRADA', respondido_em = now()
+		WHERE status = 'OFERTADA' AND oferta_expira_em <= now()`
+	args := []interface{}{}
+	if classIDs != nil {
+		expirarSQL += ` AND turma_id_turma = ANY($1)`
+		args = append(args, pq.Array(classIDs))
+	}
+	expiradas, err := queryInts(ctx, tx, expirarSQL+` RETURNING turma_id_turma`, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao expirar ofertas da lista de espera: %w", err)
+	}
+
+	// 2. Turmas a processar, em ordem crescente (mesma ordem de lock das matrículas)
+	turmas := append(append([]int{}, classIDs...), expiradas...)
+	if classIDs == nil {
+		comFila, err := queryInts(ctx, tx, `SELECT DISTINCT turma_id_turma FROM lista_espera WHERE status = 'AGUARDANDO'`)
+		if err != nil {
+			return nil, 0, fmt.Errorf("erro ao buscar turmas com lista de espera: %w", err)
+		}
+		turmas = append(turmas, comFila...)
+	}
+	sort.Ints(turmas)
+
+	// 3. Cada vaga livre vai para o próximo da fila que ainda não está matriculado na turma
+	ofertarSQL := `
+		UPDATE lista_espera SET status = 'OFERTADA', oferta_em = now(), oferta_expira_em = now() + make_interval(hours => $3)
+		WHERE id_espera IN (
+			SELECT e.id_espera FROM lista_espera e
+			WHERE e.turma_id_turma = $1 AND e.status = 'AGUARDANDO'
+			  AND NOT EXISTS (
+				SELECT 1 FROM matricula m
+				WHERE m.aluno_id_aluno = e.aluno_id_aluno AND m.turmas_id_turma = e.turma_id_turma AND m.status = 'ATIVO')
+			ORDER BY ` + ordemEspera + `
+			LIMIT $2
+			FOR UPDATE)
+		RETURNING id_espera, aluno_id_aluno, turma_id_turma, to_char(oferta_expira_em, 'YYYY-MM-DD"T"HH24:MI:SS')`
+
+	ofertas := []model.WaitlistEntry{}
+	for i, turmaID := range turmas {
+		if i > 0 && turmaID == turmas[i-1] {
+			continue
+		}
+
+		var livres int
+		err := tx.QueryRowContext(ctx, `SELECT t.vagas_turma - `+ocupacaoTurma+` FROM turma t WHERE t.id_turma = $1 FOR UPDATE`, turmaID).Scan(&livres)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("erro ao verificar vagas da turma: %w", err)
+		}
+		if livres <= 0 {
+			continue
+		}
+
+		rows, err := tx.QueryContext(ctx, ofertarSQL, turmaID, livres, horasOferta)
+		if err != nil {
+			return nil, 0, fmt.Errorf("erro ao ofertar vagas da lista de espera: %w", err)
+		}
+		for rows.Next() {
+			var o model.WaitlistEntry
+			var expira string
+			o.Status = model.StatusEsperaOfertada
+			if err := rows.Scan(&o.ID, &o.StudentID, &o.ClassID, &expira); err != nil {
+				rows.Close()
+				return nil, 0, fmt.Errorf("erro ao escanear oferta da lista de espera: %w", err)
+			}
+			o.OfferExpiresAt = &expira
+			ofertas = append(ofertas, o)
+		}
+		if err := rows.Close(); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// 4. Ofertas feitas, expiradas ou devolvidas mudam a ocupação: as vagas dos cursos acompanham
+	if err := vagas.RecalcularPorTurmas(ctx, tx, turmas); err != nil {
+		return nil, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+	return ofertas, len(expiradas), nil
+}
+
+// queryWaitlist executa uma consulta montada sobre selectEspera
+func (r *EnrollmentRepository) queryWaitlist(ctx context.Context, query string, args ...interface{}) ([]model.WaitlistEntry, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar lista de espera: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []model.WaitlistEntry{}
+	for rows.Next() {
+		var e model.WaitlistEntry
+		var position, matriculaID sql.NullInt64
+		var offeredAt, expiresAt, answeredAt sql.NullString
+		err := rows.Scan(&e.ID, &e.StudentID, &e.StudentName, &e.CourseID, &e.CourseName, &e.ClassID, &e.ClassName,
+			&position, &e.Priority, &e.PriorityReason, &e.Notes, &e.Status, &e.JoinedAt,
+			&offeredAt, &expiresAt, &answeredAt, &matriculaID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear lista de espera: %w", err)
+		}
+		if position.Valid {
+			p := int(position.Int64)
+			e.Position = &p
+		}
+		if matriculaID.Valid {
+			id := int(matriculaID.Int64)
+			e.MatriculaID = &id
+		}
+		e.OfferedAt = nullableString(offeredAt)
+		e.OfferExpiresAt = nullableString(expiresAt)
+		e.AnsweredAt = nullableString(answeredAt)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// queryInts lê uma coluna inteira de todas as linhas da consulta
+func queryInts(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// nullableString devolve nil para valores nulos do banco
+func nullableString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sysocial/internal/enrollment/model"
+	"sysocial/internal/shared/validation"
+
+	"github.com/lib/pq"
+)
+
+// ========== CONFLITOS DE HORÁRIO ==========
+
+// semConflitoAluno exclui a turma t que tenha encontro sobreposto (mesmo dia, horário e período letivo) a um
+// encontro das turmas em que o aluno $%d está ATIVO; as próprias turmas do aluno continuam na lista
+const semConflitoAluno = `NOT EXISTS (
+			SELECT 1 FROM turma_horario h
+			INNER JOIN turma_horario ho ON ho.dia_semana = h.dia_semana AND ho.turma_id_turma <> h.turma_id_turma
+			INNER JOIN turma o ON o.id_turma = ho.turma_id_turma
+			INNER JOIN matricula m ON m.turmas_id_turma = o.id_turma AND m.status = 'ATIVO'
+			WHERE h.turma_id_turma = t.id_turma AND m.aluno_id_aluno = $%d
+			  AND h.hora_inicio < ho.hora_fim AND ho.hora_inicio < h.hora_fim
+			  AND t.data_inicio <= o.data_fim AND o.data_inicio <= t.data_fim)`
+
+// sobreposicaoTurno é a condição de um encontro h cair na janela do turno escolar ($%d início, $%d fim): dia útil e
+// horários que se cruzam. Encontro em dia útil sem hora de início ou de fim não garante estar fora do turno e conta
+// como sobreposto, tanto na lista de cursos disponíveis quanto na conferência da grade.
+const sobreposicaoTurno = `(h.dia_semana BETWEEN 1 AND 5 AND (h.hora_inicio IS NULL OR h.hora_fim IS NULL OR
+			($%d::time < h.hora_fim AND h.hora_inicio < $%d::time)))`
+
+// GetScheduleConflicts lista os encontros semanais (turma_horario) das turmas classIDs que se sobrepõem aos das
+// turmas otherClassIDs: mesmo dia da semana, horários que se cruzam e períodos letivos (turma.data_inicio/data_fim)
+// com dias em comum. Encontros sem hora de início ou de fim não geram conflito.
+func (r *EnrollmentRepository) GetScheduleConflicts(ctx context.Context, classIDs, otherClassIDs []int) ([]model.ScheduleConflict, error) {
+	conflicts := []model.ScheduleConflict{}
+	if len(classIDs) == 0 || len(otherClassIDs) == 0 {
+		return conflicts, nil
+	}
+
+	query := `
+		SELECT 'TURMA', t.id_turma, t.nome_turma, o.id_turma, o.nome_turma, h.dia_semana,
+		       to_char(h.hora_inicio, 'HH24:MI'), to_char(h.hora_fim, 'HH24:MI'),
+		       to_char(ho.hora_inicio, 'HH24:MI'), to_char(ho.hora_fim, 'HH24:MI')
+		FROM turma_horario h
+		INNER JOIN turma t ON t.id_turma = h.turma_id_turma
+		INNER JOIN turma_horario ho ON ho.dia_semana = h.dia_semana AND ho.turma_id_turma <> h.turma_id_turma
+		INNER JOIN turma o ON o.id_turma = ho.turma_id_turma
+		WHERE h.turma_id_turma = ANY($1)
+		  AND ho.turma_id_turma = ANY($2)
+		  AND h.hora_inicio < ho.hora_fim AND ho.hora_inicio < h.hora_fim
+		  AND t.data_inicio <= o.data_fim AND o.data_inicio <= t.data_fim
+		ORDER BY t.nome_turma, (h.dia_semana + 6) % 7, h.hora_inicio, o.nome_turma`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(classIDs), pq.Array(otherClassIDs))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar conflitos de horário: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c model.ScheduleConflict
+		var day int
+		err := rows.Scan(&c.Type, &c.ClassID, &c.ClassName, &c.ConflictingClassID, &c.ConflictingClassName, &day,
+			&c.StartTime, &c.EndTime, &c.ConflictingStartTime, &c.ConflictingEndTime)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear conflito de horário: %w", err)
+		}
+		c.DayOfWeek = validation.NomeDiaSemana(time.Weekday(day))
+		conflicts = append(conflicts, c)
+	}
+
+	return conflicts, rows.Err()
+}
+
+// GetShiftConflicts lista os encontros semanais das turmas classIDs que caem no turno escolar do aluno (janelas de
+// segunda a sexta). Encontros em dia útil sem hora de início ou de fim também entram (ver sobreposicaoTurno).
+func (r *EnrollmentRepository) GetShiftConflicts(ctx context.Context, classIDs []int, janelas []model.ShiftWindow) ([]model.ScheduleConflict, error) {
+	conflicts := []model.ScheduleConflict{}
+	if len(classIDs) == 0 {
+		return conflicts, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT t.id_turma, t.nome_turma, h.dia_semana,
+		       COALESCE(to_char(h.hora_inicio, 'HH24:MI'), ''), COALESCE(to_char(h.hora_fim, 'HH24:MI'), '')
+		FROM turma_horario h
+		INNER JOIN turma t ON t.id_turma = h.turma_id_turma
+		WHERE h.turma_id_turma = ANY($1) AND %s
+		ORDER BY t.nome_turma, h.dia_semana, h.hora_inicio`, fmt.Sprintf(sobreposicaoTurno, 2, 3))
+
+	for _, janela := range janelas {
+		rows, err := r.db.QueryContext(ctx, query, pq.Array(classIDs), janela.Start, janela.End)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao verificar conflitos com o turno escolar: %w", err)
+		}
+
+		for rows.Next() {
+			c := model.ScheduleConflict{
+				Type:                 model.ConflitoTurno,
+				ConflictingClassName: "turno escolar " + janela.Name,
+				ConflictingStartTime: janela.Start,
+				ConflictingEndTime:   janela.End,
+			}
+			var day int
+			if err := rows.Scan(&c.ClassID, &c.ClassName, &day, &c.StartTime, &c.EndTime); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("erro ao escanear conflito com o turno escolar: %w", err)
+			}
+			c.DayOfWeek = validation.NomeDiaSemana(time.Weekday(day))
+			conflicts = append(conflicts, c)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao verificar conflitos com o turno escolar: %w", err)
+		}
+	}
+
+	return conflicts, nil
+}
+
+// GetStudentShift devolve o turno escolar gravado do aluno ("" se o aluno não existe ou não informou)
+func (r *EnrollmentRepository) GetStudentShift(ctx context.Context, studentID int) (string, error) {
+	var shift sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT periodo_escolar FROM aluno WHERE id_aluno = $1`, studentID).Scan(&shift)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar turno escolar do aluno: %w", err)
+	}
+	return shift.String, nil
+}
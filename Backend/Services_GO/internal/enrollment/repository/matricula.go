@@ -0,0 +1,388 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"sysocial/internal/enrollment/model"
+	"sysocial/internal/shared/vagas"
+)
+
+// ========== CICLO DE VIDA DA MATRÍCULA ==========
+
+// ErrTurmaLotada indica uma turma sem vaga: as matrículas ATIVO (e ofertas da lista de espera) já ocupam todas as
+// vagas_turma
+var ErrTurmaLotada = errors.New("turma sem vagas")
+
+const selectMatricula = `
+	SELECT id_matricula, aluno_id_aluno, turmas_id_turma, status, data_matricula, data_inicio, data_fim
+	FROM matricula`
+
+// StudentExists verifica se o aluno existe
+func (r *EnrollmentRepository) StudentExists(ctx context.Context, studentID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM aluno WHERE id_aluno = $1)`, studentID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar aluno: %w", err)
+	}
+	return exists, nil
+}
+
+// GetMatricula busca uma matrícula do aluno; nil quando não existe (ou é de outro aluno)
+func (r *EnrollmentRepository) GetMatricula(ctx context.Context, studentID, matriculaID int) (*model.Matricula, error) {
+	m, err := scanMatricula(r.db.QueryRowContext(ctx, selectMatricula+`
+		WHERE id_matricula = $1 AND aluno_id_aluno = $2`, matriculaID, studentID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return m, err
+}
+
+// GetMatriculas lista todas as matrículas do aluno, das mais antigas às mais recentes
+func (r *EnrollmentRepository) GetMatriculas(ctx context.Context, studentID int) ([]model.Matricula, error) {
+	rows, err := r.db.QueryContext(ctx, selectMatricula+`
+		WHERE aluno_id_aluno = $1
+		ORDER BY data_inicio, id_matricula`, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar matrículas: %w", err)
+	}
+	defer rows.Close()
+
+	matriculas := []model.Matricula{}
+	for rows.Next() {
+		m, err := scanMatricula(rows)
+		if err != nil {
+			return nil, err
+		}
+		matriculas = append(matriculas, *m)
+	}
+	return matriculas, rows.Err()
+}
+
+// ChangeMatriculaStatus grava a transição de status da matrícula (já validada pelo serviço) com o histórico; a
+// reabertura de uma matrícula trancada é ReactivateMatricula.
+// Entrar em ATIVO exige vaga na turma (conferida sob lock) e as vagas do curso são recalculadas; o aluno fica ativo
+// enquanto tiver alguma matrícula ATIVO. Devolve false quando o status mudou no meio do caminho (transição concorrente).
+func (r *EnrollmentRepository) ChangeMatriculaStatus(ctx context.Context, m model.Matricula, status, dataEfetiva, motivo string, userID int) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	ocupava, ocupa := m.Status == model.StatusMatriculaAtivo, status == model.StatusMatriculaAtivo
+	if ocupa && !ocupava {
+		if err := reserveClassSpot(ctx, tx, m.TurmaID); err != nil {
+			return false, err
+		}
+	}
+
+	// A pré-inscrição começa a valer na data efetiva e a matrícula trancada mantém o fim da vigência no dia do
+	// trancamento (a reabertura é ReactivateMatricula)
+	query := `
+		UPDATE matricula SET
+			status = $3,
+			data_inicio = CASE WHEN status = 'PRE_INSCRITO' THEN $4::date ELSE data_inicio END,
+			data_fim = CASE WHEN $3 = 'ATIVO' THEN NULL WHEN status = 'TRANCADO' THEN data_fim ELSE $4::date END,
+			motivo_encerramento = CASE WHEN $3 = 'ATIVO' THEN NULL ELSE $5::text END,
+			encerrado_por = CASE WHEN $3 = 'ATIVO' THEN NULL ELSE $6::int END
+		WHERE id_matricula = $1 AND status = $2`
+	result, err := tx.ExecContext(ctx, query, m.ID, m.Status, status, dataEfetiva, motivo, nullableUserID(userID))
+	if err != nil {
+		return false, fmt.Errorf("erro ao alterar status da matrícula: %w", err)
+	}
+	afetadas, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao alterar status da matrícula: %w", err)
+	}
+	if afetadas == 0 {
+		return false, nil
+	}
+
+	if ocupava != ocupa {
+		if err := vagas.RecalcularPorTurmas(ctx, tx, []int{m.TurmaID}); err != nil {
+			return false, err
+		}
+	}
+
+	if err := insertHistorico(ctx, tx, m.ID, m.Status, status, dataEfetiva, motivo, userID); err != nil {
+		return false, err
+	}
+	if err := updateStudentActive(ctx, tx, m.AlunoID); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+	return true, nil
+}
+
+// ReactivateMatricula reabre uma matrícula trancada sem apagar o trancamento: a matrícula TRANCADO continua com a
+// vigência encerrada no dia do trancamento e uma nova, ATIVO a partir da data efetiva, é aberta na mesma turma (com
+// vaga conferida sob lock). Assim os dias trancados não contam como aula perdida na chamada e na frequência.
+// Devolve o ID da nova matrícula, ou 0 quando a trancada mudou de status ou já foi reaberta no meio do caminho.
+func (r *EnrollmentRepository) ReactivateMatricula(ctx context.Context, m model.Matricula, dataEfetiva, motivo string, userID int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := reserveClassSpot(ctx, tx, m.TurmaID); err != nil {
+		return 0, err
+	}
+
+	var aberta bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT NOT EXISTS (
+			SELECT 1 FROM matricula p
+			WHERE p.aluno_id_aluno = m.aluno_id_aluno AND p.turmas_id_turma = m.turmas_id_turma AND p.id_matricula > m.id_matricula)
+		FROM matricula m
+		WHERE m.id_matricula = $1 AND m.status = 'TRANCADO'
+		FOR UPDATE`, m.ID).Scan(&aberta)
+	if err == sql.ErrNoRows || (err == nil && !aberta) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar matrícula trancada: %w", err)
+	}
+
+	var novaID int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO matricula (aluno_id_aluno, turmas_id_turma, status, data_matricula, data_inicio)
+		VALUES ($1, $2, 'ATIVO', CURRENT_DATE, $3::date) RETURNING id_matricula`, m.AlunoID, m.TurmaID, dataEfetiva).Scan(&novaID)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao reabrir matrícula: %w", err)
+	}
+
+	if err := insertHistorico(ctx, tx, novaID, model.StatusMatriculaTrancado, model.StatusMatriculaAtivo, dataEfetiva, motivo, userID); err != nil {
+		return 0, err
+	}
+	if err := vagas.RecalcularPorTurmas(ctx, tx, []int{m.TurmaID}); err != nil {
+		return 0, err
+	}
+	if err := updateStudentActive(ctx, tx, m.AlunoID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+	return novaID, nil
+}
+
+// HasLaterMatricula verifica se o aluno tem matrícula mais recente na mesma turma (ex: a trancada já foi reaberta)
+func (r *EnrollmentRepository) HasLaterMatricula(ctx context.Context, m model.Matricula) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM matricula
+			WHERE aluno_id_aluno = $1 AND turmas_id_turma = $2 AND id_matricula > $3)`,
+		m.AlunoID, m.TurmaID, m.ID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar matrículas do aluno: %w", err)
+	}
+	return exists, nil
+}
+
+// TransferMatricula encerra a matrícula ativa como TRANSFERIDO na véspera da data efetiva e abre outra, ATIVO a
+// partir da data efetiva, na turma de destino (com vaga conferida sob lock da turma). As presenças continuam ligadas
+// ao aluno e à turma de cada chamada, dentro da vigência de cada matrícula. Devolve o ID da nova matrícula, ou 0
+// quando a matrícula de origem mudou de status no meio do caminho.
+func (r *EnrollmentRepository) TransferMatricula(ctx context.Context, m model.Matricula, targetClassID int, dataEfetiva, motivo string, userID int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := reserveClassSpot(ctx, tx, targetClassID); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE matricula SET status = 'TRANSFERIDO', data_fim = $3::date - 1, motivo_encerramento = $4, encerrado_por = $5
+		WHERE id_matricula = $1 AND status = $2`, m.ID, m.Status, dataEfetiva, motivo, nullableUserID(userID))
+	if err != nil {
+		return 0, fmt.Errorf("erro ao encerrar matrícula de origem: %w", err)
+	}
+	afetadas, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao encerrar matrícula de origem: %w", err)
+	}
+	if afetadas == 0 {
+		return 0, nil
+	}
+
+	var novaID int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO matricula (aluno_id_aluno, turmas_id_turma, status, data_matricula, data_inicio)
+		VALUES ($1, $2, 'ATIVO', CURRENT_DATE, $3::date) RETURNING id_matricula`, m.AlunoID, targetClassID, dataEfetiva).Scan(&novaID)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao criar matrícula de destino: %w", err)
+	}
+
+	if err := insertHistorico(ctx, tx, m.ID, m.Status, model.StatusMatriculaTransferido, dataEfetiva, motivo, userID); err != nil {
+		return 0, err
+	}
+	if err := insertHistorico(ctx, tx, novaID, "", model.StatusMatriculaAtivo, dataEfetiva, motivo, userID); err != nil {
+		return 0, err
+	}
+
+	// A vaga volta para o curso de origem e sai do curso de destino
+	if err := vagas.RecalcularPorTurmas(ctx, tx, []int{m.TurmaID, targetClassID}); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+	return novaID, nil
+}
+
+// HasAttendanceSince verifica se o aluno tem presença lançada na turma em aulas a partir da data
+func (r *EnrollmentRepository) HasAttendanceSince(ctx context.Context, studentID, classID int, data string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM presenca p
+			INNER JOIN chamada c ON c.id_chamada = p.chamada_id_chamada
+			WHERE p.aluno_id_aluno = $1 AND c.turmas_id_turma = $2 AND c.data_aula >= $3::date)`,
+		studentID, classID, data).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar presenças do aluno: %w", err)
+	}
+	return exists, nil
+}
+
+// GetEnrollmentTimeline lista todas as transições das matrículas do aluno em ordem cronológica
+func (r *EnrollmentRepository) GetEnrollmentTimeline(ctx context.Context, studentID int) ([]model.EnrollmentEvent, error) {
+	query := `
+		SELECT h.id_historico, m.id_matricula, c.id_curso, c.nome, t.id_turma, t.nome_turma,
+		       h.status_anterior, h.status_novo, to_char(h.data_efetiva, 'YYYY-MM-DD'), COALESCE(h.motivo, ''),
+		       h.usuario_id, COALESCE(u.nome, ''), to_char(h.registrado_em, 'YYYY-MM-DD"T"HH24:MI:SS')
+		FROM matricula_historico h
+		INNER JOIN matricula m ON m.id_matricula = h.matricula_id_matricula
+		INNER JOIN turma t ON t.id_turma = m.turmas_id_turma
+		INNER JOIN curso c ON c.id_curso = t.cursos_id_curso
+		LEFT JOIN usuarios u ON u.id_usuario = h.usuario_id
+		WHERE m.aluno_id_aluno = $1
+		ORDER BY h.data_efetiva, h.registrado_em, h.id_historico`
+
+	rows, err := r.db.QueryContext(ctx, query, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar histórico de matrículas: %w", err)
+	}
+	defer rows.Close()
+
+	events := []model.EnrollmentEvent{}
+	for rows.Next() {
+		var e model.EnrollmentEvent
+		var from sql.NullString
+		var userID sql.NullInt64
+		err := rows.Scan(&e.ID, &e.MatriculaID, &e.CourseID, &e.CourseName, &e.ClassID, &e.ClassName,
+			&from, &e.ToStatus, &e.EffectiveDate, &e.Reason, &userID, &e.UserName, &e.RecordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear histórico de matrícula: %w", err)
+		}
+		if from.Valid {
+			e.FromStatus = &from.String
+		}
+		if userID.Valid {
+			id := int(userID.Int64)
+			e.UserID = &id
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// reserveClassSpot trava a turma (as matrículas simultâneas na mesma turma esperam) e confere se ainda há vaga,
+// descontadas as vagas reservadas para ofertas da lista de espera
+func reserveClassSpot(ctx context.Context, tx *sql.Tx, classID int) error {
+	var nome string
+	var vagas, ocupadas int
+	err := tx.QueryRowContext(ctx, `SELECT nome_turma, vagas_turma FROM turma WHERE id_turma = $1 FOR UPDATE`, classID).Scan(&nome, &vagas)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("turma %d não encontrada", classID)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao buscar turma: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT `+ocupacaoTurma+` FROM turma t WHERE t.id_turma = $1`, classID).Scan(&ocupadas)
+	if err != nil {
+		return fmt.Errorf("erro ao contar matrículas da turma: %w", err)
+	}
+	if ocupadas >= vagas {
+		return fmt.Errorf("%w: %s (%d de %d vagas ocupadas)", ErrTurmaLotada, nome, ocupadas, vagas)
+	}
+	return nil
+}
+
+// classIDsOf devolve as turmas do payload sem repetição e em ordem crescente: as reservas de vaga travam as turmas
+// sempre na mesma ordem e duas matrículas simultâneas não ficam esperando uma pela outra
+func classIDsOf(courses []model.CourseEnrollmentPayload) []int {
+	vistas := make(map[int]bool, len(courses))
+	ids := make([]int, 0, len(courses))
+	for _, c := range courses {
+		id, _ := strconv.Atoi(c.ClassID)
+		if !vistas[id] {
+			vistas[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// insertHistorico registra uma transição da matrícula; de vazio = criação e data vazia = hoje
+func insertHistorico(ctx context.Context, tx *sql.Tx, matriculaID int, de, para, dataEfetiva, motivo string, userID int) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO matricula_historico (matricula_id_matricula, status_anterior, status_novo, data_efetiva, motivo, usuario_id)
+		VALUES ($1, NULLIF($2, ''), $3, COALESCE(NULLIF($4, '')::date, CURRENT_DATE), NULLIF($5, ''), $6)`,
+		matriculaID, de, para, dataEfetiva, motivo, nullableUserID(userID))
+	if err != nil {
+		return fmt.Errorf("erro ao registrar histórico da matrícula: %w", err)
+	}
+	return nil
+}
+
+// updateStudentActive mantém o aluno ativo enquanto ele tiver alguma matrícula ATIVO
+func updateStudentActive(ctx context.Context, tx *sql.Tx, studentID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE aluno SET ativo = EXISTS (SELECT 1 FROM matricula WHERE aluno_id_aluno = $1 AND status = 'ATIVO')
+		WHERE id_aluno = $1`, studentID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar situação do aluno: %w", err)
+	}
+	return nil
+}
+
+// nullableUserID grava NULL quando a requisição não trouxe o usuário (chamada direta, fora do gateway)
+func nullableUserID(userID int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(userID), Valid: userID > 0}
+}
+
+// scanMatricula lê uma linha de selectMatricula
+func scanMatricula(row interface{ Scan(...interface{}) error }) (*model.Matricula, error) {
+	var m model.Matricula
+	var dataMatricula, dataFim sql.NullTime
+	err := row.Scan(&m.ID, &m.AlunoID, &m.TurmaID, &m.Status, &dataMatricula, &m.DataInicio, &dataFim)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao escanear matrícula: %w", err)
+	}
+	m.DataMatricula = dataMatricula.Time
+	if dataFim.Valid {
+		m.DataFim = &dataFim.Time
+	}
+	return &m, nil
+}
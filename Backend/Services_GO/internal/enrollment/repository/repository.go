@@ -7,7 +7,11 @@ import (
 	"strconv"
 	"strings"
 	"sysocial/internal/enrollment/model"
+	"sysocial/internal/shared/vagas"
+	"sysocial/internal/shared/validation"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type EnrollmentRepository struct {
@@ -96,7 +100,7 @@ func (r *EnrollmentRepository) GetEnrollmentByID(ctx context.Context, studentID
 
 	// 3. CURSOS
 	coursesQuery := `
-		SELECT t.cursos_id_curso, m.turmas_id_turma
+		SELECT t.cursos_id_curso, m.turmas_id_turma, m.id_matricula
 		FROM matricula m
 		JOIN turma t ON m.turmas_id_turma = t.id_turma
 		WHERE m.aluno_id_aluno = $1 AND m.status = 'ATIVO'`
@@ -106,10 +110,10 @@ func (r *EnrollmentRepository) GetEnrollmentByID(ctx context.Context, studentID
 	defer rowsCourses.Close()
 
 	for rowsCourses.Next() {
-		var cID, tID int
-		if err := rowsCourses.Scan(&cID, &tID); err == nil {
+		var cID, tID, mID int
+		if err := rowsCourses.Scan(&cID, &tID, &mID); err == nil {
 			payload.Courses = append(payload.Courses, model.CourseEnrollmentPayload{
-				CourseID: strconv.Itoa(cID), ClassID: strconv.Itoa(tID),
+				CourseID: strconv.Itoa(cID), ClassID: strconv.Itoa(tID), MatriculaID: mID,
 			})
 		}
 	}
@@ -141,29 +145,39 @@ func (r *EnrollmentRepository) GetEnrollmentByID(ctx context.Context, studentID
 	return payload, nil
 }
 
-// Busca dinâmica de alunos
-func (r *EnrollmentRepository) SearchStudents(ctx context.Context, filter model.StudentFilter) ([]model.StudentSummary, error) {
-	// Query base
-	baseQuery := `
-		SELECT 
-			a.id_aluno, a.nome_completo, a.cpf, a.sexo, a.escola_atual, a.periodo_escolar, a.ativo, a.data_matricula,
-			a.data_nascimento,
-			c.nome as nome_curso, t.nome_turma, t.hora_inicio
-		FROM aluno a
-		LEFT JOIN matricula m ON a.id_aluno = m.aluno_id_aluno AND m.status = 'ATIVO'
-		LEFT JOIN turma t ON m.turmas_id_turma = t.id_turma
-		LEFT JOIN curso c ON t.cursos_id_curso = c.id_curso
-		WHERE 1=1
-	`
-	
+// unaccentContains compara texto ignorando acentos e maiúsculas ("Joao" encontra "João"); o termo vai escapado
+// por escapeLike. A expressão public.f_unaccent(lower(coluna)) é a mesma dos índices trigram de
+// scripts_sql/busca_sem_acentos.sql.
+const unaccentContains = `public.f_unaccent(lower(%s)) LIKE '%%' || public.f_unaccent(lower($%d)) || '%%' ESCAPE '\'`
+
+// escapeLike escapa os curingas do LIKE (%, _ e a barra de escape) para que o termo seja comparado literalmente
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
+
+// studentSortColumns mapeia o campo de ordenação da API para a expressão SQL.
+// desc=true inverte a direção: idade crescente = nascimento decrescente; status "ATIVO" antes de "INATIVO" = ativo decrescente.
+var studentSortColumns = map[string]struct {
+	column string
+	desc   bool
+}{
+	"name":           {"a.nome_completo", false},
+	"age":            {"a.data_nascimento", true},
+	"enrollmentDate": {"a.data_matricula", false},
+	"status":         {"a.ativo", true},
+}
+
+// studentConditions monta o WHERE (sobre o alias "a" de aluno) e os argumentos do StudentFilter,
+// compartilhado pela busca paginada e pela exportação
+func studentConditions(filter model.StudentFilter) (string, []interface{}) {
 	var args []interface{}
-	var conditions []string
+	conditions := []string{"1=1"}
 	argID := 1
 
-	// Filtros Dinâmicos
+	// Filtros Dinâmicos (apenas sobre aluno; curso/turma via EXISTS para não duplicar linhas na contagem)
 	if filter.Name != "" {
-		conditions = append(conditions, fmt.Sprintf("a.nome_completo ILIKE $%d", argID))
-		args = append(args, "%"+filter.Name+"%")
+		conditions = append(conditions, fmt.Sprintf(unaccentContains, "a.nome_completo", argID))
+		args = append(args, escapeLike(filter.Name))
 		argID++
 	}
 	if filter.CPF != "" {
@@ -183,8 +197,8 @@ func (r *EnrollmentRepository) SearchStudents(ctx context.Context, filter model.
 		argID++
 	}
 	if filter.School != "" {
-		conditions = append(conditions, fmt.Sprintf("a.escola_atual ILIKE $%d", argID))
-		args = append(args, "%"+filter.School+"%")
+		conditions = append(conditions, fmt.Sprintf(unaccentContains, "a.escola_atual", argID))
+		args = append(args, escapeLike(filter.School))
 		argID++
 	}
 	if filter.SchoolShift != "" {
@@ -193,30 +207,89 @@ func (r *EnrollmentRepository) SearchStudents(ctx context.Context, filter model.
 		argID++
 	}
 	if filter.Course != "" {
-		conditions = append(conditions, fmt.Sprintf("c.nome ILIKE $%d", argID))
-		args = append(args, "%"+filter.Course+"%")
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM matricula m
+			JOIN turma t ON m.turmas_id_turma = t.id_turma
+			JOIN curso c ON t.cursos_id_curso = c.id_curso
+			WHERE m.aluno_id_aluno = a.id_aluno AND m.status = 'ATIVO' AND `+unaccentContains+`)`, "c.nome", argID))
+		args = append(args, escapeLike(filter.Course))
 		argID++
 	}
 	if filter.Class != "" {
-		conditions = append(conditions, fmt.Sprintf("t.nome_turma ILIKE $%d", argID))
-		args = append(args, "%"+filter.Class+"%")
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM matricula m
+			JOIN turma t ON m.turmas_id_turma = t.id_turma
+			WHERE m.aluno_id_aluno = a.id_aluno AND m.status = 'ATIVO' AND `+unaccentContains+`)`, "t.nome_turma", argID))
+		args = append(args, escapeLike(filter.Class))
 		argID++
 	}
 
-	if filter.Age != "" {
-		age, err := strconv.Atoi(filter.Age)
-		if err == nil {
-			conditions = append(conditions, fmt.Sprintf("EXTRACT(YEAR FROM age(current_date, a.data_nascimento)) = $%d", argID))
-			args = append(args, age)
-			argID++
-		}
+	// Faixa etária comparada direto em data_nascimento (aproveita índice):
+	// idade >= N  <=>  nasceu até hoje - N anos; idade <= N  <=>  nasceu depois de hoje - (N+1) anos
+	if filter.MinAge > 0 {
+		conditions = append(conditions, fmt.Sprintf("a.data_nascimento <= current_date - make_interval(years => $%d)", argID))
+		args = append(args, filter.MinAge)
+		argID++
+	}
+	if filter.MaxAge > 0 {
+		conditions = append(conditions, fmt.Sprintf("a.data_nascimento > current_date - make_interval(years => $%d)", argID))
+		args = append(args, filter.MaxAge+1)
+		argID++
 	}
 
-	query := baseQuery
-	if len(conditions) > 0 {
-		query += " AND " + strings.Join(conditions, " AND ")
+	return strings.Join(conditions, " AND "), args
+}
+
+// studentOrderBy devolve o ORDER BY da ordenação escolhida; id_aluno como desempate garante páginas estáveis
+func studentOrderBy(filter model.StudentFilter) string {
+	sort, ok := studentSortColumns[filter.Sort]
+	if !ok {
+		sort = studentSortColumns[model.DefaultStudentSort]
 	}
-	query += " ORDER BY a.nome_completo"
+	direction := "ASC"
+	if sort.desc != (filter.Order == "desc") {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("%s %s, a.id_aluno", sort.column, direction)
+}
+
+// SearchStudents: Busca paginada de alunos com o total de registros do filtro
+func (r *EnrollmentRepository) SearchStudents(ctx context.Context, filter model.StudentFilter) (*model.StudentPage, error) {
+	where, args := studentConditions(filter)
+	argID := len(args) + 1
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM aluno a WHERE " + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("erro ao contar alunos: %w", err)
+	}
+
+	page := &model.StudentPage{Students: []model.StudentSummary{}, Total: total, Limit: filter.Limit, Offset: filter.Offset}
+	if total == 0 || filter.Offset >= total {
+		return page, nil
+	}
+
+	// Pagina os alunos primeiro e só então junta os cursos, para que LIMIT conte alunos e não matrículas
+	query := fmt.Sprintf(`
+		WITH pagina AS (
+			SELECT
+				a.id_aluno, a.nome_completo, a.cpf, a.sexo, a.escola_atual, a.periodo_escolar, a.ativo, a.data_matricula,
+				EXTRACT(YEAR FROM age(current_date, a.data_nascimento))::int AS idade,
+				ROW_NUMBER() OVER (ORDER BY %s) AS ordem
+			FROM aluno a
+			WHERE %s
+			ORDER BY ordem
+			LIMIT $%d OFFSET $%d
+		)
+		SELECT
+			p.id_aluno, p.nome_completo, p.cpf, p.sexo, p.escola_atual, p.periodo_escolar, p.ativo, p.data_matricula, p.idade,
+			c.nome as nome_curso, t.nome_turma, t.hora_inicio
+		FROM pagina p
+		LEFT JOIN matricula m ON p.id_aluno = m.aluno_id_aluno AND m.status = 'ATIVO'
+		LEFT JOIN turma t ON m.turmas_id_turma = t.id_turma
+		LEFT JOIN curso c ON t.cursos_id_curso = c.id_curso
+		ORDER BY p.ordem, c.nome`, studentOrderBy(filter), where, argID, argID+1)
+	args = append(args, filter.Limit, filter.Offset)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -229,15 +302,15 @@ func (r *EnrollmentRepository) SearchStudents(ctx context.Context, filter model.
 
 	for rows.Next() {
 		var (
-			id int
-			name, cpf string
+			id, age                   int
+			name, cpf                 string
 			sexo, escola, turnoEscola sql.NullString
-			ativo bool
-			dtMatricula, dtNasc time.Time
-			curso, turma, horaInicio sql.NullString
+			ativo                     bool
+			dtMatricula               time.Time
+			curso, turma, horaInicio  sql.NullString
 		)
 
-		err := rows.Scan(&id, &name, &cpf, &sexo, &escola, &turnoEscola, &ativo, &dtMatricula, &dtNasc, &curso, &turma, &horaInicio)
+		err := rows.Scan(&id, &name, &cpf, &sexo, &escola, &turnoEscola, &ativo, &dtMatricula, &age, &curso, &turma, &horaInicio)
 		if err != nil {
 			return nil, err
 		}
@@ -245,12 +318,6 @@ func (r *EnrollmentRepository) SearchStudents(ctx context.Context, filter model.
 		if _, exists := studentsMap[id]; !exists {
 			statusStr := "INATIVO"
 			if ativo { statusStr = "ATIVO" }
-			
-			now := time.Now()
-			age := now.Year() - dtNasc.Year()
-			if now.YearDay() < dtNasc.YearDay() {
-				age--
-			}
 
 			studentsMap[id] = &model.StudentSummary{
 				ID: id, FullName: name, CPF: cpf, Age: age,
@@ -273,17 +340,19 @@ func (r *EnrollmentRepository) SearchStudents(ctx context.Context, filter model.
 			studentsMap[id].Shifts = append(studentsMap[id].Shifts, shift)
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	var result []model.StudentSummary
 	for _, id := range studentsOrder {
-		result = append(result, *studentsMap[id])
+		page.Students = append(page.Students, *studentsMap[id])
 	}
 
-	return result, nil
+	return page, nil
 }
 
 // CreateEnrollment: Cria e Consome Vagas
-func (r *EnrollmentRepository) CreateEnrollment(ctx context.Context, payload model.NewEnrollmentPayload) (int, error) {
+func (r *EnrollmentRepository) CreateEnrollment(ctx context.Context, payload model.NewEnrollmentPayload, userID int) (int, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil { return 0, err }
 	defer func() { if p := recover(); p != nil { tx.Rollback() } else if err != nil { tx.Rollback() } }()
@@ -323,24 +392,30 @@ func (r *EnrollmentRepository) CreateEnrollment(ctx context.Context, payload mod
 		if err != nil { return 0, err }
 	}
 
-	// Cursos: Insere e DIMINUI vaga
-	matSQL := `INSERT INTO matricula (aluno_id_aluno, turmas_id_turma, status, data_matricula) VALUES ($1, $2, $3, $4)`
-	decVagaSQL := `UPDATE curso SET vagas_restantes = vagas_restantes - 1 WHERE id_curso = (SELECT cursos_id_curso FROM turma WHERE id_turma = $1)`
+	// Cursos: Reserva a vaga na turma (lock), Insere e registra no histórico (a matrícula vale a partir de hoje)
+	matSQL := `INSERT INTO matricula (aluno_id_aluno, turmas_id_turma, status, data_matricula, data_inicio) VALUES ($1, $2, $3, $4, CURRENT_DATE) RETURNING id_matricula`
 
-	for _, c := range payload.Courses {
-		tID, _ := strconv.Atoi(c.ClassID)
-		_, err = tx.ExecContext(ctx, matSQL, studentID, tID, "ATIVO", time.Now())
+	turmaIDs := classIDsOf(payload.Courses)
+	for _, tID := range turmaIDs {
+		err = reserveClassSpot(ctx, tx, tID)
+		if err != nil { return 0, err }
+		var matriculaID int
+		err = tx.QueryRowContext(ctx, matSQL, studentID, tID, model.StatusMatriculaAtivo, time.Now()).Scan(&matriculaID)
+		if err != nil { return 0, err }
+		err = insertHistorico(ctx, tx, matriculaID, "", model.StatusMatriculaAtivo, "", "Matrícula realizada", userID)
 		if err != nil { return 0, err }
-		_, err = tx.ExecContext(ctx, decVagaSQL, tID) // Consome vaga
-		if err != nil { return 0, fmt.Errorf("erro ao atualizar vagas: %w", err) }
 	}
 
+	// Vagas do curso: derivadas das turmas
+	err = vagas.RecalcularPorTurmas(ctx, tx, turmaIDs)
+	if err != nil { return 0, err }
+
 	if err = tx.Commit(); err != nil { return 0, err }
 	return studentID, nil
 }
 
-// CancelEnrollment: Inativa Aluno, Cancela Matrícula e DEVOLVE Vagas
-func (r *EnrollmentRepository) CancelEnrollment(ctx context.Context, studentID int) error {
+// CancelEnrollment: Inativa Aluno, Cancela as matrículas informadas (já validadas pelo serviço) e DEVOLVE as vagas das ativas
+func (r *EnrollmentRepository) CancelEnrollment(ctx context.Context, studentID int, matriculas []model.Matricula, motivo string, userID int) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil { return err }
 	defer func() { if p := recover(); p != nil { tx.Rollback() } else if err != nil { tx.Rollback() } }()
@@ -351,39 +426,35 @@ func (r *EnrollmentRepository) CancelEnrollment(ctx context.Context, studentID i
 	ra, _ := res.RowsAffected()
 	if ra == 0 { return fmt.Errorf("aluno não encontrado") }
 
-	// 2. Descobrir turmas ativas (para devolver vaga)
-	rows, err := tx.QueryContext(ctx, "SELECT turmas_id_turma FROM matricula WHERE aluno_id_aluno = $1 AND status = 'ATIVO'", studentID)
-	if err != nil { return err }
-	
-	var turmasIds []int
-	for rows.Next() {
-		var tid int
-		if err := rows.Scan(&tid); err == nil { turmasIds = append(turmasIds, tid) }
-	}
-	rows.Close()
-
-	// 3. Cancelar matrículas e Devolver vagas
-	incVagaSQL := `UPDATE curso SET vagas_restantes = vagas_restantes + 1 WHERE id_curso = (SELECT cursos_id_curso FROM turma WHERE id_turma = $1)`
+	// 2. Cancelar matrículas, registrar no histórico e Devolver vagas
 
-	_, err = tx.ExecContext(ctx, "UPDATE matricula SET status = 'CANCELADO' WHERE aluno_id_aluno = $1", studentID)
-	if err != nil { return err }
+	// A matrícula encerra hoje: o aluno continua na lista de chamada dos dias em que estava matriculado
+	var turmaIDs []int
+	cancelSQL := `UPDATE matricula SET status = 'CANCELADO', data_fim = COALESCE(data_fim, GREATEST(data_inicio, CURRENT_DATE)), motivo_encerramento = $3, encerrado_por = $4 WHERE id_matricula = $1 AND status = $2`
+	for _, m := range matriculas {
+		res, err = tx.ExecContext(ctx, cancelSQL, m.ID, m.Status, motivo, nullableUserID(userID))
+		if err != nil { return err }
+		if ra, _ := res.RowsAffected(); ra == 0 { continue } // Já alterada por outra requisição
 
-	for _, tid := range turmasIds {
-		_, err = tx.ExecContext(ctx, incVagaSQL, tid) // Devolve vaga
+		err = insertHistorico(ctx, tx, m.ID, m.Status, model.StatusMatriculaCancelado, "", motivo, userID)
 		if err != nil { return err }
+		turmaIDs = append(turmaIDs, m.TurmaID)
 	}
 
+	err = vagas.RecalcularPorTurmas(ctx, tx, turmaIDs) // Devolve as vagas
+	if err != nil { return err }
+
 	return tx.Commit()
 }
 
-// UpdateEnrollment: Atualiza dados, Refaz matrículas e Ajusta Vagas
-func (r *EnrollmentRepository) UpdateEnrollment(ctx context.Context, studentID int, payload model.NewEnrollmentPayload) error {
+// UpdateEnrollment: Atualiza dados, aplica a diferença nas matrículas e Ajusta Vagas só do que mudou
+func (r *EnrollmentRepository) UpdateEnrollment(ctx context.Context, studentID int, payload model.NewEnrollmentPayload, userID int) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil { return err }
 	defer func() { if p := recover(); p != nil { tx.Rollback() } else if err != nil { tx.Rollback() } }()
 
 	// 1. Update Aluno
-	studentSQL := `UPDATE aluno SET nome_completo=$1, data_nascimento=$2, sexo=$3, telefone=$4, escola_atual=$5, serie_atual=$6, periodo_escolar=$7, nome_rua=$8, numero_endereco=$9, bairro=$10, cep=$11, observacoes=$12, ativo=true WHERE id_aluno=$13`
+	studentSQL := `UPDATE aluno SET nome_completo=$1, data_nascimento=$2, sexo=$3, telefone=$4, escola_atual=$5, serie_atual=$6, periodo_escolar=$7, nome_rua=$8, numero_endereco=$9, bairro=$10, cep=$11, observacoes=$12 WHERE id_aluno=$13`
 	numEnd, _ := strconv.Atoi(payload.Student.Number)
 	serie, _ := strconv.Atoi(payload.Student.Series)
 	_, err = tx.ExecContext(ctx, studentSQL, payload.Student.FullName, payload.Student.BirthDate, payload.Student.Gender, payload.Student.Phone, payload.Student.CurrentSchool, serie, payload.Student.SchoolShift, payload.Student.Street, numEnd, payload.Student.Neighborhood, payload.Student.ZipCode, payload.Student.Observation, studentID)
@@ -414,70 +485,105 @@ func (r *EnrollmentRepository) UpdateEnrollment(ctx context.Context, studentID i
 		if err != nil { return err }
 	}
 
-	// 3. CURSOS E VAGAS (A parte crítica)
-	
-	// A. Descobrir turmas ANTIGAS ativas para DEVOLVER vaga
-	rowsOld, err := tx.QueryContext(ctx, "SELECT turmas_id_turma FROM matricula WHERE aluno_id_aluno = $1 AND status = 'ATIVO'", studentID)
+	// 3. CURSOS E VAGAS (A parte crítica): aplica só a diferença entre as matrículas ativas e o payload,
+	// preservando a data de início e o histórico (presenças) das turmas que continuam
+
+	// A. Matrículas ATIVAS atuais, por turma (FOR UPDATE: edições simultâneas do mesmo aluno esperam)
+	rowsOld, err := tx.QueryContext(ctx, "SELECT id_matricula, turmas_id_turma FROM matricula WHERE aluno_id_aluno = $1 AND status = 'ATIVO' FOR UPDATE", studentID)
 	if err != nil { return err }
-	var oldTurmas []int
+	oldMatriculas := make(map[int]int) // turma -> matrícula
 	for rowsOld.Next() {
-		var tid int
-		if err := rowsOld.Scan(&tid); err == nil { oldTurmas = append(oldTurmas, tid) }
+		var mID, tID int
+		if err = rowsOld.Scan(&mID, &tID); err != nil { rowsOld.Close(); return err }
+		oldMatriculas[tID] = mID
 	}
 	rowsOld.Close()
 
-	incVagaSQL := `UPDATE curso SET vagas_restantes = vagas_restantes + 1 WHERE id_curso = (SELECT cursos_id_curso FROM turma WHERE id_turma = $1)`
-	for _, tid := range oldTurmas {
-		_, err = tx.ExecContext(ctx, incVagaSQL, tid) // Devolve vaga antiga
+	newTurmas := make(map[int]bool)
+	for _, c := range payload.Courses {
+		tID, _ := strconv.Atoi(c.ClassID)
+		newTurmas[tID] = true
+	}
+
+	// B. Turmas removidas: a matrícula é encerrada hoje como CANCELADO e DEVOLVE a vaga
+	closeSQL := `UPDATE matricula SET status = 'CANCELADO', data_fim = GREATEST(data_inicio, CURRENT_DATE), motivo_encerramento = $2, encerrado_por = $3 WHERE id_matricula = $1`
+	motivoRemocao := "Curso removido na edição do cadastro"
+	var changedTurmas []int
+
+	for tID, mID := range oldMatriculas {
+		if newTurmas[tID] { continue } // Continua: nada muda
+		_, err = tx.ExecContext(ctx, closeSQL, mID, motivoRemocao, nullableUserID(userID))
 		if err != nil { return err }
+		err = insertHistorico(ctx, tx, mID, model.StatusMatriculaAtivo, model.StatusMatriculaCancelado, "", motivoRemocao, userID)
+		if err != nil { return err }
+		changedTurmas = append(changedTurmas, tID)
 	}
 
-	// B. Remover matrículas antigas
-	_, err = tx.ExecContext(ctx, "DELETE FROM matricula WHERE aluno_id_aluno = $1", studentID)
-	if err != nil { return err }
+	// C. Turmas novas: reserva a vaga na turma (lock) e insere a matrícula (vale a partir de hoje)
+	matSQL := `INSERT INTO matricula (aluno_id_aluno, turmas_id_turma, status, data_matricula, data_inicio) VALUES ($1, $2, $3, $4, CURRENT_DATE) RETURNING id_matricula`
 
-	// C. Inserir NOVAS matrículas e CONSUMIR vaga
-	matSQL := `INSERT INTO matricula (aluno_id_aluno, turmas_id_turma, status, data_matricula) VALUES ($1, $2, $3, $4)`
-	decVagaSQL := `UPDATE curso SET vagas_restantes = vagas_restantes - 1 WHERE id_curso = (SELECT cursos_id_curso FROM turma WHERE id_turma = $1)`
-	
-	for _, c := range payload.Courses {
-		tID, _ := strconv.Atoi(c.ClassID)
-		_, err = tx.ExecContext(ctx, matSQL, studentID, tID, "ATIVO", time.Now())
+	for _, tID := range classIDsOf(payload.Courses) {
+		if _, ok := oldMatriculas[tID]; ok { continue } // Já matriculado: nada muda
+		err = reserveClassSpot(ctx, tx, tID)
 		if err != nil { return err }
-		_, err = tx.ExecContext(ctx, decVagaSQL, tID) // Consome nova vaga
+		var matriculaID int
+		err = tx.QueryRowContext(ctx, matSQL, studentID, tID, model.StatusMatriculaAtivo, time.Now()).Scan(&matriculaID)
 		if err != nil { return err }
+		err = insertHistorico(ctx, tx, matriculaID, "", model.StatusMatriculaAtivo, "", "Curso incluído na edição do cadastro", userID)
+		if err != nil { return err }
+		changedTurmas = append(changedTurmas, tID)
 	}
 
+	// Vagas dos cursos que mudaram: derivadas das turmas
+	err = vagas.RecalcularPorTurmas(ctx, tx, changedTurmas)
+	if err != nil { return err }
+
+	// D. O aluno fica ativo enquanto tiver algum curso ativo
+	err = updateStudentActive(ctx, tx, studentID)
+	if err != nil { return err }
+
 	return tx.Commit()
 }
 
-// GetAvailableCourses busca cursos e turmas compatíveis com o turno escolar
-func (r *EnrollmentRepository) GetAvailableCourses(ctx context.Context, schoolShift string) ([]model.CourseOption, error) {
-	var timeCondition string
-	switch schoolShift {
-	case "manha":
-		timeCondition = "t.hora_inicio >= '12:00:00'"
-	case "tarde":
-		timeCondition = "t.hora_inicio < '12:00:00'"
-	case "integral":
-		return []model.CourseOption{}, nil
-	default:
-		timeCondition = "1=1"
+// scheduleOutsideShift exige que a turma tenha grade (turma_horario) e que nenhum encontro caia no turno escolar
+// (mesma regra de GetShiftConflicts, inclusive para encontros sem horário). %s é a sobreposição com as janelas do turno.
+const scheduleOutsideShift = `EXISTS (SELECT 1 FROM turma_horario h WHERE h.turma_id_turma = t.id_turma)
+		  AND NOT EXISTS (
+			SELECT 1 FROM turma_horario h
+			WHERE h.turma_id_turma = t.id_turma AND (%s))`
+
+// GetAvailableCourses busca cursos e turmas compatíveis com o turno escolar (janelas de segunda a sexta,
+// considerando todos os horários da turma) e, com studentID > 0, sem conflito com as turmas ativas do aluno
+func (r *EnrollmentRepository) GetAvailableCourses(ctx context.Context, janelas []model.ShiftWindow, studentID int) ([]model.CourseOption, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	if len(janelas) > 0 {
+		var sobreposicoes []string
+		for _, j := range janelas {
+			args = append(args, j.Start, j.End)
+			sobreposicoes = append(sobreposicoes, fmt.Sprintf(sobreposicaoTurno, len(args)-1, len(args)))
+		}
+		conditions = append(conditions, fmt.Sprintf(scheduleOutsideShift, strings.Join(sobreposicoes, " OR ")))
+	}
+	if studentID > 0 {
+		args = append(args, studentID)
+		conditions = append(conditions, fmt.Sprintf(semConflitoAluno, len(args)))
 	}
 
 	query := fmt.Sprintf(`
 		SELECT 
 			c.id_curso, c.nome, c.vagas_totais, c.vagas_restantes,
-			t.id_turma, t.nome_turma, t.dia_semana, t.hora_inicio, t.hora_fim, t.vagas_turma, t.descricao
+			t.id_turma, t.nome_turma, t.dia_semana, COALESCE(t.hora_inicio::text, ''), COALESCE(t.hora_fim::text, ''), t.vagas_turma, t.descricao,
+			t.vagas_turma - %s
 		FROM curso c
 		JOIN turma t ON c.id_curso = t.cursos_id_curso
 		WHERE c.ativo = true 
 		  -- REMOVIDO: AND c.vagas_restantes > 0 (Para mostrar cursos lotados no front)
 		  AND %s
 		ORDER BY c.nome, t.nome_turma
-	`, timeCondition)
+	`, ocupacaoTurma, strings.Join(conditions, "\n\t\t  AND "))
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("erro na query de cursos: %w", err)
 	}
@@ -488,14 +594,14 @@ func (r *EnrollmentRepository) GetAvailableCourses(ctx context.Context, schoolSh
 
 	for rows.Next() {
 		var (
-			cID, cVagasTotal, cVagasRest, tID, tVagas int
+			cID, cVagasTotal, cVagasRest, tID, tVagas, tVagasRest int
 			cNome, tNome, tDia, tInicio, tFim       string
 			tDesc                                   sql.NullString
 		)
 
 		err := rows.Scan(
 			&cID, &cNome, &cVagasTotal, &cVagasRest,
-			&tID, &tNome, &tDia, &tInicio, &tFim, &tVagas, &tDesc,
+			&tID, &tNome, &tDia, &tInicio, &tFim, &tVagas, &tDesc, &tVagasRest,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("erro ao escanear linha: %w", err)
@@ -513,24 +619,76 @@ func (r *EnrollmentRepository) GetAvailableCourses(ctx context.Context, schoolSh
 		}
 
 		coursesMap[cID].Classes = append(coursesMap[cID].Classes, model.ClassOption{
-			ID:          tID,
-			Name:        tNome,
-			DayOfWeek:   tDia,
-			StartTime:   tInicio,
-			EndTime:     tFim,
-			Spots:       tVagas,
-			Description: tDesc.String, 
+			ID:             tID,
+			Name:           tNome,
+			DayOfWeek:      tDia,
+			StartTime:      tInicio,
+			EndTime:        tFim,
+			Spots:          tVagas,
+			AvailableSpots: tVagasRest,
+			Description:    tDesc.String,
 		})
 	}
 
+	var classIDs []int
+	for _, id := range coursesOrder {
+		for _, class := range coursesMap[id].Classes {
+			classIDs = append(classIDs, class.ID)
+		}
+	}
+	schedules, err := r.getClassSchedules(ctx, classIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	var result []model.CourseOption
 	for _, id := range coursesOrder {
-		result = append(result, *coursesMap[id])
+		course := coursesMap[id]
+		for i := range course.Classes {
+			course.Classes[i].Schedule = schedules[course.Classes[i].ID]
+			if course.Classes[i].Schedule == nil {
+				course.Classes[i].Schedule = []model.ClassSchedule{}
+			}
+		}
+		result = append(result, *course)
 	}
 
 	return result, nil
 }
 
+// getClassSchedules busca a grade semanal (turma_horario) das turmas, agrupada por turma
+func (r *EnrollmentRepository) getClassSchedules(ctx context.Context, classIDs []int) (map[int][]model.ClassSchedule, error) {
+	schedules := make(map[int][]model.ClassSchedule)
+	if len(classIDs) == 0 {
+		return schedules, nil
+	}
+
+	query := `
+		SELECT turma_id_turma, dia_semana,
+		       COALESCE(to_char(hora_inicio, 'HH24:MI'), ''), COALESCE(to_char(hora_fim, 'HH24:MI'), '')
+		FROM turma_horario
+		WHERE turma_id_turma = ANY($1)
+		ORDER BY turma_id_turma, (dia_semana + 6) % 7, hora_inicio NULLS FIRST`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(classIDs))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar horários das turmas: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var classID, day int
+		var schedule model.ClassSchedule
+		if err := rows.Scan(&classID, &day, &schedule.StartTime, &schedule.EndTime); err != nil {
+			return nil, fmt.Errorf("erro ao escanear horário: %w", err)
+		}
+		schedule.DayOfWeek = validation.NomeDiaSemana(time.Weekday(day))
+		schedules[classID] = append(schedules[classID], schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
 func (r *EnrollmentRepository) GetInitialCourseData(ctx context.Context) (map[string]interface{}, error) {
 	return map[string]interface{}{}, nil
 }
@@ -556,4 +714,193 @@ func (r *EnrollmentRepository) GetGuardianByCPF(ctx context.Context, cpf string)
 		return nil, err
 	}
 	return g, nil
-}
\ No newline at end of file
+}
+// ExportStudents: Todos os alunos do filtro (sem paginação) com cursos, turmas e contatos dos responsáveis
+func (r *EnrollmentRepository) ExportStudents(ctx context.Context, filter model.StudentFilter) ([]model.StudentExportRow, error) {
+	where, args := studentConditions(filter)
+
+	query := fmt.Sprintf(`
+		SELECT
+			a.id_aluno, a.nome_completo, a.cpf, a.data_nascimento,
+			EXTRACT(YEAR FROM age(current_date, a.data_nascimento))::int,
+			COALESCE(a.sexo, ''), COALESCE(a.telefone, ''), COALESCE(a.escola_atual, ''),
+			COALESCE(a.serie_atual::text, ''), COALESCE(a.periodo_escolar, ''),
+			concat_ws(', ', NULLIF(a.nome_rua, ''), NULLIF(a.numero_endereco::text, '0'), NULLIF(a.bairro, ''), NULLIF(a.cep, '')),
+			a.ativo, a.data_matricula,
+			COALESCE(cursos.nomes, ''), COALESCE(cursos.turmas, ''),
+			COALESCE(resp.nomes, ''), COALESCE(resp.telefones, '')
+		FROM aluno a
+		LEFT JOIN LATERAL (
+			SELECT
+				string_agg(c.nome, ', ' ORDER BY c.nome) AS nomes,
+				string_agg(c.nome || ' - ' || t.nome_turma, ', ' ORDER BY c.nome) AS turmas
+			FROM matricula m
+			JOIN turma t ON m.turmas_id_turma = t.id_turma
+			JOIN curso c ON t.cursos_id_curso = c.id_curso
+			WHERE m.aluno_id_aluno = a.id_aluno AND m.status = 'ATIVO'
+		) cursos ON true
+		LEFT JOIN LATERAL (
+			SELECT
+				string_agg(r.nome_completo || ' (' || r.parentesco || ')', '; ' ORDER BY ra.tipo = 'Principal' DESC, r.nome_completo) AS nomes,
+				string_agg(r.nome_completo || ': ' || concat_ws(' / ',
+					NULLIF(r.telefone, '') || COALESCE(' (' || NULLIF(r.contato_telefone, '') || ')', ''),
+					NULLIF(r.telefone_recado1, '') || COALESCE(' (' || NULLIF(r.contato_recado1, '') || ')', ''),
+					NULLIF(r.telefone_recado2, '') || COALESCE(' (' || NULLIF(r.contato_recado2, '') || ')', '')
+				), '; ' ORDER BY ra.tipo = 'Principal' DESC, r.nome_completo) AS telefones
+			FROM responsavel_aluno ra
+			JOIN responsavel r ON r.id_responsavel = ra.responsavel_id_responsavel
+			WHERE ra.aluno_id_aluno = a.id_aluno
+		) resp ON true
+		WHERE %s
+		ORDER BY %s`, where, studentOrderBy(filter))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao exportar alunos: %w", err)
+	}
+	defer rows.Close()
+
+	var result []model.StudentExportRow
+	for rows.Next() {
+		var (
+			row                 model.StudentExportRow
+			ativo               bool
+			dtNasc, dtMatricula time.Time
+		)
+		err := rows.Scan(&row.ID, &row.FullName, &row.CPF, &dtNasc, &row.Age,
+			&row.Gender, &row.Phone, &row.School, &row.Series, &row.SchoolShift, &row.Address,
+			&ativo, &dtMatricula, &row.Courses, &row.Classes, &row.Guardians, &row.GuardianPhones)
+		if err != nil {
+			return nil, err
+		}
+
+		row.Status = "INATIVO"
+		if ativo {
+			row.Status = "ATIVO"
+		}
+		row.BirthDate = dtNasc.Format("02/01/2006")
+		row.EnrollmentDate = dtMatricula.Format("02/01/2006")
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// GetClassesInfo: Curso, vagas restantes e período (por turma) das turmas informadas (turmas inexistentes ficam fora do mapa)
+func (r *EnrollmentRepository) GetClassesInfo(ctx context.Context, classIDs []int) (map[int]model.ClassInfo, error) {
+	result := make(map[int]model.ClassInfo)
+	if len(classIDs) == 0 {
+		return result, nil
+	}
+
+	query := `
+		SELECT t.id_turma, c.id_curso, t.vagas_turma - ` + ocupacaoTurma + `,
+		       to_char(t.data_inicio, 'YYYY-MM-DD'), to_char(t.data_fim, 'YYYY-MM-DD')
+		FROM turma t
+		JOIN curso c ON t.cursos_id_curso = c.id_curso
+		WHERE t.id_turma = ANY($1) AND c.ativo = true`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(classIDs))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar turmas: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var info model.ClassInfo
+		if err := rows.Scan(&info.ClassID, &info.CourseID, &info.AvailableSpots, &info.StartDate, &info.EndDate); err != nil {
+			return nil, err
+		}
+		result[info.ClassID] = info
+	}
+
+	return result, rows.Err()
+}
+
+// searchSources define, por tipo, a consulta de cada fonte da busca unificada.
+// Todas comparam public.f_unaccent(lower(...)) com o termo já normalizado (q), usando os índices trigram
+// de scripts_sql/busca_sem_acentos.sql. Prefixo do nome vale mais que ocorrência no meio do texto.
+var searchSources = map[string]string{
+	model.SearchTypeStudent: `
+		SELECT 'student', a.id_aluno, a.nome_completo, COALESCE(a.escola_atual, ''), NULL::int,
+			GREATEST(
+				word_similarity(b.q, public.f_unaccent(lower(a.nome_completo))),
+				word_similarity(b.q, public.f_unaccent(lower(COALESCE(a.escola_atual, '')))) * 0.5
+			) + CASE WHEN public.f_unaccent(lower(a.nome_completo)) LIKE b.ql || '%' ESCAPE '\' THEN 0.5 ELSE 0 END
+		FROM aluno a, busca b
+		WHERE public.f_unaccent(lower(a.nome_completo)) LIKE '%' || b.ql || '%' ESCAPE '\'
+			OR b.q <% public.f_unaccent(lower(a.nome_completo))
+			OR public.f_unaccent(lower(a.escola_atual)) LIKE '%' || b.ql || '%' ESCAPE '\'`,
+	model.SearchTypeGuardian: `
+		SELECT 'guardian', r.id_responsavel, r.nome_completo,
+			COALESCE((
+				SELECT 'Responsável por ' || string_agg(a.nome_completo, ', ' ORDER BY a.nome_completo)
+				FROM responsavel_aluno ra
+				JOIN aluno a ON a.id_aluno = ra.aluno_id_aluno
+				WHERE ra.responsavel_id_responsavel = r.id_responsavel
+			), ''), NULL::int,
+			word_similarity(b.q, public.f_unaccent(lower(r.nome_completo)))
+			+ CASE WHEN public.f_unaccent(lower(r.nome_completo)) LIKE b.ql || '%' ESCAPE '\' THEN 0.5 ELSE 0 END
+		FROM responsavel r, busca b
+		WHERE public.f_unaccent(lower(r.nome_completo)) LIKE '%' || b.ql || '%' ESCAPE '\'
+			OR b.q <% public.f_unaccent(lower(r.nome_completo))`,
+	model.SearchTypeCourse: `
+		SELECT 'course', c.id_curso, c.nome, c.vagas_restantes || ' de ' || c.vagas_totais || ' vagas disponíveis', NULL::int,
+			word_similarity(b.q, public.f_unaccent(lower(c.nome)))
+			+ CASE WHEN public.f_unaccent(lower(c.nome)) LIKE b.ql || '%' ESCAPE '\' THEN 0.5 ELSE 0 END
+		FROM curso c, busca b
+		WHERE c.ativo = true
+			AND (public.f_unaccent(lower(c.nome)) LIKE '%' || b.ql || '%' ESCAPE '\' OR b.q <% public.f_unaccent(lower(c.nome)))`,
+	model.SearchTypeTurma: `
+		SELECT 'turma', t.id_turma, t.nome_turma, c.nome || ' - ' || t.dia_semana, c.id_curso,
+			word_similarity(b.q, public.f_unaccent(lower(t.nome_turma)))
+			+ CASE WHEN public.f_unaccent(lower(t.nome_turma)) LIKE b.ql || '%' ESCAPE '\' THEN 0.5 ELSE 0 END
+		FROM turma t
+		JOIN curso c ON t.cursos_id_curso = c.id_curso, busca b
+		WHERE c.ativo = true
+			AND (public.f_unaccent(lower(t.nome_turma)) LIKE '%' || b.ql || '%' ESCAPE '\' OR b.q <% public.f_unaccent(lower(t.nome_turma)))`,
+}
+
+// Search: Busca unificada sem acentos em alunos, responsáveis, cursos e turmas, ordenada por relevância
+func (r *EnrollmentRepository) Search(ctx context.Context, term string, types []string, limit int) ([]model.SearchResult, error) {
+	var parts []string
+	for _, t := range types {
+		if source, ok := searchSources[t]; ok {
+			parts = append(parts, source)
+		}
+	}
+	if len(parts) == 0 {
+		return []model.SearchResult{}, nil
+	}
+
+	query := fmt.Sprintf(`
+		WITH busca AS (SELECT public.f_unaccent(lower($1)) AS q, public.f_unaccent(lower($3)) AS ql)
+		SELECT tipo, id, titulo, subtitulo, parent_id, score
+		FROM (%s) AS resultados (tipo, id, titulo, subtitulo, parent_id, score)
+		ORDER BY score DESC, titulo
+		LIMIT $2`, strings.Join(parts, "\n\t\tUNION ALL"))
+
+	rows, err := r.db.QueryContext(ctx, query, term, limit, escapeLike(term))
+	if err != nil {
+		return nil, fmt.Errorf("erro na busca unificada: %w", err)
+	}
+	defer rows.Close()
+
+	results := []model.SearchResult{}
+	for rows.Next() {
+		var (
+			item     model.SearchResult
+			parentID sql.NullInt64
+		)
+		if err := rows.Scan(&item.Type, &item.ID, &item.Title, &item.Subtitle, &parentID, &item.Score); err != nil {
+			return nil, err
+		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			item.ParentID = &id
+		}
+		results = append(results, item)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sysocial/internal/enrollment/model"
+)
+
+// Erros da lista de espera
+var (
+	ErrEsperaNaoEncontrada = errors.New("entrada da lista de espera não encontrada")
+	ErrEsperaInvalida      = errors.New("entrada na lista de espera inválida")
+	ErrJaNaListaEspera     = errors.New("aluno já está na lista de espera da turma")
+	ErrTurmaComVagas       = errors.New("turma com vagas disponíveis")
+	ErrOfertaIndisponivel  = errors.New("oferta de vaga indisponível")
+)
+
+// JoinWaitlist coloca o aluno na fila de uma turma lotada. Prioridade acima de zero exige o critério (ex:
+// vulnerabilidade social); quem já está matriculado na turma ou já está na fila não entra de novo.
+func (s *EnrollmentService) JoinWaitlist(ctx context.Context, studentID int, payload model.JoinWaitlistPayload, userID int) (*model.WaitlistEntry, error) {
+	payload.PriorityReason = strings.TrimSpace(payload.PriorityReason)
+	payload.Notes = strings.TrimSpace(payload.Notes)
+	if payload.Priority > 0 && payload.PriorityReason == "" {
+		return nil, fmt.Errorf("%w: informe o critério da prioridade", ErrEsperaInvalida)
+	}
+
+	exists, err := s.repo.StudentExists(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrAlunoNaoEncontrado
+	}
+
+	classes, err := s.repo.GetClassesInfo(ctx, []int{payload.ClassID})
+	if err != nil {
+		return nil, err
+	}
+	info, ok := classes[payload.ClassID]
+	if !ok {
+		return nil, fmt.Errorf("%w: turma %d não encontrada ou de curso inativo", ErrEsperaInvalida, payload.ClassID)
+	}
+	if info.AvailableSpots > 0 {
+		return nil, fmt.Errorf("%w: %d vaga(s) livre(s); matricule o aluno diretamente", ErrTurmaComVagas, info.AvailableSpots)
+	}
+
+	matriculas, err := s.repo.GetMatriculas(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range matriculas {
+		if m.TurmaID == payload.ClassID && m.Status == model.StatusMatriculaAtivo {
+			return nil, fmt.Errorf("%w: o aluno já está matriculado na turma", ErrEsperaInvalida)
+		}
+	}
+
+	entryID, err := s.repo.JoinWaitlist(ctx, studentID, payload, userID)
+	if err != nil {
+		return nil, err
+	}
+	if entryID == 0 {
+		return nil, ErrJaNaListaEspera
+	}
+
+	s.logger.Infof("Aluno ID %d entrou na lista de espera da turma ID %d (prioridade %d)", studentID, payload.ClassID, payload.Priority)
+	return s.repo.GetWaitlistEntry(ctx, studentID, entryID)
+}
+
+// LeaveWaitlist tira o aluno da fila; uma oferta pendente devolvida vai para o próximo
+func (s *EnrollmentService) LeaveWaitlist(ctx context.Context, studentID, entryID int) error {
+	entry, err := s.waitlistEntry(ctx, studentID, entryID)
+	if err != nil {
+		return err
+	}
+	if entry.Status != model.StatusEsperaAguardando && entry.Status != model.StatusEsperaOfertada {
+		return fmt.Errorf("%w: a entrada já foi encerrada (%s)", ErrOfertaIndisponivel, entry.Status)
+	}
+
+	if err := s.closeWaitlistEntry(ctx, *entry, model.StatusEsperaRemovida); err != nil {
+		return err
+	}
+	s.logger.Infof("Aluno ID %d saiu da lista de espera da turma ID %d", studentID, entry.ClassID)
+	return nil
+}
+
+// DeclineWaitlistOffer registra a recusa da vaga oferecida e a oferece ao próximo da fila
+func (s *EnrollmentService) DeclineWaitlistOffer(ctx context.Context, studentID, entryID int) error {
+	entry, err := s.waitlistEntry(ctx, studentID, entryID)
+	if err != nil {
+		return err
+	}
+	if entry.Status != model.StatusEsperaOfertada {
+		return fmt.Errorf("%w: não há oferta pendente (%s)", ErrOfertaIndisponivel, entry.Status)
+	}
+
+	if err := s.closeWaitlistEntry(ctx, *entry, model.StatusEsperaRecusada); err != nil {
+		return err
+	}
+	s.logger.Infof("Aluno ID %d recusou a vaga da turma ID %d", studentID, entry.ClassID)
+	return nil
+}
+
+// AcceptWaitlistOffer aceita a vaga oferecida (dentro do prazo) e matricula o aluno na turma a partir de hoje, com a
+// grade conferida contra as demais turmas ativas e o turno escolar do aluno. Devolve o ID da matrícula.
+func (s *EnrollmentService) AcceptWaitlistOffer(ctx context.Context, studentID, entryID, userID int) (int, error) {
+	entry, err := s.waitlistEntry(ctx, studentID, entryID)
+	if err != nil {
+		return 0, err
+	}
+	if entry.Status != model.StatusEsperaOfertada {
+		return 0, fmt.Errorf("%w: não há oferta pendente (%s)", ErrOfertaIndisponivel, entry.Status)
+	}
+
+	matriculas, err := s.repo.GetMatriculas(ctx, studentID)
+	if err != nil {
+		return 0, err
+	}
+	var outras []int
+	for _, m := range matriculas {
+		if m.Status == model.StatusMatriculaAtivo {
+			outras = append(outras, m.TurmaID)
+		}
+	}
+	shift, err := s.repo.GetStudentShift(ctx, studentID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.verificarGrade(ctx, []int{entry.ClassID}, outras, shift); err != nil {
+		return 0, err
+	}
+
+	matriculaID, err := s.repo.AcceptWaitlistOffer(ctx, *entry, userID)
+	if err != nil {
+		return 0, err
+	}
+	if matriculaID == 0 {
+		return 0, fmt.Errorf("%w: a oferta expirou ou já foi respondida", ErrOfertaIndisponivel)
+	}
+
+	s.logger.Infof("Aluno ID %d aceitou a vaga da turma ID %d pela lista de espera (matrícula ID %d)", studentID, entry.ClassID, matriculaID)
+	return matriculaID, nil
+}
+
+// GetClassWaitlist devolve a fila da turma: ofertas pendentes e, na ordem, quem aguarda
+func (s *EnrollmentService) GetClassWaitlist(ctx context.Context, classID int) ([]model.WaitlistEntry, error) {
+	return s.repo.GetClassWaitlist(ctx, classID)
+}
+
+// GetPendingOffers devolve as ofertas de vaga aguardando resposta, das que vencem primeiro, para a equipe avisar os
+// responsáveis antes do prazo
+func (s *EnrollmentService) GetPendingOffers(ctx context.Context) ([]model.WaitlistEntry, error) {
+	return s.repo.GetPendingOffers(ctx)
+}
+
+// GetStudentWaitlist devolve todas as entradas do aluno em listas de espera
+func (s *EnrollmentService) GetStudentWaitlist(ctx context.Context, studentID int) ([]model.WaitlistEntry, error) {
+	exists, err := s.repo.StudentExists(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrAlunoNaoEncontrado
+	}
+	return s.repo.GetStudentWaitlist(ctx, studentID)
+}
+
+// RunWaitlistSweep expira as ofertas vencidas e oferece as vagas livres de todas as turmas com fila a cada
+// MinutosVarredura minutos, até o contexto ser cancelado
+func (s *EnrollmentService) RunWaitlistSweep(ctx context.Context) {
+	intervalo := time.Duration(s.listaEspera.MinutosVarredura) * time.Minute
+	if intervalo <= 0 {
+		s.logger.Warn("Varredura da lista de espera desativada (LISTA_ESPERA_MINUTOS_VARREDURA)")
+		return
+	}
+
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		s.ofertarVagas(ctx, nil)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ofertarVagas oferece as vagas livres das turmas aos próximos das filas (nil = todas as turmas). Roda depois da
+// operação que liberou a vaga: uma falha aqui só é registrada e a varredura periódica tenta de novo.
+func (s *EnrollmentService) ofertarVagas(ctx context.Context, turmaIDs []int) {
+	if turmaIDs != nil && len(turmaIDs) == 0 {
+		return
+	}
+
+	ofertas, expiradas, err := s.repo.OfferWaitlistSpots(ctx, turmaIDs, s.listaEspera.HorasOferta)
+	if err != nil {
+		s.logger.Warnf("Erro ao ofertar vagas da lista de espera: %v", err)
+		return
+	}
+	if expiradas > 0 {
+		s.logger.Infof("Lista de espera: %d oferta(s) expirada(s)", expiradas)
+	}
+	for _, o := range ofertas {
+		s.logger.Infof("Lista de espera: vaga da turma ID %d oferecida ao aluno ID %d até %s", o.ClassID, o.StudentID, *o.OfferExpiresAt)
+	}
+}
+
+// closeWaitlistEntry encerra a entrada e, se ela tinha oferta pendente, oferece a vaga ao próximo da fila
+func (s *EnrollmentService) closeWaitlistEntry(ctx context.Context, entry model.WaitlistEntry, status string) error {
+	fechada, err := s.repo.CloseWaitlistEntry(ctx, entry.ID, entry.Status, status)
+	if err != nil {
+		return err
+	}
+	if !fechada {
+		return fmt.Errorf("%w: a entrada foi alterada por outra requisição", ErrOfertaIndisponivel)
+	}
+	if entry.Status == model.StatusEsperaOfertada {
+		s.ofertarVagas(ctx, []int{entry.ClassID})
+	}
+	return nil
+}
+
+// waitlistEntry busca a entrada do aluno (ErrEsperaNaoEncontrada quando não existe)
+func (s *EnrollmentService) waitlistEntry(ctx context.Context, studentID, entryID int) (*model.WaitlistEntry, error) {
+	entry, err := s.repo.GetWaitlistEntry(ctx, studentID, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrEsperaNaoEncontrada
+	}
+	return entry, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sysocial/internal/enrollment/model"
+)
+
+// ConflictError é um ErrConflitoHorario com a lista dos encontros sobrepostos, devolvida ao cliente
+type ConflictError struct {
+	Conflicts []model.ScheduleConflict
+}
+
+func (e *ConflictError) Error() string {
+	descricoes := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		descricoes[i] = c.String()
+	}
+	return ErrConflitoHorario.Error() + ": " + strings.Join(descricoes, "; ")
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflitoHorario
+}
+
+// conflictError junta os conflitos de horário em um único erro ErrConflitoHorario
+func conflictError(conflicts []model.ScheduleConflict) error {
+	return &ConflictError{Conflicts: conflicts}
+}
+
+// janelasTurno devolve as janelas do turno escolar (manha, tarde ou integral = as duas); outro valor não restringe
+func (s *EnrollmentService) janelasTurno(schoolShift string) []model.ShiftWindow {
+	manha := model.ShiftWindow{Name: "da manhã", Start: s.turno.ManhaInicio, End: s.turno.ManhaFim}
+	tarde := model.ShiftWindow{Name: "da tarde", Start: s.turno.TardeInicio, End: s.turno.TardeFim}
+	switch schoolShift {
+	case "manha":
+		return []model.ShiftWindow{manha}
+	case "tarde":
+		return []model.ShiftWindow{tarde}
+	case "integral":
+		return []model.ShiftWindow{manha, tarde}
+	default:
+		return nil
+	}
+}
+
+// verificarGrade confere as turmas novas do aluno entre si, contra as demais turmas dele (outras) e contra o turno
+// escolar. Devolve *ConflictError com todos os encontros sobrepostos.
+func (s *EnrollmentService) verificarGrade(ctx context.Context, novas, outras []int, schoolShift string) error {
+	if len(novas) == 0 {
+		return nil
+	}
+
+	conflicts, err := s.repo.GetScheduleConflicts(ctx, novas, append(append([]int{}, novas...), outras...))
+	if err != nil {
+		return err
+	}
+
+	unicos := semDuplicados(conflicts, novas)
+
+	turno, err := s.repo.GetShiftConflicts(ctx, novas, s.janelasTurno(schoolShift))
+	if err != nil {
+		return err
+	}
+
+	if all := append(unicos, turno...); len(all) > 0 {
+		return conflictError(all)
+	}
+	return nil
+}
+
+// semDuplicados tira a repetição dos conflitos entre duas turmas novas, que aparecem nos dois sentidos: fica o da
+// turma de menor ID. Conflitos com as demais turmas do aluno ficam todos.
+func semDuplicados(conflicts []model.ScheduleConflict, novas []int) []model.ScheduleConflict {
+	nova := make(map[int]bool, len(novas))
+	for _, id := range novas {
+		nova[id] = true
+	}
+	unicos := make([]model.ScheduleConflict, 0, len(conflicts))
+	for _, c := range conflicts {
+		if !nova[c.ConflictingClassID] || c.ClassID < c.ConflictingClassID {
+			unicos = append(unicos, c)
+		}
+	}
+	return unicos
+}
+
+// turmasDoPayload devolve as turmas escolhidas no cadastro, sem repetição e em ordem crescente
+func turmasDoPayload(courses []model.CourseEnrollmentPayload) []int {
+	vistas := make(map[int]bool, len(courses))
+	var ids []int
+	for _, c := range courses {
+		id, err := strconv.Atoi(c.ClassID)
+		if err != nil || vistas[id] {
+			continue
+		}
+		vistas[id] = true
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// verificarGradeEdicao confere a grade na edição do cadastro: as turmas que entram contra as que o aluno mantém e,
+// se o turno escolar mudou, também as mantidas contra o novo turno
+func (s *EnrollmentService) verificarGradeEdicao(ctx context.Context, studentID int, payload model.NewEnrollmentPayload, matriculas []model.Matricula) error {
+	ativas := make(map[int]bool, len(matriculas))
+	for _, m := range matriculas {
+		if m.Status == model.StatusMatriculaAtivo {
+			ativas[m.TurmaID] = true
+		}
+	}
+
+	shift, err := s.repo.GetStudentShift(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	turnoMudou := shift != payload.Student.SchoolShift
+
+	var novas, mantidas []int
+	for _, id := range turmasDoPayload(payload.Courses) {
+		if ativas[id] && !turnoMudou {
+			mantidas = append(mantidas, id)
+		} else {
+			novas = append(novas, id)
+		}
+	}
+	return s.verificarGrade(ctx, novas, mantidas, payload.Student.SchoolShift)
+}
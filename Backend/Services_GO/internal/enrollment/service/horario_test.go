@@ -0,0 +1,77 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"sysocial/internal/enrollment/model"
+)
+
+func TestSemDuplicados(t *testing.T) {
+	conflito := func(de, com int) model.ScheduleConflict {
+		return model.ScheduleConflict{Type: model.ConflitoTurma, ClassID: de, ConflictingClassID: com}
+	}
+
+	tests := []struct {
+		name      string
+		conflicts []model.ScheduleConflict
+		novas     []int
+		want      []model.ScheduleConflict
+	}{
+		{
+			name:      "sem conflitos",
+			conflicts: []model.ScheduleConflict{},
+			novas:     []int{1, 2},
+			want:      []model.ScheduleConflict{},
+		},
+		{
+			name:      "duas turmas novas: fica um sentido",
+			conflicts: []model.ScheduleConflict{conflito(2, 1), conflito(1, 2)},
+			novas:     []int{1, 2},
+			want:      []model.ScheduleConflict{conflito(1, 2)},
+		},
+		{
+			name:      "nova contra turma mantida: fica",
+			conflicts: []model.ScheduleConflict{conflito(2, 9)},
+			novas:     []int{2},
+			want:      []model.ScheduleConflict{conflito(2, 9)},
+		},
+		{
+			name:      "mantida de ID menor: fica",
+			conflicts: []model.ScheduleConflict{conflito(5, 3)},
+			novas:     []int{5},
+			want:      []model.ScheduleConflict{conflito(5, 3)},
+		},
+		{
+			name: "três turmas novas e uma mantida",
+			conflicts: []model.ScheduleConflict{
+				conflito(1, 2), conflito(1, 3), conflito(2, 1), conflito(3, 1), conflito(3, 7),
+			},
+			novas: []int{1, 2, 3},
+			want:  []model.ScheduleConflict{conflito(1, 2), conflito(1, 3), conflito(3, 7)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := semDuplicados(tt.conflicts, tt.novas); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("semDuplicados = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleConflictString(t *testing.T) {
+	c := model.ScheduleConflict{
+		ClassName: "Violão A", DayOfWeek: "Segunda-feira", StartTime: "08:00", EndTime: "09:30",
+		ConflictingClassName: "turno escolar da manhã", ConflictingStartTime: "07:00", ConflictingEndTime: "12:00",
+	}
+	if got, want := c.String(), "Violão A (Segunda-feira 08:00-09:30) conflita com turno escolar da manhã (07:00-12:00)"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+
+	c.StartTime, c.EndTime = "", ""
+	if got, want := c.String(), "Violão A (Segunda-feira sem horário definido) conflita com turno escolar da manhã (07:00-12:00)"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
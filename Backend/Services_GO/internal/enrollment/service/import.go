@@ -0,0 +1,411 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"sysocial/internal/enrollment/model"
+	"sysocial/internal/shared/export"
+	"sysocial/internal/shared/validation"
+)
+
+// MaxImportRows limita o tamanho de um lote (um semestre costuma ter algumas dezenas de alunos)
+const MaxImportRows = 1000
+
+// importValidator aplica às linhas as mesmas tags `binding` do formulário
+var importValidator = validation.NewBinding()
+
+// ErrArquivoInvalido indica um CSV ilegível ou sem as colunas obrigatórias
+var ErrArquivoInvalido = errors.New("arquivo de importação inválido")
+
+// importColumn associa uma coluna do CSV ao campo do NewEnrollmentPayload (path igual ao dos erros de validação)
+type importColumn struct {
+	header string
+	path   string
+	set    func(p *model.NewEnrollmentPayload, value string)
+}
+
+// importColumns define o layout aceito. resp1 é o responsável principal (financeiro);
+// resp2 é opcional. "turmas" recebe os IDs das turmas separados por "|".
+var importColumns = []importColumn{
+	{"aluno_nome", "student.fullName", func(p *model.NewEnrollmentPayload, v string) { p.Student.FullName = v }},
+	{"aluno_nascimento", "student.birthDate", func(p *model.NewEnrollmentPayload, v string) { p.Student.BirthDate = parseImportDate(v) }},
+	{"aluno_cpf", "student.cpf", func(p *model.NewEnrollmentPayload, v string) { p.Student.CPF = v }},
+	{"aluno_telefone", "student.phone", func(p *model.NewEnrollmentPayload, v string) { p.Student.Phone = v }},
+	{"aluno_sexo", "student.gender", func(p *model.NewEnrollmentPayload, v string) { p.Student.Gender = strings.ToUpper(v) }},
+	{"aluno_cep", "student.zipCode", func(p *model.NewEnrollmentPayload, v string) { p.Student.ZipCode = v }},
+	{"aluno_rua", "student.street", func(p *model.NewEnrollmentPayload, v string) { p.Student.Street = v }},
+	{"aluno_numero", "student.number", func(p *model.NewEnrollmentPayload, v string) { p.Student.Number = v }},
+	{"aluno_bairro", "student.neighborhood", func(p *model.NewEnrollmentPayload, v string) { p.Student.Neighborhood = v }},
+	{"aluno_escola", "student.currentSchool", func(p *model.NewEnrollmentPayload, v string) { p.Student.CurrentSchool = v }},
+	{"aluno_serie", "student.series", func(p *model.NewEnrollmentPayload, v string) { p.Student.Series = v }},
+	{"aluno_turno_escolar", "student.schoolShift", func(p *model.NewEnrollmentPayload, v string) { p.Student.SchoolShift = normalizeHeader(v) }},
+	{"aluno_observacoes", "student.observation", func(p *model.NewEnrollmentPayload, v string) { p.Student.Observation = v }},
+	{"turmas", "courses", nil},
+}
+
+// studentTemplateColumns são as colunas do aluno na planilha modelo, antes das dos responsáveis e de "turmas"
+var studentTemplateColumns = []string{
+	"aluno_nome", "aluno_nascimento", "aluno_cpf", "aluno_telefone", "aluno_sexo", "aluno_cep", "aluno_rua",
+	"aluno_numero", "aluno_bairro", "aluno_escola", "aluno_serie", "aluno_turno_escolar", "aluno_observacoes",
+}
+
+// guardianColumns são repetidas com os prefixos resp1_ e resp2_
+var guardianColumns = []struct {
+	suffix string
+	field  string
+	set    func(g *model.GuardianPayload, value string)
+}{
+	{"nome", "fullName", func(g *model.GuardianPayload, v string) { g.FullName = v }},
+	{"cpf", "cpf", func(g *model.GuardianPayload, v string) { g.CPF = v }},
+	{"parentesco", "relationship", func(g *model.GuardianPayload, v string) { g.Relationship = normalizeHeader(v) }},
+	{"telefone", "phone", func(g *model.GuardianPayload, v string) { g.Phone = v }},
+	{"contato_telefone", "phoneContact", func(g *model.GuardianPayload, v string) { g.PhoneContact = v }},
+	{"recado1", "messagePhone1", func(g *model.GuardianPayload, v string) { g.MessagePhone1 = v }},
+	{"contato_recado1", "messagePhone1Contact", func(g *model.GuardianPayload, v string) { g.MessagePhone1Contact = v }},
+	{"recado2", "messagePhone2", func(g *model.GuardianPayload, v string) { g.MessagePhone2 = v }},
+	{"contato_recado2", "messagePhone2Contact", func(g *model.GuardianPayload, v string) { g.MessagePhone2Contact = v }},
+}
+
+// requiredImportHeaders precisam existir no cabeçalho (os valores são validados linha a linha)
+var requiredImportHeaders = []string{"aluno_nome", "aluno_nascimento", "aluno_cpf", "aluno_sexo", "aluno_turno_escolar", "resp1_nome", "resp1_cpf", "resp1_parentesco", "resp1_telefone"}
+
+// importRow é uma linha já convertida em payload, com os IDs de turma ainda por resolver
+type importRow struct {
+	line     int
+	payload  model.NewEnrollmentPayload
+	classIDs []int
+	errors   []model.ImportRowError
+}
+
+// ImportEnrollments lê o CSV, valida todas as linhas e, fora do modo dryRun, matricula as linhas válidas.
+// Cada linha é gravada em sua própria transação (CreateEnrollment), reaproveitando responsáveis pelo CPF.
+func (s *EnrollmentService) ImportEnrollments(ctx context.Context, file io.Reader, dryRun bool, userID int) (*model.ImportResult, error) {
+	rows, err := parseImportCSV(file)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ImportResult{DryRun: dryRun, TotalRows: len(rows), StudentIDs: []int{}, Errors: []model.ImportRowError{}}
+
+	if err := s.validateImportRows(ctx, rows); err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		if len(row.errors) > 0 {
+			result.Errors = append(result.Errors, row.errors...)
+			continue
+		}
+		result.ValidRows++
+
+		if dryRun {
+			continue
+		}
+
+		id, err := s.repo.CreateEnrollment(ctx, row.payload, userID)
+		if err != nil {
+			result.Errors = append(result.Errors, model.ImportRowError{Line: row.line, Message: err.Error()})
+			continue
+		}
+		result.Imported++
+		result.StudentIDs = append(result.StudentIDs, id)
+	}
+
+	s.logger.Infof("Importação de matrículas (dryRun=%t): %d linhas, %d válidas, %d importadas",
+		dryRun, result.TotalRows, result.ValidRows, result.Imported)
+	return result, nil
+}
+
+// ImportErrorReport converte os erros da importação em planilha para download
+func ImportErrorReport(result *model.ImportResult) export.Table {
+	table := export.Table{Sheet: "Erros", Headers: []string{"Linha", "Coluna", "Erro"}}
+	for _, e := range result.Errors {
+		table.Rows = append(table.Rows, []string{strconv.Itoa(e.Line), e.Column, e.Message})
+	}
+	return table
+}
+
+// ImportTemplate devolve a planilha modelo (apenas o cabeçalho) no layout aceito pela importação
+func ImportTemplate() export.Table {
+	headers := append([]string{}, studentTemplateColumns...)
+	for n := 1; n <= 2; n++ {
+		for _, col := range guardianColumns {
+			headers = append(headers, fmt.Sprintf("resp%d_%s", n, col.suffix))
+		}
+	}
+	headers = append(headers, "turmas")
+	return export.Table{Sheet: "Matrículas", Headers: headers}
+}
+
+// validateImportRows aplica as mesmas validações do POST /enrollments e confere CPFs repetidos,
+// turmas inexistentes e vagas, acumulando os erros em cada linha
+func (s *EnrollmentService) validateImportRows(ctx context.Context, rows []importRow) error {
+	var allClassIDs []int
+	for _, row := range rows {
+		allClassIDs = append(allClassIDs, row.classIDs...)
+	}
+	classes, err := s.repo.GetClassesInfo(ctx, allClassIDs)
+	if err != nil {
+		return err
+	}
+
+	seenCPF := make(map[string]int)
+	spotsUsed := make(map[int]int)
+
+	for i := range rows {
+		row := &rows[i]
+
+		// Validação estrutural (mesmas tags binding do formulário)
+		if err := importValidator.Struct(&row.payload); err != nil {
+			for _, fe := range validation.Errors(err) {
+				row.addError(importHeaderFor(fe.Field), fe.Message)
+			}
+		}
+
+		// Turmas: precisam existir (curso ativo) e ter vaga considerando as linhas anteriores do arquivo
+		for _, classID := range row.classIDs {
+			info, ok := classes[classID]
+			if !ok {
+				row.addError("turmas", fmt.Sprintf("turma %d não encontrada ou curso inativo", classID))
+				continue
+			}
+			row.payload.Courses = append(row.payload.Courses, model.CourseEnrollmentPayload{
+				CourseID: strconv.Itoa(info.CourseID),
+				ClassID:  strconv.Itoa(classID),
+			})
+		}
+
+		if len(row.errors) > 0 {
+			continue
+		}
+
+		// Regras de negócio (CPF, responsável financeiro) e normalização dos CPFs
+		if err := validateEnrollment(&row.payload); err != nil {
+			row.addError("", err.Error())
+			continue
+		}
+
+		studentCPF := row.payload.Student.CPF
+		if line, dup := seenCPF[studentCPF]; dup {
+			row.addError("aluno_cpf", fmt.Sprintf("CPF repetido no arquivo (linha %d)", line))
+			continue
+		}
+		seenCPF[studentCPF] = row.line
+
+		exists, err := s.repo.CheckCpfExists(ctx, studentCPF)
+		if err != nil {
+			return err
+		}
+		if exists {
+			row.addError("aluno_cpf", "CPF já cadastrado no sistema")
+			continue
+		}
+
+		// Grade: turmas da linha entre si e contra o turno escolar
+		err = s.verificarGrade(ctx, turmasDoPayload(row.payload.Courses), nil, row.payload.Student.SchoolShift)
+		var conflito *ConflictError
+		if errors.As(err, &conflito) {
+			for _, c := range conflito.Conflicts {
+				row.addError("turmas", "conflito de horário: "+c.String())
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, c := range row.payload.Courses {
+			classID, _ := strconv.Atoi(c.ClassID)
+			info := classes[classID]
+			if spotsUsed[classID] >= info.AvailableSpots {
+				row.addError("turmas", fmt.Sprintf("turma %d sem vagas", classID))
+			}
+		}
+		if len(row.errors) > 0 {
+			continue
+		}
+		for _, c := range row.payload.Courses {
+			classID, _ := strconv.Atoi(c.ClassID)
+			spotsUsed[classID]++
+		}
+	}
+
+	return nil
+}
+
+func (r *importRow) addError(column, message string) {
+	r.errors = append(r.errors, model.ImportRowError{Line: r.line, Column: column, Message: message})
+}
+
+// parseImportCSV lê o arquivo (separador ";" ou ",", com ou sem BOM) e converte cada linha em payload
+func parseImportCSV(file io.Reader) ([]importRow, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrArquivoInvalido, err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: cabeçalho ausente", ErrArquivoInvalido)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[normalizeHeader(h)] = i
+	}
+
+	var missing []string
+	for _, h := range requiredImportHeaders {
+		if _, ok := index[h]; !ok {
+			missing = append(missing, h)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: colunas obrigatórias ausentes: %s", ErrArquivoInvalido, strings.Join(missing, ", "))
+	}
+
+	var rows []importRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: linha %d: %v", ErrArquivoInvalido, line, err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(rows) == MaxImportRows {
+			return nil, fmt.Errorf("%w: limite de %d linhas por arquivo", ErrArquivoInvalido, MaxImportRows)
+		}
+
+		rows = append(rows, buildImportRow(line, record, index))
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: nenhuma linha de dados", ErrArquivoInvalido)
+	}
+	return rows, nil
+}
+
+// buildImportRow monta o NewEnrollmentPayload de uma linha do CSV
+func buildImportRow(line int, record []string, index map[string]int) importRow {
+	row := importRow{line: line}
+	get := func(header string) string {
+		if i, ok := index[header]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	for _, col := range importColumns {
+		if col.set != nil {
+			col.set(&row.payload, get(col.header))
+		}
+	}
+
+	for n := 1; n <= 2; n++ {
+		prefix := fmt.Sprintf("resp%d_", n)
+		// Segundo responsável é opcional: só entra se nome ou CPF forem preenchidos
+		if n > 1 && get(prefix+"nome") == "" && get(prefix+"cpf") == "" {
+			continue
+		}
+		guardian := model.GuardianPayload{IsPrincipal: n == 1}
+		for _, col := range guardianColumns {
+			col.set(&guardian, get(prefix+col.suffix))
+		}
+		row.payload.Guardians = append(row.payload.Guardians, guardian)
+	}
+
+	for _, value := range strings.Split(get("turmas"), "|") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		classID, err := strconv.Atoi(value)
+		if err != nil {
+			row.addError("turmas", fmt.Sprintf("ID de turma inválido: %q", value))
+			continue
+		}
+		row.classIDs = append(row.classIDs, classID)
+	}
+
+	return row
+}
+
+// importHeaderFor traduz o campo do erro de validação (ex: "guardians[0].cpf") para a coluna do CSV (resp1_cpf)
+func importHeaderFor(field string) string {
+	for _, col := range importColumns {
+		if col.path == field {
+			return col.header
+		}
+	}
+
+	var n int
+	var name string
+	if _, err := fmt.Sscanf(strings.Replace(field, "].", " ", 1), "guardians[%d %s", &n, &name); err == nil {
+		for _, col := range guardianColumns {
+			if col.field == name {
+				return fmt.Sprintf("resp%d_%s", n+1, col.suffix)
+			}
+		}
+	}
+	return field
+}
+
+// detectDelimiter escolhe entre ";" (padrão do Excel em pt-BR) e "," olhando o cabeçalho
+func detectDelimiter(data []byte) rune {
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	if bytes.Count(firstLine, []byte(",")) > bytes.Count(firstLine, []byte(";")) {
+		return ','
+	}
+	return ';'
+}
+
+// normalizeHeader deixa o texto minúsculo e sem acentos ("Turno Escolar" -> "turno_escolar", "Manhã" -> "manha")
+func normalizeHeader(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	value = strings.NewReplacer(
+		"á", "a", "à", "a", "â", "a", "ã", "a",
+		"é", "e", "ê", "e", "í", "i",
+		"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ç", "c",
+		" ", "_",
+	).Replace(value)
+	return value
+}
+
+// parseImportDate aceita DD/MM/AAAA (padrão das planilhas) e AAAA-MM-DD; outros formatos seguem para a validação
+func parseImportDate(value string) string {
+	if t, err := time.Parse("02/01/2006", value); err == nil {
+		return t.Format("2006-01-02")
+	}
+	return value
+}
+
+func isBlankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
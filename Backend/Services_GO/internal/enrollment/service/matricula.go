@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"sysocial/internal/enrollment/model"
+)
+
+// transicoesMatricula é o ciclo de vida da matrícula: status de origem -> destinos permitidos.
+// TRANSFERIDO, CONCLUIDO, CANCELADO e EVADIDO são finais.
+var transicoesMatricula = map[string][]string{
+	model.StatusMatriculaPreInscrito: {model.StatusMatriculaAtivo, model.StatusMatriculaCancelado},
+	model.StatusMatriculaAtivo: {
+		model.StatusMatriculaTrancado, model.StatusMatriculaTransferido, model.StatusMatriculaConcluido,
+		model.StatusMatriculaCancelado, model.StatusMatriculaEvadido,
+	},
+	model.StatusMatriculaTrancado: {model.StatusMatriculaAtivo, model.StatusMatriculaCancelado, model.StatusMatriculaEvadido},
+}
+
+// transicaoPermitida verifica se a matrícula pode passar de um status para o outro
+func transicaoPermitida(de, para string) bool {
+	for _, destino := range transicoesMatricula[de] {
+		if destino == para {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeMatriculaStatus muda o status de uma única matrícula do aluno na data efetiva (vazia = hoje), dentro das
+// transições permitidas. Encerrar devolve a vaga só da turma da matrícula (oferecida à lista de espera) e o aluno
+// continua ativo se tiver outros cursos; reabrir uma matrícula trancada abre uma nova matrícula ATIVO a partir da
+// data efetiva, que volta a ocupar a vaga. Devolve o ID da matrícula que ficou com o novo status.
+func (s *EnrollmentService) ChangeMatriculaStatus(ctx context.Context, studentID, matriculaID int, payload model.StatusChangePayload, userID int) (int, error) {
+	payload.Reason = strings.TrimSpace(payload.Reason)
+	if payload.Reason == "" {
+		return 0, fmt.Errorf("%w: informe o motivo", ErrMudancaStatusInvalida)
+	}
+
+	matricula, err := s.repo.GetMatricula(ctx, studentID, matriculaID)
+	if err != nil {
+		return 0, err
+	}
+	if matricula == nil {
+		return 0, ErrMatriculaNaoEncontrada
+	}
+	if !transicaoPermitida(matricula.Status, payload.Status) {
+		return 0, fmt.Errorf("%w: de %s para %s", ErrTransicaoInvalida, matricula.Status, payload.Status)
+	}
+
+	// Uma trancada que já foi reaberta fica só como registro do trancamento
+	if matricula.Status == model.StatusMatriculaTrancado {
+		reaberta, err := s.repo.HasLaterMatricula(ctx, *matricula)
+		if err != nil {
+			return 0, err
+		}
+		if reaberta {
+			return 0, fmt.Errorf("%w: a matrícula trancada já foi reaberta em outra matrícula da turma", ErrTransicaoInvalida)
+		}
+	}
+
+	dataEfetiva, err := dataEfetivaTransicao(*matricula, payload.EffectiveDate)
+	if err != nil {
+		return 0, err
+	}
+
+	if reabertura(matricula.Status, payload.Status) {
+		return s.reabrirMatricula(ctx, *matricula, dataEfetiva, payload.Reason, userID)
+	}
+
+	alterada, err := s.repo.ChangeMatriculaStatus(ctx, *matricula, payload.Status, dataEfetiva, payload.Reason, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !alterada {
+		return 0, fmt.Errorf("%w: o status da matrícula foi alterado por outra requisição", ErrTransicaoInvalida)
+	}
+
+	s.logger.Infof("Matrícula ID %d do aluno ID %d: %s -> %s em %s", matriculaID, studentID, matricula.Status, payload.Status, dataEfetiva)
+	if matricula.Status == model.StatusMatriculaAtivo {
+		s.ofertarVagas(ctx, []int{matricula.TurmaID}) // A vaga liberada vai para a lista de espera
+	}
+	return matriculaID, nil
+}
+
+// reabrirMatricula abre a nova matrícula ATIVO de uma trancada; ela começa depois do trancamento para que os dias
+// trancados não entrem na chamada nem na frequência
+func (s *EnrollmentService) reabrirMatricula(ctx context.Context, trancada model.Matricula, dataEfetiva, motivo string, userID int) (int, error) {
+	if trancada.DataFim != nil && dataEfetiva <= trancada.DataFim.Format("2006-01-02") {
+		return 0, fmt.Errorf("%w: a matrícula foi trancada em %s; reabra a partir do dia seguinte",
+			ErrMudancaStatusInvalida, trancada.DataFim.Format("2006-01-02"))
+	}
+
+	novaID, err := s.repo.ReactivateMatricula(ctx, trancada, dataEfetiva, motivo, userID)
+	if err != nil {
+		return 0, err
+	}
+	if novaID == 0 {
+		return 0, fmt.Errorf("%w: o status da matrícula foi alterado por outra requisição", ErrTransicaoInvalida)
+	}
+
+	s.logger.Infof("Matrícula ID %d do aluno ID %d reaberta em %s (nova matrícula ID %d)", trancada.ID, trancada.AlunoID, dataEfetiva, novaID)
+	return novaID, nil
+}
+
+// reabertura indica a volta de uma matrícula trancada, que abre uma nova matrícula em vez de alterar a trancada
+func reabertura(de, para string) bool {
+	return de == model.StatusMatriculaTrancado && para == model.StatusMatriculaAtivo
+}
+
+// TransferMatricula transfere uma matrícula ativa para outra turma na data efetiva (vazia = hoje): a matrícula de
+// origem termina na véspera como TRANSFERIDO e a de destino começa na data, com vaga e grade de horários conferidas.
+// Devolve o ID da nova matrícula.
+func (s *EnrollmentService) TransferMatricula(ctx context.Context, studentID, matriculaID int, payload model.TransferPayload, userID int) (int, error) {
+	payload.Reason = strings.TrimSpace(payload.Reason)
+	if payload.Reason == "" {
+		return 0, fmt.Errorf("%w: informe o motivo", ErrMudancaStatusInvalida)
+	}
+
+	matricula, err := s.repo.GetMatricula(ctx, studentID, matriculaID)
+	if err != nil {
+		return 0, err
+	}
+	if matricula == nil {
+		return 0, ErrMatriculaNaoEncontrada
+	}
+	if !transicaoPermitida(matricula.Status, model.StatusMatriculaTransferido) {
+		return 0, fmt.Errorf("%w: de %s para %s", ErrTransicaoInvalida, matricula.Status, model.StatusMatriculaTransferido)
+	}
+	if payload.TargetClassID == matricula.TurmaID {
+		return 0, fmt.Errorf("%w: o aluno já está nesta turma", ErrTransferenciaInvalida)
+	}
+
+	dataEfetiva, err := dataEfetivaTransicao(*matricula, payload.EffectiveDate)
+	if err != nil {
+		return 0, err
+	}
+	// A origem termina na véspera: precisa ter ao menos um dia de vigência
+	if dataEfetiva == matricula.DataInicio.Format("2006-01-02") {
+		return 0, fmt.Errorf("%w: a matrícula começou em %s; transfira a partir do dia seguinte", ErrTransferenciaInvalida, dataEfetiva)
+	}
+
+	if err := s.verificarDestinoTransferencia(ctx, *matricula, payload.TargetClassID, dataEfetiva); err != nil {
+		return 0, err
+	}
+
+	novaID, err := s.repo.TransferMatricula(ctx, *matricula, payload.TargetClassID, dataEfetiva, payload.Reason, userID)
+	if err != nil {
+		return 0, err
+	}
+	if novaID == 0 {
+		return 0, fmt.Errorf("%w: o status da matrícula foi alterado por outra requisição", ErrTransicaoInvalida)
+	}
+
+	s.logger.Infof("Matrícula ID %d do aluno ID %d transferida para a turma ID %d (matrícula ID %d) em %s",
+		matriculaID, studentID, payload.TargetClassID, novaID, dataEfetiva)
+	s.ofertarVagas(ctx, []int{matricula.TurmaID}) // A vaga liberada na origem vai para a lista de espera
+	return novaID, nil
+}
+
+// verificarDestinoTransferencia confere a turma de destino: existente, de curso ativo, com a data efetiva dentro do
+// período dela e sem matrícula ativa do aluno. Confere também a grade do destino contra as demais turmas ativas e o
+// turno escolar do aluno e recusa a transferência se já há presenças na origem a partir da data efetiva.
+func (s *EnrollmentService) verificarDestinoTransferencia(ctx context.Context, origem model.Matricula, targetClassID int, dataEfetiva string) error {
+	classes, err := s.repo.GetClassesInfo(ctx, []int{targetClassID})
+	if err != nil {
+		return err
+	}
+	destino, ok := classes[targetClassID]
+	if !ok {
+		return fmt.Errorf("%w: turma de destino %d não encontrada ou de curso inativo", ErrTransferenciaInvalida, targetClassID)
+	}
+	if dataEfetiva < destino.StartDate || dataEfetiva > destino.EndDate {
+		return fmt.Errorf("%w: a turma de destino vai de %s a %s; a data efetiva %s está fora desse período",
+			ErrTransferenciaInvalida, destino.StartDate, destino.EndDate, dataEfetiva)
+	}
+
+	matriculas, err := s.repo.GetMatriculas(ctx, origem.AlunoID)
+	if err != nil {
+		return err
+	}
+	var outras []int
+	for _, m := range matriculas {
+		if m.Status != model.StatusMatriculaAtivo || m.ID == origem.ID {
+			continue
+		}
+		if m.TurmaID == targetClassID {
+			return fmt.Errorf("%w: o aluno já está matriculado na turma de destino", ErrTransferenciaInvalida)
+		}
+		outras = append(outras, m.TurmaID)
+	}
+
+	shift, err := s.repo.GetStudentShift(ctx, origem.AlunoID)
+	if err != nil {
+		return err
+	}
+	if err := s.verificarGrade(ctx, []int{targetClassID}, outras, shift); err != nil {
+		return err
+	}
+
+	lancadas, err := s.repo.HasAttendanceSince(ctx, origem.AlunoID, origem.TurmaID, dataEfetiva)
+	if err != nil {
+		return err
+	}
+	if lancadas {
+		return fmt.Errorf("%w: há presenças lançadas na turma de origem a partir de %s", ErrTransferenciaInvalida, dataEfetiva)
+	}
+	return nil
+}
+
+// GetEnrollmentTimeline devolve a linha do tempo de todas as matrículas do aluno
+func (s *EnrollmentService) GetEnrollmentTimeline(ctx context.Context, studentID int) ([]model.EnrollmentEvent, error) {
+	exists, err := s.repo.StudentExists(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrAlunoNaoEncontrado
+	}
+	return s.repo.GetEnrollmentTimeline(ctx, studentID)
+}
+
+// dataEfetivaTransicao resolve a data efetiva (vazia = hoje): não pode ser futura nem anterior à vigência atual
+// (o início da matrícula ativa ou o fim do trancamento)
+func dataEfetivaTransicao(m model.Matricula, informada string) (string, error) {
+	hoje := time.Now().Format("2006-01-02")
+	data := informada
+	if data == "" {
+		data = hoje
+	}
+	if data > hoje {
+		return "", fmt.Errorf("%w: a data efetiva não pode ser futura", ErrMudancaStatusInvalida)
+	}
+
+	switch m.Status {
+	case model.StatusMatriculaAtivo:
+		if inicio := m.DataInicio.Format("2006-01-02"); data < inicio {
+			return "", fmt.Errorf("%w: a matrícula começou em %s", ErrMudancaStatusInvalida, inicio)
+		}
+	case model.StatusMatriculaTrancado:
+		if m.DataFim != nil && data < m.DataFim.Format("2006-01-02") {
+			return "", fmt.Errorf("%w: a matrícula foi trancada em %s", ErrMudancaStatusInvalida, m.DataFim.Format("2006-01-02"))
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"sysocial/internal/enrollment/model"
+)
+
+func TestTransicaoPermitida(t *testing.T) {
+	todos := []string{
+		model.StatusMatriculaPreInscrito, model.StatusMatriculaAtivo, model.StatusMatriculaTrancado,
+		model.StatusMatriculaTransferido, model.StatusMatriculaConcluido, model.StatusMatriculaCancelado,
+		model.StatusMatriculaEvadido,
+	}
+	permitidas := map[[2]string]bool{
+		{model.StatusMatriculaPreInscrito, model.StatusMatriculaAtivo}:     true,
+		{model.StatusMatriculaPreInscrito, model.StatusMatriculaCancelado}: true,
+		{model.StatusMatriculaAtivo, model.StatusMatriculaTrancado}:        true,
+		{model.StatusMatriculaAtivo, model.StatusMatriculaTransferido}:     true,
+		{model.StatusMatriculaAtivo, model.StatusMatriculaConcluido}:       true,
+		{model.StatusMatriculaAtivo, model.StatusMatriculaCancelado}:       true,
+		{model.StatusMatriculaAtivo, model.StatusMatriculaEvadido}:         true,
+		{model.StatusMatriculaTrancado, model.StatusMatriculaAtivo}:        true,
+		{model.StatusMatriculaTrancado, model.StatusMatriculaCancelado}:    true,
+		{model.StatusMatriculaTrancado, model.StatusMatriculaEvadido}:      true,
+	}
+
+	// Todas as combinações: só as do ciclo de vida passam; status finais não saem do lugar
+	for _, de := range todos {
+		for _, para := range todos {
+			want := permitidas[[2]string{de, para}]
+			if got := transicaoPermitida(de, para); got != want {
+				t.Errorf("transicaoPermitida(%s, %s) = %v, want %v", de, para, got, want)
+			}
+		}
+	}
+
+	if transicaoPermitida("", model.StatusMatriculaAtivo) || transicaoPermitida(model.StatusMatriculaAtivo, "DESCONHECIDO") {
+		t.Error("status desconhecidos não podem transicionar")
+	}
+}
+
+func TestReabertura(t *testing.T) {
+	if !reabertura(model.StatusMatriculaTrancado, model.StatusMatriculaAtivo) {
+		t.Error("TRANCADO -> ATIVO é reabertura")
+	}
+	if reabertura(model.StatusMatriculaPreInscrito, model.StatusMatriculaAtivo) {
+		t.Error("PRE_INSCRITO -> ATIVO não é reabertura")
+	}
+	if reabertura(model.StatusMatriculaTrancado, model.StatusMatriculaCancelado) {
+		t.Error("TRANCADO -> CANCELADO não é reabertura")
+	}
+}
+
+func TestDataEfetivaTransicao(t *testing.T) {
+	hoje := time.Now()
+	dia := func(offset int) time.Time {
+		d := hoje.AddDate(0, 0, offset)
+		return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	data := func(offset int) string { return dia(offset).Format("2006-01-02") }
+	fim := func(offset int) *time.Time { d := dia(offset); return &d }
+
+	ativa := model.Matricula{Status: model.StatusMatriculaAtivo, DataInicio: dia(-10)}
+	trancada := model.Matricula{Status: model.StatusMatriculaTrancado, DataInicio: dia(-30), DataFim: fim(-5)}
+	pre := model.Matricula{Status: model.StatusMatriculaPreInscrito, DataInicio: dia(-2)}
+
+	tests := []struct {
+		name      string
+		matricula model.Matricula
+		informada string
+		want      string
+		wantErr   bool
+	}{
+		{"vazia = hoje", ativa, "", data(0), false},
+		{"hoje", ativa, data(0), data(0), false},
+		{"futura", ativa, data(1), "", true},
+		{"no início da ativa", ativa, data(-10), data(-10), false},
+		{"antes do início da ativa", ativa, data(-11), "", true},
+		{"no dia do trancamento", trancada, data(-5), data(-5), false},
+		{"depois do trancamento", trancada, data(-4), data(-4), false},
+		{"antes do trancamento", trancada, data(-6), "", true},
+		{"pré-inscrição sem limite inferior", pre, data(-20), data(-20), false},
+		{"pré-inscrição futura", pre, data(3), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dataEfetivaTransicao(tt.matricula, tt.informada)
+			if tt.wantErr {
+				if !errors.Is(err, ErrMudancaStatusInvalida) {
+					t.Fatalf("err = %v, want ErrMudancaStatusInvalida", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("dataEfetivaTransicao = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
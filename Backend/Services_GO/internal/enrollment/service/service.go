@@ -2,89 +2,288 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"sysocial/internal/enrollment/model"
 	"sysocial/internal/enrollment/repository"
+	"sysocial/internal/shared/config"
+	"sysocial/internal/shared/cpf"
+	"sysocial/internal/shared/export"
 	"sysocial/internal/shared/logger"
 )
 
+var (
+	// ErrCPFInvalido indica um CPF com formato ou dígitos verificadores inválidos
+	ErrCPFInvalido = errors.New("CPF inválido")
+	// ErrFiltroInvalido indica parâmetros de busca inconsistentes entre si
+	ErrFiltroInvalido = errors.New("filtro inválido")
+	// ErrAlunoNaoEncontrado indica um aluno inexistente
+	ErrAlunoNaoEncontrado = errors.New("aluno não encontrado")
+	// ErrMatriculaNaoEncontrada indica uma matrícula inexistente ou de outro aluno
+	ErrMatriculaNaoEncontrada = errors.New("matrícula não encontrada")
+	// ErrTransicaoInvalida indica uma mudança de status fora do ciclo de vida da matrícula
+	ErrTransicaoInvalida = errors.New("transição de status da matrícula não permitida")
+	// ErrTransferenciaInvalida indica uma transferência para a própria turma, para turma inexistente ou fora do período
+	// na data efetiva, ou com presenças já lançadas na turma de origem depois da data efetiva
+	ErrTransferenciaInvalida = errors.New("transferência inválida")
+	// ErrConflitoHorario indica encontros semanais sobrepostos entre turmas do aluno
+	ErrConflitoHorario = errors.New("conflito de horário")
+	// ErrTurmaLotada indica uma turma sem vagas
+	ErrTurmaLotada = repository.ErrTurmaLotada
+	// ErrMudancaStatusInvalida indica uma mudança de status sem motivo ou com data efetiva futura ou fora da vigência
+	ErrMudancaStatusInvalida = errors.New("mudança de status da matrícula inválida")
+	// ErrMatriculaTrancada indica uma turma em que o aluno está com a matrícula trancada: a volta é pela reabertura
+	// (TRANCADO -> ATIVO), não pela edição do cadastro
+	ErrMatriculaTrancada = errors.New("matrícula trancada na turma")
+)
+
 type EnrollmentService struct {
-	repo   *repository.EnrollmentRepository
-	logger logger.Logger
+	repo        *repository.EnrollmentRepository
+	logger      logger.Logger
+	listaEspera config.ListaEsperaConfig
+	turno       config.TurnoConfig
 }
 
-func NewEnrollmentService(repo *repository.EnrollmentRepository, logger logger.Logger) *EnrollmentService {
-	return &EnrollmentService{repo: repo, logger: logger}
+func NewEnrollmentService(repo *repository.EnrollmentRepository, logger logger.Logger, listaEspera config.ListaEsperaConfig, turno config.TurnoConfig) *EnrollmentService {
+	return &EnrollmentService{repo: repo, logger: logger, listaEspera: listaEspera, turno: turno}
 }
 
-func (s *EnrollmentService) SearchStudents(ctx context.Context, filter model.StudentFilter) ([]model.StudentSummary, error) {
+func (s *EnrollmentService) SearchStudents(ctx context.Context, filter model.StudentFilter) (*model.StudentPage, error) {
+	if err := normalizeStudentFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = model.DefaultStudentLimit
+	}
+
 	return s.repo.SearchStudents(ctx, filter)
 }
 
-func (s *EnrollmentService) CheckCpfAvailability(ctx context.Context, cpf string) (bool, error) {
-	// Assumindo que o repository faz a limpeza das strings ou recebe o dado bruto.
-	return s.repo.CheckCpfExists(ctx, cpf)
+// normalizeStudentFilter aplica os padrões e confere a consistência do filtro (busca e exportação)
+func normalizeStudentFilter(filter *model.StudentFilter) error {
+	// Busca parcial por CPF compara apenas dígitos (o banco guarda a forma canônica)
+	filter.CPF = cpf.Normalize(filter.CPF)
+
+	// Idade exata é uma faixa de um ano só
+	if filter.Age > 0 {
+		if (filter.MinAge > 0 && filter.MinAge != filter.Age) || (filter.MaxAge > 0 && filter.MaxAge != filter.Age) {
+			return fmt.Errorf("%w: age não pode ser combinado com minAge/maxAge diferentes", ErrFiltroInvalido)
+		}
+		filter.MinAge, filter.MaxAge = filter.Age, filter.Age
+	}
+	if filter.MaxAge > 0 && filter.MinAge > filter.MaxAge {
+		return fmt.Errorf("%w: minAge maior que maxAge", ErrFiltroInvalido)
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = model.DefaultStudentSort
+	}
+	if filter.Order == "" {
+		filter.Order = "asc"
+	}
+	return nil
+}
+
+// ExportStudents monta a planilha de alunos do filtro com as colunas pedidas (padrão: DefaultStudentExportColumns).
+// maskCPF oculta o CPF para perfis sem acesso a dados pessoais.
+func (s *EnrollmentService) ExportStudents(ctx context.Context, filter model.StudentFilter, maskCPF bool) (*export.Table, error) {
+	if err := normalizeStudentFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	columns := append([]string(nil), model.DefaultStudentExportColumns...)
+	if filter.Columns != "" {
+		columns = strings.Split(filter.Columns, ",")
+	}
+
+	headers := make([]string, 0, len(columns))
+	for i, key := range columns {
+		key = strings.TrimSpace(key)
+		columns[i] = key
+		header := ""
+		for _, col := range model.StudentExportColumns {
+			if col.Key == key {
+				header = col.Header
+				break
+			}
+		}
+		if header == "" {
+			return nil, fmt.Errorf("%w: coluna de exportação desconhecida %q", ErrFiltroInvalido, key)
+		}
+		headers = append(headers, header)
+	}
+
+	students, err := s.repo.ExportStudents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	table := &export.Table{Sheet: "Alunos", Headers: headers, Rows: make([][]string, 0, len(students))}
+	for _, student := range students {
+		if maskCPF {
+			student.CPF = cpf.Mask(student.CPF)
+		} else {
+			student.CPF = cpf.Format(student.CPF)
+		}
+
+		row := make([]string, len(columns))
+		for i, key := range columns {
+			row[i] = student.Value(key)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	s.logger.Infof("Exportação de alunos: %d registros, %d colunas", len(table.Rows), len(columns))
+	return table, nil
+}
+
+// Search executa a busca unificada; types vazio busca em todas as fontes
+func (s *EnrollmentService) Search(ctx context.Context, query model.SearchQuery) ([]model.SearchResult, error) {
+	types := []string{model.SearchTypeStudent, model.SearchTypeGuardian, model.SearchTypeCourse, model.SearchTypeTurma}
+	if query.Types != "" {
+		types = nil
+		seen := make(map[string]bool)
+		for _, t := range strings.Split(query.Types, ",") {
+			t = strings.TrimSpace(t)
+			switch t {
+			case model.SearchTypeStudent, model.SearchTypeGuardian, model.SearchTypeCourse, model.SearchTypeTurma:
+				if !seen[t] { // Tipo repetido duplicaria os resultados
+					seen[t] = true
+					types = append(types, t)
+				}
+			default:
+				return nil, fmt.Errorf("%w: tipo de busca desconhecido %q", ErrFiltroInvalido, t)
+			}
+		}
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = model.DefaultSearchLimit
+	}
+
+	return s.repo.Search(ctx, strings.TrimSpace(query.Q), types, limit)
+}
+
+func (s *EnrollmentService) CheckCpfAvailability(ctx context.Context, studentCPF string) (bool, error) {
+	if !cpf.IsValid(studentCPF) {
+		return false, ErrCPFInvalido
+	}
+	return s.repo.CheckCpfExists(ctx, cpf.Normalize(studentCPF))
 }
 
-func (s *EnrollmentService) CancelEnrollment(ctx context.Context, studentID int) error {
+// CancelEnrollment inativa o aluno e cancela todas as matrículas que ainda aceitam o cancelamento
+func (s *EnrollmentService) CancelEnrollment(ctx context.Context, studentID int, userID int) error {
 	s.logger.Infof("Cancelando (inativando) matrícula do aluno ID: %d", studentID)
-	return s.repo.CancelEnrollment(ctx, studentID)
+
+	matriculas, err := s.repo.GetMatriculas(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	canceladas := []model.Matricula{}
+	liberadas := []int{}
+	for _, m := range matriculas {
+		if transicaoPermitida(m.Status, model.StatusMatriculaCancelado) {
+			canceladas = append(canceladas, m)
+		}
+		if m.Status == model.StatusMatriculaAtivo {
+			liberadas = append(liberadas, m.TurmaID)
+		}
+	}
+
+	if err := s.repo.CancelEnrollment(ctx, studentID, canceladas, "Cancelamento da matrícula do aluno", userID); err != nil {
+		return err
+	}
+
+	// O aluno sai das listas de espera; as vagas liberadas (e as ofertas que ele tinha) vão para os próximos da fila
+	ofertas, err := s.repo.RemoveStudentWaitlist(ctx, studentID)
+	if err != nil {
+		s.logger.Warnf("Aluno ID %d cancelado, mas não saiu das listas de espera: %v", studentID, err)
+	}
+	s.ofertarVagas(ctx, append(liberadas, ofertas...))
+	return nil
 }
 
 func (s *EnrollmentService) GetEnrollmentByID(ctx context.Context, studentID int) (*model.NewEnrollmentPayload, error) {
 	return s.repo.GetEnrollmentByID(ctx, studentID)
 }
 
-func (s *EnrollmentService) UpdateEnrollment(ctx context.Context, id int, payload model.NewEnrollmentPayload) error {
+func (s *EnrollmentService) UpdateEnrollment(ctx context.Context, id int, payload model.NewEnrollmentPayload, userID int) error {
 	// 1. Validações de Negócio (Mesmas do Create)
-	if payload.Student.FullName == "" {
-		return fmt.Errorf("nome do aluno é obrigatório")
+	if err := validateEnrollment(&payload); err != nil {
+		return err
 	}
-	if payload.Student.CPF == "" {
-		return fmt.Errorf("CPF do aluno é obrigatório")
+	
+	// 2. Turmas ativas antes da edição: as que saírem liberam vaga para a lista de espera
+	matriculas, err := s.repo.GetMatriculas(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	// Verifica responsável financeiro
-	hasPrincipal := false
-	for _, g := range payload.Guardians {
-		if g.IsPrincipal {
-			hasPrincipal = true
-			break
-		}
+	// Turma com matrícula trancada não entra como matrícula nova: seria uma segunda matrícula paralela à trancada
+	if err := verificarTrancadas(payload.Courses, matriculas); err != nil {
+		return err
 	}
-	if !hasPrincipal {
-		return fmt.Errorf("é obrigatório ter pelo menos um responsável financeiro")
+
+	// 3. Grade: turmas novas contra as mantidas e o turno; se o turno mudou, todas as turmas contra o novo turno
+	if err := s.verificarGradeEdicao(ctx, id, payload, matriculas); err != nil {
+		return err
 	}
-	
-	// 2. Log e Chamada ao Repositório
+
+	// 4. Log e Chamada ao Repositório
 	s.logger.Infof("Atualizando matrícula ID %d: %s", id, payload.Student.FullName)
-	return s.repo.UpdateEnrollment(ctx, id, payload)
-}
+	if err := s.repo.UpdateEnrollment(ctx, id, payload, userID); err != nil {
+		return err
+	}
 
-func (s *EnrollmentService) CreateEnrollment(ctx context.Context, payload model.NewEnrollmentPayload) (int, error) {
-	// Validações de negócio
-	if payload.Student.FullName == "" {
-		return 0, fmt.Errorf("nome do aluno é obrigatório")
+	mantidas := make(map[int]bool, len(payload.Courses))
+	for _, c := range payload.Courses {
+		classID, _ := strconv.Atoi(c.ClassID)
+		mantidas[classID] = true
 	}
-	if payload.Student.CPF == "" {
-		return 0, fmt.Errorf("CPF do aluno é obrigatório")
+	liberadas := []int{}
+	for _, m := range matriculas {
+		if m.Status == model.StatusMatriculaAtivo && !mantidas[m.TurmaID] {
+			liberadas = append(liberadas, m.TurmaID)
+		}
 	}
+	s.ofertarVagas(ctx, liberadas)
+	return nil
+}
 
-	// Verifica se existe pelo menos 1 responsável principal
-	hasPrincipal := false
-	for _, g := range payload.Guardians {
-		if g.IsPrincipal {
-			hasPrincipal = true
-			break
+// verificarTrancadas recusa as turmas do payload cuja matrícula mais recente do aluno está TRANCADO
+func verificarTrancadas(courses []model.CourseEnrollmentPayload, matriculas []model.Matricula) error {
+	ultima := make(map[int]model.Matricula, len(matriculas))
+	for _, m := range matriculas {
+		if atual, ok := ultima[m.TurmaID]; !ok || m.ID > atual.ID {
+			ultima[m.TurmaID] = m
+		}
+	}
+	for _, id := range turmasDoPayload(courses) {
+		if m, ok := ultima[id]; ok && m.Status == model.StatusMatriculaTrancado {
+			return fmt.Errorf("%w: reabra a matrícula %d na turma %d pela mudança de status", ErrMatriculaTrancada, m.ID, id)
 		}
 	}
-	if !hasPrincipal {
-		return 0, fmt.Errorf("é obrigatório ter pelo menos um responsável financeiro")
+	return nil
+}
+
+func (s *EnrollmentService) CreateEnrollment(ctx context.Context, payload model.NewEnrollmentPayload, userID int) (int, error) {
+	// Validações de negócio
+	if err := validateEnrollment(&payload); err != nil {
+		return 0, err
+	}
+
+	// Grade: turmas escolhidas entre si e contra o turno escolar
+	if err := s.verificarGrade(ctx, turmasDoPayload(payload.Courses), nil, payload.Student.SchoolShift); err != nil {
+		return 0, err
 	}
 
 	s.logger.Infof("Processando matrícula para: %s", payload.Student.FullName)
 
-	id, err := s.repo.CreateEnrollment(ctx, payload)
+	id, err := s.repo.CreateEnrollment(ctx, payload, userID)
 	if err != nil {
 		s.logger.Error("Erro na persistência da matrícula", err)
 		return 0, err
@@ -97,11 +296,54 @@ func (s *EnrollmentService) GetCourseData(ctx context.Context) (map[string]inter
 	return s.repo.GetInitialCourseData(ctx)
 }
 
-// GetAvailableCourses chama o repositório para buscar cursos compatíveis com o turno
-func (s *EnrollmentService) GetAvailableCourses(ctx context.Context, schoolShift string) ([]model.CourseOption, error) {
-	return s.repo.GetAvailableCourses(ctx, schoolShift)
+// GetAvailableCourses chama o repositório para buscar cursos compatíveis com o turno e, se informado o aluno
+// (studentID > 0), sem conflito de horário com as turmas em que ele já está ativo
+func (s *EnrollmentService) GetAvailableCourses(ctx context.Context, schoolShift string, studentID int) ([]model.CourseOption, error) {
+	return s.repo.GetAvailableCourses(ctx, s.janelasTurno(schoolShift), studentID)
+}
+
+func (s *EnrollmentService) GetGuardianByCPF(ctx context.Context, guardianCPF string) (*model.Guardian, error) {
+	if !cpf.IsValid(guardianCPF) {
+		return nil, ErrCPFInvalido
+	}
+	return s.repo.GetGuardianByCPF(ctx, cpf.Normalize(guardianCPF))
+}
+
+// validateEnrollment aplica as regras de negócio comuns a criação, edição e importação de matrículas
+func validateEnrollment(payload *model.NewEnrollmentPayload) error {
+	if payload.Student.FullName == "" {
+		return fmt.Errorf("nome do aluno é obrigatório")
+	}
+	if payload.Student.CPF == "" {
+		return fmt.Errorf("CPF do aluno é obrigatório")
+	}
+	if err := normalizeCPFs(payload); err != nil {
+		return err
+	}
+
+	// Verifica se existe pelo menos 1 responsável principal (financeiro)
+	for _, g := range payload.Guardians {
+		if g.IsPrincipal {
+			return nil
+		}
+	}
+	return fmt.Errorf("é obrigatório ter pelo menos um responsável financeiro")
 }
 
-func (s *EnrollmentService) GetGuardianByCPF(ctx context.Context, cpf string) (*model.Guardian, error) {
-	return s.repo.GetGuardianByCPF(ctx, cpf)
+// normalizeCPFs valida e converte para a forma canônica (apenas dígitos) os CPFs do aluno e dos responsáveis,
+// garantindo que "123.456.789-09" e "12345678909" sejam tratados como a mesma pessoa
+func normalizeCPFs(payload *model.NewEnrollmentPayload) error {
+	if !cpf.IsValid(payload.Student.CPF) {
+		return fmt.Errorf("%w: aluno", ErrCPFInvalido)
+	}
+	payload.Student.CPF = cpf.Normalize(payload.Student.CPF)
+
+	for i := range payload.Guardians {
+		if !cpf.IsValid(payload.Guardians[i].CPF) {
+			return fmt.Errorf("%w: responsável %s", ErrCPFInvalido, payload.Guardians[i].FullName)
+		}
+		payload.Guardians[i].CPF = cpf.Normalize(payload.Guardians[i].CPF)
+	}
+
+	return nil
 }
\ No newline at end of file
@@ -6,6 +6,7 @@ import (
 
 	"sysocial/internal/file/model"
 	"sysocial/internal/file/service"
+	"sysocial/internal/shared/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -21,7 +22,7 @@ type FileHandler struct {
 func NewFileHandler(fileService service.FileService) *FileHandler {
 	return &FileHandler{
 		fileService: fileService,
-		validator:   validator.New(),
+		validator:   validation.New(),
 	}
 }
 
@@ -29,13 +30,13 @@ func NewFileHandler(fileService service.FileService) *FileHandler {
 func (h *FileHandler) UploadFile(c *gin.Context) {
 	var req model.UploadRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
 	// Validar dados
 	if err := h.validator.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados de validação inválidos", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, validation.Response(err))
 		return
 	}
 
@@ -7,10 +7,15 @@ import (
 
 // Config contém todas as configurações da aplicação
 type Config struct {
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Redis    RedisConfig
-	Log      LogConfig
+	Database    DatabaseConfig
+	JWT         JWTConfig
+	Redis       RedisConfig
+	Log         LogConfig
+	Instituicao InstituicaoConfig
+	Frequencia  FrequenciaConfig
+	Chamada     ChamadaConfig
+	ListaEspera ListaEsperaConfig
+	Turno       TurnoConfig
 }
 
 // DatabaseConfig configurações do banco de dados
@@ -42,6 +47,43 @@ type LogConfig struct {
 	Format string
 }
 
+// InstituicaoConfig dados impressos no cabeçalho dos relatórios em PDF
+type InstituicaoConfig struct {
+	Nome     string
+	Endereco string
+	Logo     string // Caminho de uma imagem PNG/JPG (opcional)
+}
+
+// FrequenciaConfig critérios padrão do relatório de risco de evasão (podem ser sobrescritos por consulta)
+type FrequenciaConfig struct {
+	Minima             int // Percentual mínimo de frequência
+	FaltasConsecutivas int // Faltas seguidas que colocam o aluno em risco
+	MinimoAulas        int // Aulas registradas necessárias para avaliar o percentual
+}
+
+// ChamadaConfig janela de edição das chamadas: cada mês pode ser lançado/editado até DiasEdicao dias após
+// o seu fim, a menos que seja fechado antes; reaberturas valem por DiasReabertura dias se não informado o prazo
+type ChamadaConfig struct {
+	DiasEdicao     int
+	DiasReabertura int
+}
+
+// ListaEsperaConfig prazo para o aluno responder à oferta de vaga da lista de espera e intervalo da varredura que
+// expira as ofertas vencidas e oferece as vagas livres ao próximo da fila
+type ListaEsperaConfig struct {
+	HorasOferta      int
+	MinutosVarredura int
+}
+
+// TurnoConfig horários (HH:MM) do turno escolar, de segunda a sexta, em que o aluno não pode ter aula nas turmas;
+// o turno integral ocupa as duas janelas
+type TurnoConfig struct {
+	ManhaInicio string
+	ManhaFim    string
+	TardeInicio string
+	TardeFim    string
+}
+
 // Load carrega as configurações das variáveis de ambiente
 func Load() *Config {
 	return &Config{
@@ -66,6 +108,30 @@ func Load() *Config {
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "text"),
 		},
+		Instituicao: InstituicaoConfig{
+			Nome:     getEnv("INSTITUICAO_NOME", "SYSOCIAL"),
+			Endereco: getEnv("INSTITUICAO_ENDERECO", ""),
+			Logo:     getEnv("INSTITUICAO_LOGO", ""),
+		},
+		Frequencia: FrequenciaConfig{
+			Minima:             getEnvAsPositiveInt("FREQUENCIA_MINIMA", 75),
+			FaltasConsecutivas: getEnvAsPositiveInt("FREQUENCIA_FALTAS_CONSECUTIVAS", 3),
+			MinimoAulas:        getEnvAsPositiveInt("FREQUENCIA_MINIMO_AULAS", 4),
+		},
+		Chamada: ChamadaConfig{
+			DiasEdicao:     getEnvAsInt("CHAMADA_DIAS_EDICAO", 10),
+			DiasReabertura: getEnvAsInt("CHAMADA_DIAS_REABERTURA", 7),
+		},
+		ListaEspera: ListaEsperaConfig{
+			HorasOferta:      getEnvAsInt("LISTA_ESPERA_HORAS_OFERTA", 48),
+			MinutosVarredura: getEnvAsInt("LISTA_ESPERA_MINUTOS_VARREDURA", 15),
+		},
+		Turno: TurnoConfig{
+			ManhaInicio: getEnv("TURNO_MANHA_INICIO", "07:00"),
+			ManhaFim:    getEnv("TURNO_MANHA_FIM", "12:00"),
+			TardeInicio: getEnv("TURNO_TARDE_INICIO", "13:00"),
+			TardeFim:    getEnv("TURNO_TARDE_FIM", "17:30"),
+		},
 	}
 }
 
@@ -86,3 +152,11 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsPositiveInt é getEnvAsInt para critérios que não admitem zero ou negativo (voltam ao padrão)
+func getEnvAsPositiveInt(key string, defaultValue int) int {
+	if value := getEnvAsInt(key, defaultValue); value > 0 {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,61 @@
+package cpf
+
+import "strings"
+
+// Normalize retorna apenas os dígitos do CPF (forma canônica usada no banco)
+func Normalize(cpf string) string {
+	var b strings.Builder
+	for _, r := range cpf {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// IsValid verifica os dígitos verificadores de um CPF (aceita com ou sem máscara)
+func IsValid(cpf string) bool {
+	digits := Normalize(cpf)
+	if len(digits) != 11 {
+		return false
+	}
+
+	// Sequências repetidas (000.000.000-00, 111...) passam no cálculo mas são inválidas
+	if strings.Count(digits, digits[:1]) == 11 {
+		return false
+	}
+
+	for _, size := range []int{9, 10} {
+		sum := 0
+		for i := 0; i < size; i++ {
+			sum += int(digits[i]-'0') * (size + 1 - i)
+		}
+		dv := (sum * 10) % 11
+		if dv == 10 {
+			dv = 0
+		}
+		if dv != int(digits[size]-'0') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Format aplica a máscara 000.000.000-00 a um CPF completo
+func Format(cpf string) string {
+	digits := Normalize(cpf)
+	if len(digits) != 11 {
+		return cpf
+	}
+	return digits[:3] + "." + digits[3:6] + "." + digits[6:9] + "-" + digits[9:]
+}
+
+// Mask oculta os três primeiros e os dois últimos dígitos (***.456.789-**), padrão usado em listagens
+func Mask(cpf string) string {
+	digits := Normalize(cpf)
+	if len(digits) != 11 {
+		return "***.***.***-**"
+	}
+	return "***." + digits[3:6] + "." + digits[6:9] + "-**"
+}
@@ -0,0 +1,57 @@
+package cpf
+
+import "testing"
+
+func TestIsValid(t *testing.T) {
+	tests := []struct {
+		name string
+		cpf  string
+		want bool
+	}{
+		{"válido sem máscara", "52998224725", true},
+		{"válido com máscara", "529.982.247-25", true},
+		{"válido com DV 0 (resto 10)", "123.456.789-09", true},
+		{"outro válido", "111.444.777-35", true},
+		{"primeiro DV errado", "52998224735", false},
+		{"segundo DV errado", "52998224724", false},
+		{"sequência repetida", "111.111.111-11", false},
+		{"zeros", "00000000000", false},
+		{"curto", "5299822472", false},
+		{"longo", "529982247250", false},
+		{"vazio", "", false},
+		{"letras", "abc.def.ghi-jk", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsValid(tt.cpf); got != tt.want {
+				t.Errorf("IsValid(%q) = %v, want %v", tt.cpf, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeFormatMask(t *testing.T) {
+	tests := []struct {
+		in        string
+		normalize string
+		format    string
+		mask      string
+	}{
+		{"529.982.247-25", "52998224725", "529.982.247-25", "***.982.247-**"},
+		{"52998224725", "52998224725", "529.982.247-25", "***.982.247-**"},
+		{"123", "123", "123", "***.***.***-**"},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.normalize {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.normalize)
+		}
+		if got := Format(tt.in); got != tt.format {
+			t.Errorf("Format(%q) = %q, want %q", tt.in, got, tt.format)
+		}
+		if got := Mask(tt.in); got != tt.mask {
+			t.Errorf("Mask(%q) = %q, want %q", tt.in, got, tt.mask)
+		}
+	}
+}
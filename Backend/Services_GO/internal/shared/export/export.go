@@ -0,0 +1,179 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Formatos de exportação suportados
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// utf8BOM faz o Excel reconhecer o CSV como UTF-8 (acentos corretos ao abrir com duplo clique)
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Table é uma planilha genérica: cabeçalho e linhas já formatadas como texto
+type Table struct {
+	Sheet   string
+	Headers []string
+	Rows    [][]string
+}
+
+// ContentType retorna o Content-Type HTTP do formato
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FileName monta o nome do arquivo com a data de geração (ex: alunos_20250301.csv)
+func FileName(prefix, format string) string {
+	return fmt.Sprintf("%s_%s.%s", prefix, time.Now().Format("20060102"), format)
+}
+
+// Write grava a tabela no formato pedido; CSV usa o delimitador informado
+func Write(w io.Writer, format string, table Table, delimiter rune) error {
+	if format == FormatXLSX {
+		return WriteXLSX(w, table)
+	}
+	return WriteCSV(w, table, delimiter)
+}
+
+// WriteCSV grava a tabela como CSV com BOM UTF-8, linha a linha
+func WriteCSV(w io.Writer, table Table, delimiter rune) error {
+	if _, err := w.Write(utf8BOM); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if delimiter != 0 {
+		writer.Comma = delimiter
+	}
+
+	if err := writer.Write(table.Headers); err != nil {
+		return err
+	}
+	for _, row := range table.Rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = SanitizeCell(value)
+		}
+		if err := writer.Write(cells); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteXLSX grava a tabela como planilha XLSX (cabeçalho em negrito e congelado)
+func WriteXLSX(w io.Writer, table Table) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := table.Sheet
+	if sheet == "" {
+		sheet = "Planilha1"
+	}
+	// Nomes de aba são limitados a 31 caracteres pelo Excel
+	if len([]rune(sheet)) > 31 {
+		sheet = string([]rune(sheet)[:31])
+	}
+	if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+		return err
+	}
+
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	boldID, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	if err := stream.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(table.Headers))
+	for i, h := range table.Headers {
+		header[i] = excelize.Cell{StyleID: boldID, Value: h}
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	for i, row := range table.Rows {
+		cells := make([]interface{}, len(row))
+		for j, value := range row {
+			cells[j] = SanitizeCell(value)
+		}
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+		if err := stream.SetRow(cell, cells); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.Flush(); err != nil {
+		return err
+	}
+
+	_, err = f.WriteTo(w)
+	return err
+}
+
+// SanitizeCell neutraliza valores que a planilha interpretaria como fórmula (CSV injection): textos começando com
+// =, @, tab ou CR ganham um apóstrofo na frente. Com + ou - só quando podem ser fórmula: o sinal sozinho (ex: a
+// marca "-" das exportações) e números simples ("-5", "+3", "-2,5") ficam como estão.
+func SanitizeCell(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '@', '\t', '\r':
+		return "'" + value
+	case '+', '-':
+		if len(value) == 1 || isNumber(value) {
+			return value
+		}
+		return "'" + value
+	}
+	return value
+}
+
+// isNumber indica um número simples com sinal, com ponto ou vírgula decimal
+func isNumber(value string) bool {
+	_, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	return err == nil && !strings.ContainsAny(value, "xXpPiInN")
+}
+
+// ParseDelimiter converte o parâmetro de delimitador ("," ";" "tab" "|") em rune; vazio usa o padrão
+func ParseDelimiter(value string, fallback rune) (rune, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return fallback, nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+
+	runes := []rune(value)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' {
+		return 0, fmt.Errorf("delimitador inválido: %q", value)
+	}
+	return runes[0], nil
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeCell(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"vazio", "", ""},
+		{"texto", "Ana", "Ana"},
+		{"marca fora da matrícula", "-", "-"},
+		{"sinal de mais sozinho", "+", "+"},
+		{"número negativo", "-5", "-5"},
+		{"número com sinal", "+3", "+3"},
+		{"decimal com vírgula", "-2,5", "-2,5"},
+		{"decimal com ponto", "-2.5", "-2.5"},
+		{"fórmula com =", "=SUM(A1:A2)", "'=SUM(A1:A2)"},
+		{"fórmula com @", "@SUM(A1)", "'@SUM(A1)"},
+		{"fórmula com +", "+cmd|' /C calc'!A0", "'+cmd|' /C calc'!A0"},
+		{"fórmula com -", "-2+3", "'-2+3"},
+		{"texto com -", "-Ana", "'-Ana"},
+		{"infinito não é número", "-Inf", "'-Inf"},
+		{"hexadecimal não é número", "-0x1p3", "'-0x1p3"},
+		{"tab", "\tA", "'\tA"},
+		{"CR", "\rA", "'\rA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeCell(tt.value); got != tt.want {
+				t.Errorf("SanitizeCell(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSVMantemMarca(t *testing.T) {
+	table := Table{Headers: []string{"Aluno", "01/03"}, Rows: [][]string{{"Ana", "-"}, {"Bia", "=1+1"}}}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, table, ';'); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.TrimPrefix(buf.String(), string(utf8BOM))
+	want := "Aluno;01/03\nAna;-\nBia;'=1+1\n"
+	if got != want {
+		t.Errorf("WriteCSV = %q, want %q", got, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +14,19 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Headers usados pelo API Gateway para repassar a identidade do usuário autenticado
+const (
+	HeaderUserID   = "X-User-ID"
+	HeaderUserTipo = "X-User-Tipo"
+)
+
+// Tipos de usuário (usuarios.tipo)
+const (
+	TipoAdmin     = "A" // Administrador
+	TipoUsuario   = "U" // Regular/Operador
+	TipoProfessor = "P" // Professor
+)
+
 // Logger middleware para logging de requisições
 func Logger() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -63,7 +77,8 @@ func CORS() gin.HandlerFunc {
 		// Permitir qualquer origem
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "Idempotent-Replayed")
 		c.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
@@ -122,6 +137,10 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
+		// Descartar headers de identidade enviados pelo cliente (só o gateway pode defini-los)
+		c.Request.Header.Del(HeaderUserID)
+		c.Request.Header.Del(HeaderUserTipo)
+
 		// Verificar se é uma rota pública
 		publicRoutes := []string{
 			"/health",
@@ -176,10 +195,67 @@ func Auth() gin.HandlerFunc {
 		c.Set("email", claims.Email)
 		c.Set("tipo", claims.Tipo)
 
+		// Repassar a identidade validada aos serviços internos via headers
+		c.Request.Header.Set(HeaderUserID, strconv.Itoa(claims.UserID))
+		c.Request.Header.Set(HeaderUserTipo, claims.Tipo)
+
+		c.Next()
+	}
+}
+
+// Identity middleware para os serviços internos: lê a identidade repassada pelo API Gateway
+func Identity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID, err := strconv.Atoi(c.GetHeader(HeaderUserID)); err == nil {
+			c.Set("user_id", userID)
+		}
+		if tipo := c.GetHeader(HeaderUserTipo); tipo != "" {
+			c.Set("tipo", tipo)
+		}
+
 		c.Next()
 	}
 }
 
+// UserID retorna o ID do usuário autenticado (0 se não houver identidade)
+func UserID(c *gin.Context) int {
+	return c.GetInt("user_id")
+}
+
+// UserTipo retorna o tipo do usuário autenticado ("" se não houver identidade)
+func UserTipo(c *gin.Context) string {
+	return c.GetString("tipo")
+}
+
+// IsAdmin indica se o usuário autenticado é administrador
+func IsAdmin(c *gin.Context) bool {
+	return UserTipo(c) == TipoAdmin
+}
+
+// CanViewPersonalData indica se o usuário pode ver dados pessoais completos (CPF etc.)
+func CanViewPersonalData(c *gin.Context) bool {
+	tipo := UserTipo(c)
+	return tipo == TipoAdmin || tipo == TipoUsuario
+}
+
+// RequireTipo restringe a rota aos tipos de usuário informados (ex: TipoAdmin, TipoUsuario)
+func RequireTipo(tipos ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tipo := UserTipo(c)
+		for _, t := range tipos {
+			if t == tipo {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Acesso negado para este perfil de usuário",
+		})
+		c.Abort()
+	}
+}
+
 // RequestID middleware para adicionar ID único às requisições
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,157 @@
+// Package vagas mantém as vagas dos cursos derivadas das turmas. A capacidade de verdade é turma.vagas_turma;
+// curso.vagas_totais é a soma das turmas do curso e curso.vagas_restantes desconta a ocupação das turmas (OcupacaoTurma).
+// As contagens são sempre recalculadas a partir das matrículas (nunca somadas/subtraídas), então não acumulam erro.
+package vagas
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Execer é o que Recalcular precisa: *sql.DB ou *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// OcupacaoTurma conta o que ocupa as vagas da turma t: as matrículas ATIVO e as ofertas da lista de espera ainda no
+// prazo (a vaga fica reservada para o aluno até ele responder). Vale para a turma e para o curso.
+const OcupacaoTurma = `(
+	(SELECT COUNT(*) FROM matricula m WHERE m.turmas_id_turma = t.id_turma AND m.status = 'ATIVO') +
+	(SELECT COUNT(*) FROM lista_espera e WHERE e.turma_id_turma = t.id_turma AND e.status = 'OFERTADA' AND e.oferta_expira_em > now()))`
+
+// cursoVagasSQL calcula, por curso, as vagas derivadas das turmas e da ocupação delas; %s filtra os cursos (alias c)
+const cursoVagasSQL = `
+	SELECT c.id_curso,
+	       COALESCE(SUM(t.vagas_turma), 0) AS totais,
+	       COALESCE(SUM(t.vagas_turma), 0) - COALESCE(SUM(o.ocupadas), 0) AS restantes
+	FROM curso c
+	LEFT JOIN turma t ON t.cursos_id_curso = c.id_curso
+	LEFT JOIN LATERAL (SELECT ` + OcupacaoTurma + ` AS ocupadas) o ON true
+	WHERE %s
+	GROUP BY c.id_curso`
+
+// Divergencia é um curso cujas vagas gravadas não batem com as derivadas das turmas
+type Divergencia struct {
+	CursoID                 int
+	Nome                    string
+	VagasTotais             int
+	VagasRestantes          int
+	VagasTotaisDerivadas    int
+	VagasRestantesDerivadas int
+}
+
+// TurmaLotada é uma turma com mais matrículas ATIVO do que vagas (dados anteriores ao controle por turma)
+type TurmaLotada struct {
+	TurmaID  int
+	Nome     string
+	Vagas    int
+	Ocupadas int
+}
+
+// RecalcularPorTurmas recalcula as vagas dos cursos das turmas informadas
+func RecalcularPorTurmas(ctx context.Context, db Execer, turmaIDs []int) error {
+	if len(turmaIDs) == 0 {
+		return nil
+	}
+	return recalcular(ctx, db, `c.id_curso IN (SELECT cursos_id_curso FROM turma WHERE id_turma = ANY($1))`, pq.Array(turmaIDs))
+}
+
+// RecalcularCursos recalcula as vagas dos cursos informados
+func RecalcularCursos(ctx context.Context, db Execer, cursoIDs []int) error {
+	if len(cursoIDs) == 0 {
+		return nil
+	}
+	return recalcular(ctx, db, `c.id_curso = ANY($1)`, pq.Array(cursoIDs))
+}
+
+// RecalcularTodos recalcula as vagas de todos os cursos (reconciliação); devolve quantos cursos mudaram
+func RecalcularTodos(ctx context.Context, db Execer) (int64, error) {
+	query := `
+		UPDATE curso SET vagas_totais = v.totais, vagas_restantes = v.restantes
+		FROM (` + fmt.Sprintf(cursoVagasSQL, "true") + `) v
+		WHERE curso.id_curso = v.id_curso
+		  AND (curso.vagas_totais <> v.totais OR curso.vagas_restantes <> v.restantes)`
+
+	result, err := db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao recalcular vagas dos cursos: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListarDivergencias lista os cursos com vagas gravadas diferentes das derivadas das turmas
+func ListarDivergencias(ctx context.Context, db *sql.DB) ([]Divergencia, error) {
+	query := `
+		SELECT c.id_curso, c.nome, c.vagas_totais, c.vagas_restantes, v.totais, v.restantes
+		FROM curso c
+		INNER JOIN (` + fmt.Sprintf(cursoVagasSQL, "true") + `) v ON v.id_curso = c.id_curso
+		WHERE c.vagas_totais <> v.totais OR c.vagas_restantes <> v.restantes
+		ORDER BY c.nome`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao comparar vagas dos cursos: %w", err)
+	}
+	defer rows.Close()
+
+	divergencias := []Divergencia{}
+	for rows.Next() {
+		var d Divergencia
+		if err := rows.Scan(&d.CursoID, &d.Nome, &d.VagasTotais, &d.VagasRestantes, &d.VagasTotaisDerivadas, &d.VagasRestantesDerivadas); err != nil {
+			return nil, fmt.Errorf("erro ao escanear vagas do curso: %w", err)
+		}
+		divergencias = append(divergencias, d)
+	}
+	return divergencias, rows.Err()
+}
+
+// ListarTurmasLotadas lista as turmas com mais matrículas ATIVO do que vagas
+func ListarTurmasLotadas(ctx context.Context, db *sql.DB) ([]TurmaLotada, error) {
+	query := `
+		SELECT t.id_turma, t.nome_turma, t.vagas_turma, COUNT(m.id_matricula)
+		FROM turma t
+		INNER JOIN matricula m ON m.turmas_id_turma = t.id_turma AND m.status = 'ATIVO'
+		GROUP BY t.id_turma, t.nome_turma, t.vagas_turma
+		HAVING COUNT(m.id_matricula) > t.vagas_turma
+		ORDER BY t.nome_turma`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar ocupação das turmas: %w", err)
+	}
+	defer rows.Close()
+
+	turmas := []TurmaLotada{}
+	for rows.Next() {
+		var t TurmaLotada
+		if err := rows.Scan(&t.TurmaID, &t.Nome, &t.Vagas, &t.Ocupadas); err != nil {
+			return nil, fmt.Errorf("erro ao escanear ocupação da turma: %w", err)
+		}
+		turmas = append(turmas, t)
+	}
+	return turmas, rows.Err()
+}
+
+// recalcular grava as vagas derivadas nos cursos que atendem ao filtro (sobre o alias c). Os cursos são travados
+// antes (em ordem de ID, sem deadlock entre transações): o UPDATE só começa depois que as outras matrículas nos mesmos
+// cursos terminam e, no READ COMMITTED, agrega já vendo as turmas e matrículas que elas gravaram.
+// Deve rodar dentro da transação que alterou as matrículas.
+func recalcular(ctx context.Context, db Execer, filtro string, args ...interface{}) error {
+	lock := `SELECT c.id_curso FROM curso c WHERE ` + filtro + ` ORDER BY c.id_curso FOR UPDATE`
+	if _, err := db.ExecContext(ctx, lock, args...); err != nil {
+		return fmt.Errorf("erro ao travar cursos para recalcular vagas: %w", err)
+	}
+
+	query := `
+		UPDATE curso SET vagas_totais = v.totais, vagas_restantes = v.restantes
+		FROM (` + fmt.Sprintf(cursoVagasSQL, filtro) + `) v
+		WHERE curso.id_curso = v.id_curso`
+
+	if _, err := db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("erro ao recalcular vagas dos cursos: %w", err)
+	}
+	return nil
+}
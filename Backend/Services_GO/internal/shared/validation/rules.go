@@ -0,0 +1,128 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"sysocial/internal/shared/cpf"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// rule associa uma tag de validação à sua função e mensagem em pt-BR
+type rule struct {
+	tag     string
+	fn      validator.Func
+	message string
+}
+
+var rules = []rule{
+	{"cpf", validateCPF, "{0} deve ser um CPF válido"},
+	{"cep", validateCEP, "{0} deve ser um CEP válido (00000-000)"},
+	{"telefone", validateTelefone, "{0} deve ser um telefone válido com DDD"},
+	{"data", validateData, "{0} deve ser uma data válida no formato AAAA-MM-DD"},
+	{"hora", validateHora, "{0} deve ser um horário válido no formato HH:MM"},
+	{"diasemana", validateDiaSemana, "{0} deve ser um dia da semana válido (ex: Segunda-feira)"},
+}
+
+var (
+	cepRegex  = regexp.MustCompile(`^\d{5}-?\d{3}$`)
+	horaRegex = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d(:[0-5]\d)?$`)
+)
+
+// diasSemana mapeia os nomes normalizados (sem acento, minúsculos) para time.Weekday
+var diasSemana = map[string]time.Weekday{
+	"domingo": time.Sunday,
+	"segunda": time.Monday,
+	"terca":   time.Tuesday,
+	"quarta":  time.Wednesday,
+	"quinta":  time.Thursday,
+	"sexta":   time.Friday,
+	"sabado":  time.Saturday,
+}
+
+// nomesDiasSemana nome canônico de cada time.Weekday (domingo = 0)
+var nomesDiasSemana = [...]string{"Domingo", "Segunda-feira", "Terça-feira", "Quarta-feira", "Quinta-feira", "Sexta-feira", "Sábado"}
+
+// OnlyDigits remove todos os caracteres que não são dígitos
+func OnlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// IsValidTelefone verifica se o número é um telefone brasileiro (fixo ou celular) com DDD
+func IsValidTelefone(phone string) bool {
+	digits := OnlyDigits(phone)
+	if len(digits) >= 12 && strings.HasPrefix(digits, "55") {
+		digits = digits[2:]
+	}
+
+	if len(digits) != 10 && len(digits) != 11 {
+		return false
+	}
+
+	// DDDs válidos vão de 11 a 99 (nenhum começa com 0 ou termina em 0)
+	if digits[0] == '0' || digits[1] == '0' {
+		return false
+	}
+
+	// Celular (11 dígitos) começa com 9; fixo (10 dígitos) começa de 2 a 5
+	if len(digits) == 11 {
+		return digits[2] == '9'
+	}
+	return digits[2] >= '2' && digits[2] <= '5'
+}
+
+// ParseDiaSemana converte o nome de um dia da semana em português para time.Weekday.
+// Aceita variações como "Segunda-feira", "segunda feira", "Segunda", "Terça" ou "Terca".
+func ParseDiaSemana(dia string) (time.Weekday, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(dia))
+	normalized = strings.NewReplacer("ç", "c", "á", "a", "à", "a", "ã", "a").Replace(normalized)
+	normalized = strings.TrimSuffix(normalized, "feira")
+	normalized = strings.TrimRight(normalized, "- ")
+
+	weekday, ok := diasSemana[normalized]
+	return weekday, ok
+}
+
+// NomeDiaSemana retorna o nome canônico em português de um dia da semana (ex: "Segunda-feira")
+func NomeDiaSemana(dia time.Weekday) string {
+	return nomesDiasSemana[dia]
+}
+
+// ========== FUNÇÕES DE VALIDAÇÃO ==========
+
+func validateCPF(fl validator.FieldLevel) bool {
+	return cpf.IsValid(fl.Field().String())
+}
+
+func validateCEP(fl validator.FieldLevel) bool {
+	return cepRegex.MatchString(strings.TrimSpace(fl.Field().String()))
+}
+
+func validateTelefone(fl validator.FieldLevel) bool {
+	return IsValidTelefone(fl.Field().String())
+}
+
+func validateData(fl validator.FieldLevel) bool {
+	_, err := time.Parse("2006-01-02", fl.Field().String())
+	return err == nil
+}
+
+// validateHora aceita string vazia para permitir limpar o horário em updates (*string);
+// combine com required quando o horário for obrigatório
+func validateHora(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	return value == "" || horaRegex.MatchString(value)
+}
+
+func validateDiaSemana(fl validator.FieldLevel) bool {
+	_, ok := ParseDiaSemana(fl.Field().String())
+	return ok
+}
@@ -0,0 +1,171 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	ptBRTranslations "github.com/go-playground/validator/v10/translations/pt_BR"
+)
+
+// FieldError representa um erro de validação de um campo específico
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+}
+
+var (
+	translator ut.Translator
+	once       sync.Once
+)
+
+// getTranslator retorna o tradutor pt-BR compartilhado
+func getTranslator() ut.Translator {
+	once.Do(func() {
+		locale := pt_BR.New()
+		uni := ut.New(locale, locale)
+		translator, _ = uni.GetTranslator("pt_BR")
+	})
+	return translator
+}
+
+// New cria um validator que lê a tag `validate`, já com regras customizadas e mensagens em pt-BR
+func New() *validator.Validate {
+	v := validator.New()
+	Register(v)
+	return v
+}
+
+// NewBinding cria um validator que lê a tag `binding`, como o do gin, para validar payloads fora dos handlers
+// (ex: linhas de uma importação) sem depender do framework HTTP
+func NewBinding() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	Register(v)
+	return v
+}
+
+// RegisterGin configura o validator usado pelo gin (tag `binding`) com as regras e traduções
+func RegisterGin() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		Register(v)
+	}
+}
+
+// Register adiciona as regras customizadas e as traduções pt-BR a um validator existente
+func Register(v *validator.Validate) {
+	trans := getTranslator()
+
+	// Usar o nome do campo no JSON/query em vez do nome da struct Go
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	_ = ptBRTranslations.RegisterDefaultTranslations(v, trans)
+
+	for _, rule := range rules {
+		_ = v.RegisterValidation(rule.tag, rule.fn)
+		registerTranslation(v, trans, rule.tag, rule.message)
+	}
+}
+
+// registerTranslation registra a mensagem pt-BR de uma regra customizada
+func registerTranslation(v *validator.Validate, trans ut.Translator, tag, message string) {
+	_ = v.RegisterTranslation(tag, trans,
+		func(ut ut.Translator) error {
+			return ut.Add(tag, message, true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			t, err := ut.T(tag, fe.Field())
+			if err != nil {
+				return fe.Error()
+			}
+			return t
+		},
+	)
+}
+
+// Errors converte o erro de binding/validação em uma lista de erros por campo
+func Errors(err error) []FieldError {
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		trans := getTranslator()
+		result := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			result = append(result, FieldError{
+				Field:   fieldPath(fe),
+				Tag:     fe.Tag(),
+				Message: fe.Translate(trans),
+			})
+		}
+		return result
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Tag:     "type",
+			Message: fmt.Sprintf("%s possui um tipo inválido (esperado %s)", typeErr.Field, typeErr.Type.String()),
+		}}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return []FieldError{{Tag: "json", Message: "JSON malformado"}}
+	}
+
+	if errors.Is(err, io.EOF) {
+		return []FieldError{{Tag: "json", Message: "corpo da requisição vazio"}}
+	}
+
+	return []FieldError{{Message: err.Error()}}
+}
+
+// Response monta o corpo padrão de resposta 400 para erros de validação
+func Response(err error) gin.H {
+	fields := Errors(err)
+
+	messages := make([]string, 0, len(fields))
+	for _, f := range fields {
+		messages = append(messages, f.Message)
+	}
+
+	return gin.H{
+		"error":   "Dados inválidos",
+		"details": strings.Join(messages, "; "),
+		"fields":  fields,
+	}
+}
+
+// fieldPath remove o nome da struct raiz do namespace (ex: "student.cpf", "guardians[0].phone")
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if idx := strings.Index(ns, "."); idx >= 0 {
+		return ns[idx+1:]
+	}
+	return fe.Field()
+}
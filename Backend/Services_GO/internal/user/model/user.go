@@ -20,7 +20,7 @@ type User struct {
 type CreateUserRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=20"`
 	Nome     string `json:"nome" validate:"required,min=2,max=50"`
-	Telefone string `json:"telefone" validate:"omitempty,min=10,max=15"`
+	Telefone string `json:"telefone" validate:"omitempty,telefone"`
 	Email    string `json:"email" validate:"required,email"`
 	Senha    string `json:"senha" validate:"required,min=6"`
 	Tipo     string `json:"tipo" validate:"required,oneof=admin user moderator"`
@@ -29,7 +29,7 @@ type CreateUserRequest struct {
 // UpdateUserRequest representa a requisição de atualização de usuário
 type UpdateUserRequest struct {
 	Nome       *string `json:"nome" validate:"omitempty,min=2,max=50"`
-	Telefone   *string `json:"telefone" validate:"omitempty,min=10,max=15"`
+	Telefone   *string `json:"telefone" validate:"omitempty,telefone"`
 	Email      *string `json:"email" validate:"omitempty,email"`
 	Tipo       *string `json:"tipo" validate:"omitempty,oneof=admin user moderator"`
 	TrocaSenha *bool   `json:"troca_senha"`
@@ -0,0 +1,27 @@
+-- BUSCA SEM ACENTOS (unaccent + pg_trgm)
+-- Permite encontrar "João" digitando "Joao" na busca de alunos e na busca unificada (GET /api/v1/search).
+-- Requer permissão para criar extensões no banco.
+
+create extension if not exists unaccent;
+create extension if not exists pg_trgm;
+
+-- unaccent() não é IMMUTABLE e por isso não pode ser usado em índices; este wrapper fixa o dicionário.
+-- As consultas do enrollment-service usam exatamente a expressão public.f_unaccent(lower(coluna)).
+create or replace function public.f_unaccent(text)
+returns text
+language sql
+immutable parallel safe strict
+as $$
+  select public.unaccent('public.unaccent'::regdictionary, $1)
+$$;
+
+-- Índices trigram (atendem LIKE '%termo%' e os operadores de similaridade)
+create index IF not exists aluno_idx_nome_trgm on public.aluno using gin (public.f_unaccent(lower(nome_completo)) gin_trgm_ops);
+
+create index IF not exists aluno_idx_escola_trgm on public.aluno using gin (public.f_unaccent(lower(escola_atual)) gin_trgm_ops);
+
+create index IF not exists responsavel_idx_nome_trgm on public.responsavel using gin (public.f_unaccent(lower(nome_completo)) gin_trgm_ops);
+
+create index IF not exists curso_idx_nome_trgm on public.curso using gin (public.f_unaccent(lower(nome)) gin_trgm_ops);
+
+create index IF not exists turma_idx_nome_trgm on public.turma using gin (public.f_unaccent(lower(nome_turma)) gin_trgm_ops);
@@ -0,0 +1,24 @@
+-- CALENDÁRIO INSTITUCIONAL (chamadas-service)
+-- Feriados e recessos valem para todas as turmas; cancelamentos e aulas extras são de uma turma.
+-- Consultado ao gerar as datas de aula do mês e nos relatórios de frequência.
+
+create table public.calendario_evento (
+  id_evento integer generated always as identity not null,
+  tipo character varying(20) not null,
+  data_inicio date not null,
+  data_fim date not null,
+  turma_id_turma integer null,
+  motivo character varying(200) not null,
+  criado_por integer null,
+  criado_em timestamp without time zone not null default now(),
+  constraint calendario_evento_pk primary key (id_evento),
+  constraint calendario_evento_turma foreign KEY (turma_id_turma) references turma (id_turma) on delete cascade,
+  constraint calendario_evento_usuario foreign KEY (criado_por) references usuarios (id_usuario),
+  constraint calendario_evento_tipo check (tipo in ('FERIADO', 'RECESSO', 'CANCELAMENTO', 'AULA_EXTRA')),
+  constraint calendario_evento_periodo check (data_fim >= data_inicio),
+  constraint calendario_evento_escopo check ((tipo in ('CANCELAMENTO', 'AULA_EXTRA')) = (turma_id_turma is not null))
+) TABLESPACE pg_default;
+
+create index IF not exists calendario_evento_idx_1 on public.calendario_evento using btree (data_inicio, data_fim) TABLESPACE pg_default;
+
+create index IF not exists calendario_evento_idx_2 on public.calendario_evento using btree (turma_id_turma) TABLESPACE pg_default;
@@ -0,0 +1,49 @@
+-- MIGRAÇÃO: UMA CHAMADA POR TURMA E DATA
+-- A tela mensal deixou de criar chamadas ao ser aberta; elas passam a ser abertas explicitamente
+-- (POST /chamadas/sessoes) ou geradas para o período da turma (POST /chamadas/gerar).
+-- Este script unifica as chamadas repetidas criadas por acessos simultâneos e impede novas repetições.
+
+begin;
+
+-- 1. Conferir as chamadas repetidas que serão unificadas (mantém-se a de menor id)
+select turmas_id_turma, data_aula, array_agg(id_chamada order by id_chamada) as chamadas
+from public.chamada
+group by turmas_id_turma, data_aula
+having count(*) > 1;
+
+create temporary table chamada_unificada on commit drop as
+select id_chamada,
+       min(id_chamada) over (partition by turmas_id_turma, data_aula) as id_mantido
+from public.chamada;
+
+-- 2. Presenças: por aluno, prevalece a da chamada mantida; senão, a lançada por último
+delete from public.presenca p
+using (
+  select p.id_presenca,
+         row_number() over (
+           partition by u.id_mantido, p.aluno_id_aluno
+           order by (p.chamada_id_chamada = u.id_mantido) desc, p.id_presenca desc
+         ) as ordem
+  from public.presenca p
+  inner join chamada_unificada u on u.id_chamada = p.chamada_id_chamada
+  where u.id_mantido in (select id_mantido from chamada_unificada where id_chamada <> id_mantido)
+) d
+where p.id_presenca = d.id_presenca
+  and d.ordem > 1;
+
+update public.presenca p
+set chamada_id_chamada = u.id_mantido
+from chamada_unificada u
+where p.chamada_id_chamada = u.id_chamada
+  and u.id_chamada <> u.id_mantido;
+
+delete from public.chamada c
+using chamada_unificada u
+where c.id_chamada = u.id_chamada
+  and u.id_chamada <> u.id_mantido;
+
+-- 3. Garantir uma chamada por turma e data daqui em diante
+alter table public.chamada
+  add constraint chamada_turma_data_key unique (turmas_id_turma, data_aula);
+
+commit;
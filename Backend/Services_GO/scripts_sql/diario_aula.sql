@@ -0,0 +1,28 @@
+-- DIÁRIO DE CLASSE (chamadas-service)
+-- Registro do que foi trabalhado em cada aula: um diário por chamada, editável pelas mesmas regras das presenças
+-- (professor atribuído à turma, a partir do dia da aula e enquanto o mês estiver aberto).
+-- Anexos (planos de aula, fotos, listas de material) são enviados ao file-service com
+-- entidade_pai = 'diario_aula' e id_entidade_pai = id_chamada.
+
+create table public.diario_aula (
+  id_diario integer generated always as identity not null,
+  chamada_id_chamada integer not null,
+  tema character varying(200) not null,
+  conteudo text null,
+  atividades text null,
+  materiais text null,
+  observacoes text null,
+  registrado_por integer null,
+  registrado_em timestamp without time zone not null default now(),
+  atualizado_por integer null,
+  atualizado_em timestamp without time zone not null default now(),
+  constraint diario_aula_pk primary key (id_diario),
+  constraint diario_aula_chamada_key unique (chamada_id_chamada),
+  constraint diario_aula_chamada foreign KEY (chamada_id_chamada) references chamada (id_chamada) on delete cascade,
+  constraint diario_aula_registrado_por foreign KEY (registrado_por) references usuarios (id_usuario),
+  constraint diario_aula_atualizado_por foreign KEY (atualizado_por) references usuarios (id_usuario),
+  constraint diario_aula_tema check (btrim(tema) <> '')
+) TABLESPACE pg_default;
+
+create index IF not exists anexos_idx_diario_aula on public.anexos using btree (id_entidade_pai) TABLESPACE pg_default
+  where entidade_pai = 'diario_aula';
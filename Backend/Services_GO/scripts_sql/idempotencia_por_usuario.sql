@@ -0,0 +1,19 @@
+-- MIGRAÇÃO: IDEMPOTENCY-KEY POR USUÁRIO
+-- A chave passa a valer por usuário: a mesma Idempotency-Key enviada por outro usuário é uma requisição nova e
+-- nunca devolve o resultado guardado de outra pessoa. Chaves sem resultado há mais de alguns minutos (serviço
+-- caiu no meio do lançamento) são reaproveitadas pelo chamadas-service.
+
+begin;
+
+-- As chaves existentes valem por no máximo 24h; descartá-las só faz um reenvio antigo ser executado de novo
+-- (os lançamentos de presença são upserts)
+delete from public.requisicao_idempotente;
+
+alter table public.requisicao_idempotente
+  add column usuario_id integer not null;
+
+alter table public.requisicao_idempotente
+  drop constraint requisicao_idempotente_pk,
+  add constraint requisicao_idempotente_pk primary key (chave, operacao, usuario_id);
+
+commit;
@@ -0,0 +1,10 @@
+-- ÍNDICES DA BUSCA PAGINADA DE ALUNOS (GET /enrollments/students)
+-- Filtro de faixa etária compara data_nascimento diretamente; ordenações por nome e data de matrícula.
+
+create index IF not exists aluno_idx_nascimento on public.aluno using btree (data_nascimento, id_aluno) TABLESPACE pg_default;
+
+create index IF not exists aluno_idx_nome on public.aluno using btree (nome_completo, id_aluno) TABLESPACE pg_default;
+
+create index IF not exists aluno_idx_data_matricula on public.aluno using btree (data_matricula, id_aluno) TABLESPACE pg_default;
+
+create index IF not exists matricula_idx_aluno_status on public.matricula using btree (aluno_id_aluno, status) TABLESPACE pg_default;
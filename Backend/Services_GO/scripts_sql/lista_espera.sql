@@ -0,0 +1,46 @@
+-- LISTA DE ESPERA POR TURMA
+-- Alunos aguardam vaga em uma turma lotada. A fila é ordenada por prioridade (maior primeiro; ex: 1 = vulnerabilidade
+-- social, com o critério em prioridade_motivo) e, na mesma prioridade, pela data de entrada.
+-- Quando uma vaga é liberada (cancelamento, trancamento, transferência, remoção do curso na edição), o
+-- enrollment-service oferece a vaga ao próximo da fila: a entrada passa a OFERTADA e a vaga fica reservada para o
+-- aluno até oferta_expira_em (LISTA_ESPERA_HORAS_OFERTA). Ofertas não respondidas no prazo viram EXPIRADA e a vaga
+-- vai para o seguinte.
+--   AGUARDANDO -> OFERTADA, REMOVIDA
+--   OFERTADA   -> ACEITA (gera a matrícula), RECUSADA, EXPIRADA, REMOVIDA
+-- ACEITA, RECUSADA, EXPIRADA e REMOVIDA são finais.
+
+begin;
+
+create table public.lista_espera (
+  id_espera integer generated always as identity not null,
+  turma_id_turma integer not null,
+  aluno_id_aluno integer not null,
+  prioridade smallint not null default 0,
+  prioridade_motivo text null,
+  observacoes text null,
+  status character varying(20) not null default 'AGUARDANDO',
+  data_entrada timestamp without time zone not null default now(),
+  oferta_em timestamp without time zone null,
+  oferta_expira_em timestamp without time zone null,
+  respondido_em timestamp without time zone null,
+  matricula_id_matricula integer null,
+  registrado_por integer null,
+  constraint lista_espera_pk primary key (id_espera),
+  constraint lista_espera_turma foreign KEY (turma_id_turma) references turma (id_turma) on delete cascade,
+  constraint lista_espera_aluno foreign KEY (aluno_id_aluno) references aluno (id_aluno) on delete cascade,
+  constraint lista_espera_matricula foreign KEY (matricula_id_matricula) references matricula (id_matricula) on delete set null,
+  constraint lista_espera_usuario foreign KEY (registrado_por) references usuarios (id_usuario),
+  constraint lista_espera_status_valido check (status in ('AGUARDANDO', 'OFERTADA', 'ACEITA', 'RECUSADA', 'EXPIRADA', 'REMOVIDA')),
+  constraint lista_espera_prioridade_valida check (prioridade between 0 and 10),
+  constraint lista_espera_oferta check (status <> 'OFERTADA' or oferta_expira_em is not null)
+) TABLESPACE pg_default;
+
+-- Um aluno só aparece uma vez na fila de cada turma
+create unique index IF not exists lista_espera_unica on public.lista_espera using btree (turma_id_turma, aluno_id_aluno)
+where status in ('AGUARDANDO', 'OFERTADA');
+
+create index IF not exists lista_espera_idx_1 on public.lista_espera using btree (turma_id_turma, status, prioridade desc, data_entrada) TABLESPACE pg_default;
+
+create index IF not exists lista_espera_idx_2 on public.lista_espera using btree (aluno_id_aluno) TABLESPACE pg_default;
+
+commit;
@@ -0,0 +1,9 @@
+-- ENCERRAMENTO DE MATRÍCULA POR CURSO
+-- Cada matrícula (aluno x turma) pode ser encerrada sozinha: CANCELADO, TRANCADO ou CONCLUIDO, com o motivo, a
+-- data efetiva (data_fim) e o usuário que encerrou. A vaga volta apenas para o curso da turma encerrada e o aluno
+-- só é inativado quando não sobra nenhuma matrícula ATIVO.
+
+alter table public.matricula
+  add column motivo_encerramento text null,
+  add column encerrado_por integer null,
+  add constraint matricula_encerrado_por_fk foreign key (encerrado_por) references usuarios (id_usuario);
@@ -0,0 +1,42 @@
+-- CICLO DE VIDA DA MATRÍCULA E HISTÓRICO DE STATUS
+-- Status possíveis de uma matrícula (aluno x turma) e transições aceitas pelo enrollment-service:
+--   PRE_INSCRITO -> ATIVO, CANCELADO
+--   ATIVO        -> TRANCADO, TRANSFERIDO, CONCLUIDO, CANCELADO, EVADIDO
+--   TRANCADO     -> ATIVO, CANCELADO, EVADIDO
+--   TRANSFERIDO, CONCLUIDO, CANCELADO e EVADIDO são finais.
+-- Só matrículas ATIVO ocupam vaga. PRE_INSCRITO ainda não entra na lista de chamada.
+-- matricula_historico guarda cada transição (de/para, data efetiva, motivo e usuário); status_anterior nulo é a
+-- criação da matrícula.
+
+begin;
+
+alter table public.matricula
+  add constraint matricula_status_valido check (status in ('PRE_INSCRITO', 'ATIVO', 'TRANCADO', 'TRANSFERIDO', 'CONCLUIDO', 'CANCELADO', 'EVADIDO'));
+
+create table public.matricula_historico (
+  id_historico integer generated always as identity not null,
+  matricula_id_matricula integer not null,
+  status_anterior character varying(20) null,
+  status_novo character varying(20) not null,
+  data_efetiva date not null default CURRENT_DATE,
+  motivo text null,
+  usuario_id integer null,
+  registrado_em timestamp without time zone not null default now(),
+  constraint matricula_historico_pk primary key (id_historico),
+  constraint matricula_historico_matricula foreign KEY (matricula_id_matricula) references matricula (id_matricula) on delete cascade,
+  constraint matricula_historico_usuario foreign KEY (usuario_id) references usuarios (id_usuario)
+) TABLESPACE pg_default;
+
+create index IF not exists matricula_historico_idx_1 on public.matricula_historico using btree (matricula_id_matricula, registrado_em) TABLESPACE pg_default;
+
+-- Histórico inicial das matrículas existentes: a criação e, nas encerradas, o encerramento
+insert into public.matricula_historico (matricula_id_matricula, status_anterior, status_novo, data_efetiva, motivo, registrado_em)
+select id_matricula, null, 'ATIVO', data_inicio, 'Matrícula anterior ao histórico', data_inicio
+from public.matricula;
+
+insert into public.matricula_historico (matricula_id_matricula, status_anterior, status_novo, data_efetiva, motivo, usuario_id, registrado_em)
+select id_matricula, 'ATIVO', status, coalesce(data_fim, data_inicio), motivo_encerramento, encerrado_por, coalesce(data_fim, data_inicio)
+from public.matricula
+where status <> 'ATIVO';
+
+commit;
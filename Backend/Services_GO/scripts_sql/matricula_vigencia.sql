@@ -0,0 +1,45 @@
+-- VIGÊNCIA DAS MATRÍCULAS
+-- matricula passa a guardar o período em que o aluno esteve na turma: data_inicio (obrigatória) e data_fim
+-- (null = matrícula em andamento). A lista de chamada (chamadas-service) usa a vigência: o aluno só aparece, e só
+-- pode receber presença, nas aulas entre data_inicio e data_fim. Matrículas encerradas continuam visíveis nos
+-- meses em que estavam vigentes.
+
+begin;
+
+alter table public.matricula
+  add column data_inicio date null,
+  add column data_fim date null;
+
+-- 1. Início: a data da matrícula ou a primeira presença lançada na turma, o que vier antes
+update public.matricula m
+set data_inicio = least(
+  coalesce(m.data_matricula, a.data_matricula),
+  (select min(c.data_aula)
+   from public.presenca p
+   inner join public.chamada c on c.id_chamada = p.chamada_id_chamada
+   where p.aluno_id_aluno = m.aluno_id_aluno and c.turmas_id_turma = m.turmas_id_turma)
+)
+from public.aluno a
+where a.id_aluno = m.aluno_id_aluno;
+
+update public.matricula set data_inicio = CURRENT_DATE where data_inicio is null;
+
+-- 2. Fim das matrículas já encerradas: a última presença lançada na turma (ou o próprio início, sem presenças)
+update public.matricula m
+set data_fim = greatest(m.data_inicio, coalesce(
+  (select max(c.data_aula)
+   from public.presenca p
+   inner join public.chamada c on c.id_chamada = p.chamada_id_chamada
+   where p.aluno_id_aluno = m.aluno_id_aluno and c.turmas_id_turma = m.turmas_id_turma),
+  m.data_inicio))
+where m.status <> 'ATIVO';
+
+alter table public.matricula alter column data_inicio set not null;
+alter table public.matricula alter column data_inicio set default CURRENT_DATE;
+
+alter table public.matricula
+  add constraint matricula_vigencia check (data_fim is null or data_fim >= data_inicio);
+
+create index IF not exists matricula_idx_turma_vigencia on public.matricula using btree (turmas_id_turma, data_inicio, data_fim) TABLESPACE pg_default;
+
+commit;
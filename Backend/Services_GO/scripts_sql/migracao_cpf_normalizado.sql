@@ -0,0 +1,64 @@
+-- MIGRAÇÃO: CPF NORMALIZADO (APENAS DÍGITOS) EM ALUNO E RESPONSÁVEL
+-- O enrollment-service passa a gravar e buscar CPFs somente com dígitos.
+-- Este script converte os registros existentes ("123.456.789-09" -> "12345678909").
+
+begin;
+
+-- 1. Conferir alunos que colidiriam após a normalização.
+--    Se esta consulta retornar linhas, resolva os duplicados manualmente antes de continuar
+--    (a constraint aluno_cpf_key impede o UPDATE abaixo).
+select regexp_replace(cpf, '\D', '', 'g') as cpf_normalizado, array_agg(id_aluno order by id_aluno) as alunos
+from public.aluno
+group by 1
+having count(*) > 1;
+
+update public.aluno
+set cpf = regexp_replace(cpf, '\D', '', 'g')
+where cpf ~ '\D';
+
+-- 2. Responsáveis duplicados (mesmo CPF com e sem máscara) são unificados no menor id
+create temporary table responsavel_unificado on commit drop as
+select id_responsavel,
+       min(id_responsavel) over (partition by regexp_replace(cpf, '\D', '', 'g')) as id_mantido
+from public.responsavel;
+
+-- Remover vínculos que ficariam repetidos após a unificação
+delete from public.responsavel_aluno ra
+using responsavel_unificado u
+where ra.responsavel_id_responsavel = u.id_responsavel
+  and u.id_responsavel <> u.id_mantido
+  and exists (
+    select 1 from public.responsavel_aluno ra2
+    where ra2.responsavel_id_responsavel = u.id_mantido
+      and ra2.aluno_id_aluno = ra.aluno_id_aluno
+  );
+
+update public.responsavel_aluno ra
+set responsavel_id_responsavel = u.id_mantido
+from responsavel_unificado u
+where ra.responsavel_id_responsavel = u.id_responsavel
+  and u.id_responsavel <> u.id_mantido;
+
+delete from public.responsavel r
+using responsavel_unificado u
+where r.id_responsavel = u.id_responsavel
+  and u.id_responsavel <> u.id_mantido;
+
+update public.responsavel
+set cpf = regexp_replace(cpf, '\D', '', 'g')
+where cpf ~ '\D';
+
+-- 3. Garantir a forma canônica daqui em diante
+alter table public.aluno
+  add constraint aluno_cpf_digitos check (cpf ~ '^\d{11}$') not valid;
+
+alter table public.responsavel
+  add constraint responsavel_cpf_digitos check (cpf ~ '^\d{11}$') not valid;
+
+create unique index if not exists responsavel_cpf_key on public.responsavel using btree (cpf);
+
+commit;
+
+-- Opcional: após corrigir registros antigos com CPF incompleto, validar as constraints
+-- alter table public.aluno validate constraint aluno_cpf_digitos;
+-- alter table public.responsavel validate constraint responsavel_cpf_digitos;
@@ -0,0 +1,24 @@
+-- JANELA DE EDIÇÃO E FECHAMENTO MENSAL DAS CHAMADAS (chamadas-service)
+-- Sem registro, o mês da turma pode ser lançado/editado até CHAMADA_DIAS_EDICAO dias após o seu fim.
+-- FECHADO bloqueia o mês antes disso; REABERTO (somente administradores) libera a edição até reaberto_ate.
+
+create table public.periodo_chamada (
+  id_periodo integer generated always as identity not null,
+  turma_id_turma integer not null,
+  ano_mes character(6) not null,
+  situacao character varying(10) not null,
+  fechado_por integer null,
+  fechado_em timestamp without time zone null,
+  reaberto_por integer null,
+  reaberto_em timestamp without time zone null,
+  reaberto_ate date null,
+  motivo character varying(200) null,
+  constraint periodo_chamada_pk primary key (id_periodo),
+  constraint periodo_chamada_turma_mes_key unique (turma_id_turma, ano_mes),
+  constraint periodo_chamada_turma foreign KEY (turma_id_turma) references turma (id_turma) on delete cascade,
+  constraint periodo_chamada_fechado_por foreign KEY (fechado_por) references usuarios (id_usuario),
+  constraint periodo_chamada_reaberto_por foreign KEY (reaberto_por) references usuarios (id_usuario),
+  constraint periodo_chamada_situacao check (situacao in ('FECHADO', 'REABERTO')),
+  constraint periodo_chamada_ano_mes check (ano_mes ~ '^\d{4}(0[1-9]|1[0-2])$'),
+  constraint periodo_chamada_reabertura check (situacao <> 'REABERTO' or reaberto_ate is not null)
+) TABLESPACE pg_default;
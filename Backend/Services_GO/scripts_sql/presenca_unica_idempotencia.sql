@@ -0,0 +1,41 @@
+-- MIGRAÇÃO: UMA PRESENÇA POR ALUNO EM CADA CHAMADA + IDEMPOTÊNCIA DOS LANÇAMENTOS
+-- O lançamento de presenças passa a ser um único INSERT ... ON CONFLICT, que depende da constraint
+-- presenca_chamada_aluno_key. Reenvios com o mesmo header Idempotency-Key devolvem o resultado original.
+
+begin;
+
+-- 1. Conferir as presenças repetidas que serão removidas (mantém-se a lançada por último)
+select chamada_id_chamada, aluno_id_aluno, array_agg(id_presenca order by id_presenca) as presencas
+from public.presenca
+group by chamada_id_chamada, aluno_id_aluno
+having count(*) > 1;
+
+delete from public.presenca p
+using (
+  select id_presenca,
+         row_number() over (partition by chamada_id_chamada, aluno_id_aluno order by id_presenca desc) as ordem
+  from public.presenca
+) d
+where p.id_presenca = d.id_presenca
+  and d.ordem > 1;
+
+alter table public.presenca
+  add constraint presenca_chamada_aluno_key unique (chamada_id_chamada, aluno_id_aluno);
+
+-- 2. Chaves de idempotência (Idempotency-Key) dos lançamentos de presença.
+--    resultado fica nulo enquanto a requisição original está em andamento; chaves com falha são removidas.
+create table public.requisicao_idempotente (
+  chave character varying(100) not null,
+  operacao character varying(50) not null,
+  hash_corpo character(64) not null,
+  resultado text null,
+  criado_em timestamp without time zone not null default now(),
+  constraint requisicao_idempotente_pk primary key (chave, operacao)
+) TABLESPACE pg_default;
+
+create index IF not exists requisicao_idempotente_idx_1 on public.requisicao_idempotente using btree (criado_em) TABLESPACE pg_default;
+
+commit;
+
+-- Opcional (rotina de limpeza): chaves vencidas são reaproveitadas pelo serviço, mas podem ser apagadas
+-- delete from public.requisicao_idempotente where criado_em < now() - interval '1 day';
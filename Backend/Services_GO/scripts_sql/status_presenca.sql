@@ -0,0 +1,50 @@
+-- MIGRAÇÃO: STATUS DE PRESENÇA CONFIGURÁVEIS
+-- presenca.presente passa a aceitar apenas os códigos de status_presenca. A categoria define como o status
+-- entra na frequência (chamadas-service):
+--   PRESENTE    = conta como aula assistida e interrompe a sequência de faltas
+--   AUSENTE     = falta; conta como aula e aumenta a sequência de faltas
+--   JUSTIFICADO = falta justificada; conta como aula, mas não altera a sequência de faltas
+--   DISPENSADO  = não entra na frequência (nem como aula)
+-- Status inativos continuam válidos nos registros antigos, mas não podem ser lançados.
+-- Documentos de justificativa são enviados ao file-service com entidade_pai = 'presenca' e id_entidade_pai = id_presenca.
+
+begin;
+
+create table public.status_presenca (
+  codigo character varying(2) not null,
+  nome character varying(40) not null,
+  categoria character varying(12) not null,
+  ativo boolean not null default true,
+  ordem smallint not null default 0,
+  constraint status_presenca_pk primary key (codigo),
+  constraint status_presenca_codigo check (codigo <> '' and codigo = upper(btrim(codigo))),
+  constraint status_presenca_categoria check (categoria in ('PRESENTE', 'AUSENTE', 'JUSTIFICADO', 'DISPENSADO'))
+) TABLESPACE pg_default;
+
+insert into public.status_presenca (codigo, nome, categoria, ordem) values
+  ('P', 'Presente', 'PRESENTE', 1),
+  ('F', 'Falta', 'AUSENTE', 2),
+  ('J', 'Falta justificada', 'JUSTIFICADO', 3),
+  ('A', 'Atraso', 'PRESENTE', 4),
+  ('D', 'Dispensado', 'DISPENSADO', 5);
+
+-- 1. Normalizar os códigos já gravados: 'F ' (antigo default) -> 'F', 'FJ' -> 'J', vazio -> 'F'
+update public.presenca set presente = upper(btrim(presente)) where presente <> upper(btrim(presente));
+update public.presenca set presente = 'J' where presente = 'FJ';
+update public.presenca set presente = 'F' where presente = '';
+
+-- 2. Conferir códigos desconhecidos (a constraint abaixo falha enquanto existirem; corrija-os antes de continuar)
+select presente, count(*) as quantidade
+from public.presenca
+where presente not in (select codigo from public.status_presenca)
+group by presente;
+
+alter table public.presenca alter column presente drop default;
+
+alter table public.presenca
+  add constraint presenca_status foreign KEY (presente) references status_presenca (codigo);
+
+create index IF not exists anexos_idx_presenca on public.anexos using btree (id_entidade_pai) TABLESPACE pg_default
+  where entidade_pai = 'presenca';
+
+commit;
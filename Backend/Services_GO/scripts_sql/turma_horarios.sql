@@ -0,0 +1,50 @@
+-- MIGRAÇÃO: GRADE DE HORÁRIOS DA TURMA (VÁRIOS DIAS E HORÁRIOS POR SEMANA)
+-- A grade passa a ficar em turma_horario (um registro por encontro semanal).
+-- turma.dia_semana, hora_inicio e hora_fim continuam como resumo da grade, mantido pelo cursosturmas-service
+-- (ex: "Segunda-feira, Quarta-feira"), para telas e consultas que só exibem a turma.
+
+begin;
+
+create table public.turma_horario (
+  id_horario integer generated always as identity not null,
+  turma_id_turma integer not null,
+  dia_semana smallint not null, -- 0 = domingo ... 6 = sábado
+  hora_inicio time without time zone null,
+  hora_fim time without time zone null,
+  constraint turma_horario_pk primary key (id_horario),
+  constraint turma_horario_turma foreign KEY (turma_id_turma) references turma (id_turma) on delete cascade,
+  constraint turma_horario_dia check (dia_semana between 0 and 6),
+  constraint turma_horario_periodo check (hora_inicio is null or hora_fim is null or hora_fim > hora_inicio),
+  constraint turma_horario_unico unique (turma_id_turma, dia_semana, hora_inicio)
+) TABLESPACE pg_default;
+
+create index IF not exists turma_horario_idx_1 on public.turma_horario using btree (turma_id_turma) TABLESPACE pg_default;
+
+-- 1. Migrar o dia/horário atual de cada turma (aceita "Segunda-feira", "segunda", "Terça", "Sabado"...)
+insert into public.turma_horario (turma_id_turma, dia_semana, hora_inicio, hora_fim)
+select t.id_turma, d.dia, t.hora_inicio, t.hora_fim
+from public.turma t
+cross join lateral (
+  select case
+    when n like 'dom%' then 0
+    when n like 'seg%' then 1
+    when n like 'ter%' then 2
+    when n like 'qua%' then 3
+    when n like 'qui%' then 4
+    when n like 'sex%' then 5
+    when n like 'sab%' or n like 'sáb%' then 6
+  end as dia
+  from (select lower(trim(t.dia_semana)) as n) nome
+) d
+where d.dia is not null;
+
+-- 2. Conferir turmas que não puderam ser migradas (dia_semana fora do padrão).
+--    Cadastre a grade delas pela API (PUT /api/v1/turmas/:id com "horarios").
+select t.id_turma, t.nome_turma, t.dia_semana
+from public.turma t
+where not exists (select 1 from public.turma_horario h where h.turma_id_turma = t.id_turma);
+
+-- 3. O resumo de vários dias não cabe em 20 caracteres
+alter table public.turma alter column dia_semana type character varying(100);
+
+commit;
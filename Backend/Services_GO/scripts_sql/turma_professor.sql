@@ -0,0 +1,38 @@
+-- MIGRAÇÃO: PROFESSORES DA TURMA (TITULAR E SUBSTITUTOS, COM VIGÊNCIA)
+-- Mantida pelo cursosturmas-service (/turmas/:id/professores, só administradores). O chamadas-service só permite
+-- que professores (P) e operadores (U) lancem chamadas e presenças das turmas às quais estão atribuídos na data da
+-- aula; administradores (A) lançam em qualquer turma.
+-- Cada turma tem no máximo um TITULAR por vez (conferido pelo serviço); substitutos podem se sobrepor.
+
+begin;
+
+create table public.turma_professor (
+  id_atribuicao integer generated always as identity not null,
+  turma_id_turma integer not null,
+  usuario_id_usuario integer not null,
+  papel character varying(10) not null,
+  data_inicio date not null,
+  data_fim date null, -- nulo = até o fim da turma
+  criado_por integer null,
+  criado_em timestamp without time zone not null default now(),
+  constraint turma_professor_pk primary key (id_atribuicao),
+  constraint turma_professor_turma foreign KEY (turma_id_turma) references turma (id_turma) on delete cascade,
+  constraint turma_professor_usuario foreign KEY (usuario_id_usuario) references usuarios (id_usuario) on delete cascade,
+  constraint turma_professor_criado_por foreign KEY (criado_por) references usuarios (id_usuario),
+  constraint turma_professor_papel check (papel in ('TITULAR', 'SUBSTITUTO')),
+  constraint turma_professor_vigencia check (data_fim is null or data_fim >= data_inicio)
+) TABLESPACE pg_default;
+
+create index IF not exists turma_professor_idx_1 on public.turma_professor using btree (turma_id_turma) TABLESPACE pg_default;
+create index IF not exists turma_professor_idx_2 on public.turma_professor using btree (usuario_id_usuario) TABLESPACE pg_default;
+
+-- Mantém o acesso de quem já lançou chamadas (professores e operadores), como substituto durante o período da
+-- turma. Os demais precisam ser atribuídos por um administrador antes de lançar.
+insert into public.turma_professor (turma_id_turma, usuario_id_usuario, papel, data_inicio, data_fim)
+select distinct c.turmas_id_turma, c.users_id_usuario, 'SUBSTITUTO', t.data_inicio, t.data_fim
+from public.chamada c
+join public.turma t on t.id_turma = c.turmas_id_turma
+join public.usuarios u on u.id_usuario = c.users_id_usuario
+where u.tipo in ('P', 'U');
+
+commit;
@@ -0,0 +1,36 @@
+-- VAGAS CONTROLADAS POR TURMA
+-- A capacidade de verdade passa a ser turma.vagas_turma: o enrollment-service trava a turma (FOR UPDATE) e só
+-- matricula se as matrículas ATIVO couberem nas vagas. curso.vagas_totais e curso.vagas_restantes viram valores
+-- derivados (soma das turmas menos as matrículas ATIVO), recalculados pelos serviços a cada mudança
+-- (internal/shared/vagas) e pelo comando de reconciliação (cmd/vagas-reconcile: go run . [-dry-run]).
+--
+-- O trigger trg_atualiza_vagas_curso também mexia em vagas_restantes a cada insert/update/delete em matricula,
+-- somando-se aos ajustes feitos pelo código: as vagas eram contadas duas vezes. Ele sai.
+
+begin;
+
+drop trigger if exists trg_atualiza_vagas_curso on public.matricula;
+drop function if exists public.atualiza_vagas_curso();
+
+alter table public.turma
+  add constraint turma_vagas_positivas check (vagas_turma > 0) not valid;
+
+-- Recalcular as vagas de todos os cursos a partir das turmas (turmas já lotadas ficam com vagas_restantes
+-- negativas até a secretaria regularizar; o comando de reconciliação lista essas turmas)
+update public.curso c
+set vagas_totais = v.totais, vagas_restantes = v.restantes
+from (
+  select c2.id_curso,
+         coalesce(sum(t.vagas_turma), 0) as totais,
+         coalesce(sum(t.vagas_turma), 0) - coalesce(sum(o.ocupadas), 0) as restantes
+  from public.curso c2
+  left join public.turma t on t.cursos_id_curso = c2.id_curso
+  left join lateral (
+    select count(*) as ocupadas from public.matricula m
+    where m.turmas_id_turma = t.id_turma and m.status = 'ATIVO'
+  ) o on true
+  group by c2.id_curso
+) v
+where c.id_curso = v.id_curso;
+
+commit;
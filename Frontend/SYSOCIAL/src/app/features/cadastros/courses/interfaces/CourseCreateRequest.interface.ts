@@ -1,6 +1,7 @@
 export interface CourseCreateRequest {
   nome: string;
-  vagasTotais: number;
   ativo: boolean;
+  // Somente leitura: derivadas das vagas das turmas do curso
+  vagasTotais?: number;
   vagasRestantes?: number;
 }
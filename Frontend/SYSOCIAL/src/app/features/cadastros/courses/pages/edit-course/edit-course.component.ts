@@ -32,7 +32,6 @@ export class EditCourseComponent implements OnInit {
 
   model: CourseCreateRequest = {
     nome: '',
-    vagasTotais: 0,
     ativo: true,
   };
 
@@ -73,15 +72,8 @@ export class EditCourseComponent implements OnInit {
   }
 
   onSubmit() {
-    if (!this.model.nome || !this.model.vagasTotais) {
-      toast.error('Preencha os campos obrigatórios: nome e vagas totais.', {
-        position: 'bottom-center',
-      });
-      return;
-    }
-
-    if (this.model.vagasTotais <= 0) {
-      toast.error('Número de vagas deve ser maior que zero.', {
+    if (!this.model.nome) {
+      toast.error('Preencha o campo obrigatório: nome.', {
         position: 'bottom-center',
       });
       return;
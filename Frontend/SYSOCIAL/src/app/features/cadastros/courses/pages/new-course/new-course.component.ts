@@ -28,22 +28,15 @@ export class NewCourseComponent {
 
   loading = false;
 
+  // As vagas do curso são a soma das vagas das turmas cadastradas nele
   model: CourseCreateRequest = {
     nome: '',
-    vagasTotais: 0,
     ativo: true,
   };
 
   onSubmit() {
-    if (!this.model.nome || !this.model.vagasTotais) {
-      toast.error('Preencha os campos obrigatórios: nome e vagas totais.', {
-        position: 'bottom-center',
-      });
-      return;
-    }
-
-    if (this.model.vagasTotais <= 0) {
-      toast.error('Número de vagas deve ser maior que zero.', {
+    if (!this.model.nome) {
+      toast.error('Preencha o campo obrigatório: nome.', {
         position: 'bottom-center',
       });
       return;
@@ -77,7 +70,6 @@ export class NewCourseComponent {
   reset() {
     this.model = {
       nome: '',
-      vagasTotais: 0,
       ativo: true,
     };
 
@@ -56,14 +56,14 @@ import { CourseOption, ClassOption } from '../interfaces/enrollment.model';
                 <option value="" disabled selected class="text-gray-300">Selecione a turma...</option>
                 
                 <!-- LÓGICA DE VAGAS NA TURMA -->
-                <!-- Nota: Assume que 'spots' na ClassOption é a vaga restante da turma -->
+                <!-- availableSpots: vagas da turma menos as matrículas ativas -->
                 <option *ngFor="let classItem of getClassesFor(enrollment.get('courseId')?.value)" 
                         [value]="classItem.id.toString()"
-                        [disabled]="classItem.spots <= 0"
-                        [class.text-red-400]="classItem.spots <= 0"
+                        [disabled]="classItem.availableSpots <= 0"
+                        [class.text-red-400]="classItem.availableSpots <= 0"
                         class="text-gray-900">
                   {{ classItem.name }} - {{ classItem.dayOfWeek }} ({{ formatTime(classItem.startTime) }} às {{ formatTime(classItem.endTime) }})
-                  {{ classItem.spots <= 0 ? ' - LOTADO' : '' }}
+                  {{ classItem.availableSpots <= 0 ? ' - LOTADO' : '(' + classItem.availableSpots + ' vagas)' }}
                 </option>
               </select>
 
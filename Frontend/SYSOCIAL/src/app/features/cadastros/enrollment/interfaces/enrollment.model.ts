@@ -7,6 +7,7 @@ export interface ClassOption {
   startTime: string;
   endTime: string;
   spots: number;
+  availableSpots: number; // Vagas da turma menos as matrículas ativas
   description: string;
 }
 
@@ -48,6 +49,19 @@ export interface StudentFilter {
   course?: string;      // Busca por nome do curso
   class?: string;       // Busca por nome da turma
   courseShift?: string; // Busca por turno do curso
+  minAge?: number;
+  maxAge?: number;
+
+  // Paginação e ordenação (server-side)
+  sort?: 'name' | 'age' | 'enrollmentDate' | 'status';
+  order?: 'asc' | 'desc';
+  limit?: number;
+  offset?: number;
+}
+
+export interface StudentPage {
+  data: StudentSummary[];
+  pagination: { total: number; limit: number; offset: number };
 }
 
 // --- ENVIOS PARA O BACKEND DE MATRÍCULA (Porta 8084) ---
@@ -85,6 +99,85 @@ export interface GuardianPayload {
 export interface CourseEnrollmentPayload {
   courseId: string;
   classId: string;
+  matriculaId?: number; // Vem do backend na edição; identifica a matrícula nos encerramentos por curso
+}
+
+export type EndEnrollmentAction = 'cancel' | 'suspend' | 'complete';
+
+export type MatriculaStatus = 'PRE_INSCRITO' | 'ATIVO' | 'TRANCADO' | 'TRANSFERIDO' | 'CONCLUIDO' | 'CANCELADO' | 'EVADIDO';
+
+export interface StatusChangePayload extends EndEnrollmentPayload {
+  status: Exclude<MatriculaStatus, 'PRE_INSCRITO' | 'TRANSFERIDO'>;
+}
+
+export interface TransferPayload extends EndEnrollmentPayload {
+  targetClassId: number;
+}
+
+// Transição de status de uma matrícula na linha do tempo do aluno
+export interface EnrollmentEvent {
+  id: number;
+  matriculaId: number;
+  courseId: number;
+  courseName: string;
+  classId: number;
+  className: string;
+  fromStatus: MatriculaStatus | null; // null = criação da matrícula
+  toStatus: MatriculaStatus;
+  effectiveDate: string;
+  reason: string;
+  userId: number | null;
+  userName: string;
+  recordedAt: string;
+}
+
+export interface EndEnrollmentPayload {
+  reason: string;
+  effectiveDate?: string; // AAAA-MM-DD; vazio = hoje
+}
+
+export type WaitlistStatus = 'AGUARDANDO' | 'OFERTADA' | 'ACEITA' | 'RECUSADA' | 'EXPIRADA' | 'REMOVIDA';
+
+export interface JoinWaitlistPayload {
+  classId: number;
+  priority?: number;       // 0 = ordem de chegada; maior passa na frente (até 10)
+  priorityReason?: string; // Obrigatório quando priority > 0 (ex: vulnerabilidade social)
+  notes?: string;
+}
+
+// Entrada de um aluno na lista de espera de uma turma lotada
+export interface WaitlistEntry {
+  id: number;
+  studentId: number;
+  studentName: string;
+  courseId: number;
+  courseName: string;
+  classId: number;
+  className: string;
+  position: number | null; // Só para quem está AGUARDANDO
+  priority: number;
+  priorityReason: string;
+  notes: string;
+  status: WaitlistStatus;
+  joinedAt: string;
+  offeredAt: string | null;
+  offerExpiresAt: string | null; // Prazo para aceitar a vaga oferecida
+  answeredAt: string | null;
+  matriculaId: number | null;
+}
+
+// Encontro semanal sobreposto, devolvido no 409 ao matricular/transferir (type TURNO = turno escolar do aluno)
+export interface ScheduleConflict {
+  type: 'TURMA' | 'TURNO';
+  classId: number;
+  className: string;
+  conflictingClassId: number; // 0 quando o conflito é com o turno escolar
+  conflictingClassName: string;
+  dayOfWeek: string;
+  startTime: string;
+  endTime: string;
+  conflictingStartTime: string;
+  conflictingEndTime: string;
 }
 
 export interface DocumentPayload {
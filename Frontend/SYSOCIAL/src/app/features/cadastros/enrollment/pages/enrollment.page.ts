@@ -11,7 +11,7 @@ import { StudentFormComponent } from '../components/student-form.component';
 import { GuardianListComponent } from '../components/guardian-list.component';
 import { AcademicFormComponent } from '../components/academic-form.component';
 import { DocumentsFormComponent } from '../components/documents-form.component';
-import { CourseOption, EnrollmentPayload, FileUploadRequest } from '../interfaces/enrollment.model';
+import { CourseOption, EnrollmentPayload, FileUploadRequest, ScheduleConflict } from '../interfaces/enrollment.model';
 
 @Component({
   selector: 'app-enrollment-page',
@@ -179,7 +179,7 @@ export class EnrollmentPage implements OnInit {
         }
 
         if (data.student && data.student.schoolShift) {
-          this.service.getAvailableCourses(data.student.schoolShift).subscribe({
+          this.service.getAvailableCourses(data.student.schoolShift, this.enrollmentId).subscribe({
             next: (coursesOptions) => {
               this.availableCourses = coursesOptions || [];
               const coursesArray = this.enrollmentsArray;
@@ -233,7 +233,7 @@ export class EnrollmentPage implements OnInit {
     const enrollments = this.enrollmentsArray;
     while (enrollments.length !== 0) { enrollments.removeAt(0); }
     this.addCourse(); 
-    this.service.getAvailableCourses(shift).subscribe({
+    this.service.getAvailableCourses(shift, this.enrollmentId).subscribe({
       next: (data) => { this.availableCourses = data || []; },
       error: (err) => { console.error(err); alert('Erro ao carregar cursos.'); }
     });
@@ -368,6 +368,14 @@ export class EnrollmentPage implements OnInit {
         }
       }
       this.router.navigate(['/cadastros/student-list']);
-    } catch (error) { console.error(error); alert('Erro ao salvar.'); } finally { this.isSubmitting = false; this.submittingMessage = 'Salvar Matrícula'; }
+    } catch (error: any) {
+      console.error(error);
+      const conflicts: ScheduleConflict[] = error?.error?.conflicts || [];
+      if (conflicts.length > 0) {
+        alert('Conflito de horário:\n' + conflicts.map(c => `${c.className} (${c.dayOfWeek} ${c.startTime}-${c.endTime}) x ${c.conflictingClassName} (${c.conflictingStartTime}-${c.conflictingEndTime})`).join('\n'));
+      } else {
+        alert('Erro ao salvar.');
+      }
+    } finally { this.isSubmitting = false; this.submittingMessage = 'Salvar Matrícula'; }
   }
 }
\ No newline at end of file
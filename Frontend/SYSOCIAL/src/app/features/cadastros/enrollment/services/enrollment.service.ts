@@ -2,7 +2,7 @@ import { Injectable, inject } from '@angular/core';
 import { HttpClient, HttpParams } from '@angular/common/http';
 import { Observable, lastValueFrom, of } from 'rxjs';
 import { map, catchError } from 'rxjs/operators';
-import { CourseOption, EnrollmentPayload, FileUploadRequest, StudentSummary, StudentFilter, GuardianPayload } from '../interfaces/enrollment.model';
+import { CourseOption, EndEnrollmentAction, EndEnrollmentPayload, EnrollmentEvent, EnrollmentPayload, StatusChangePayload, TransferPayload, JoinWaitlistPayload, WaitlistEntry, FileUploadRequest, StudentPage, StudentFilter, GuardianPayload } from '../interfaces/enrollment.model';
 import { environment } from 'src/environments/environment';
 
 export interface StudentListState {
@@ -29,8 +29,10 @@ export class EnrollmentService {
 
   // --- Enrollment API (8084) ---
 
-  getAvailableCourses(shift: string): Observable<CourseOption[]> {
-    const params = new HttpParams().set('shift', shift);
+  // studentId (edição): esconde as turmas que conflitam com as turmas ativas do aluno
+  getAvailableCourses(shift: string, studentId?: number | null): Observable<CourseOption[]> {
+    let params = new HttpParams().set('shift', shift);
+    if (studentId) params = params.set('studentId', studentId);
     return this.http.get<CourseOption[]>(`${this.ENROLLMENT_API_URL}/available-courses`, { params });
   }
 
@@ -64,7 +66,53 @@ export class EnrollmentService {
     return this.http.patch(`${this.ENROLLMENT_API_URL}/${id}/cancel`, {});
   }
 
-  searchStudents(filters: StudentFilter): Observable<StudentSummary[]> {
+  // Cancela, tranca ou conclui só um curso do aluno
+  endCourseEnrollment(id: number, matriculaId: number, action: EndEnrollmentAction, payload: EndEnrollmentPayload): Observable<any> {
+    return this.http.patch(`${this.ENROLLMENT_API_URL}/${id}/courses/${matriculaId}/${action}`, payload);
+  }
+
+  changeCourseStatus(id: number, matriculaId: number, payload: StatusChangePayload): Observable<any> {
+    return this.http.patch(`${this.ENROLLMENT_API_URL}/${id}/courses/${matriculaId}/status`, payload);
+  }
+
+  // Move o aluno para outra turma: a matrícula atual fica como TRANSFERIDO e a nova começa na data efetiva
+  transferCourse(id: number, matriculaId: number, payload: TransferPayload): Observable<any> {
+    return this.http.post(`${this.ENROLLMENT_API_URL}/${id}/courses/${matriculaId}/transfer`, payload);
+  }
+
+  getEnrollmentTimeline(id: number): Observable<EnrollmentEvent[]> {
+    return this.http.get<{ data: EnrollmentEvent[] }>(`${this.ENROLLMENT_API_URL}/${id}/timeline`).pipe(map(r => r.data));
+  }
+
+  getClassWaitlist(classId: number): Observable<WaitlistEntry[]> {
+    return this.http.get<{ data: WaitlistEntry[] }>(`${this.ENROLLMENT_API_URL}/waitlist/classes/${classId}`).pipe(map(r => r.data));
+  }
+
+  getPendingWaitlistOffers(): Observable<WaitlistEntry[]> {
+    return this.http.get<{ data: WaitlistEntry[] }>(`${this.ENROLLMENT_API_URL}/waitlist/offers`).pipe(map(r => r.data));
+  }
+
+  getStudentWaitlist(id: number): Observable<WaitlistEntry[]> {
+    return this.http.get<{ data: WaitlistEntry[] }>(`${this.ENROLLMENT_API_URL}/${id}/waitlist`).pipe(map(r => r.data));
+  }
+
+  joinWaitlist(id: number, payload: JoinWaitlistPayload): Observable<WaitlistEntry> {
+    return this.http.post<{ data: WaitlistEntry }>(`${this.ENROLLMENT_API_URL}/${id}/waitlist`, payload).pipe(map(r => r.data));
+  }
+
+  leaveWaitlist(id: number, entryId: number): Observable<any> {
+    return this.http.delete(`${this.ENROLLMENT_API_URL}/${id}/waitlist/${entryId}`);
+  }
+
+  acceptWaitlistOffer(id: number, entryId: number): Observable<any> {
+    return this.http.post(`${this.ENROLLMENT_API_URL}/${id}/waitlist/${entryId}/accept`, {});
+  }
+
+  declineWaitlistOffer(id: number, entryId: number): Observable<any> {
+    return this.http.post(`${this.ENROLLMENT_API_URL}/${id}/waitlist/${entryId}/decline`, {});
+  }
+
+  searchStudents(filters: StudentFilter): Observable<StudentPage> {
     let params = new HttpParams();
     if (filters.name) params = params.set('name', filters.name);
     if (filters.cpf) params = params.set('cpf', filters.cpf.replace(/\D/g, ''));
@@ -75,7 +123,13 @@ export class EnrollmentService {
     if (filters.schoolShift) params = params.set('schoolShift', filters.schoolShift);
     if (filters.course) params = params.set('course', filters.course);
     if (filters.class) params = params.set('class', filters.class);
-    return this.http.get<StudentSummary[]>(`${this.ENROLLMENT_API_URL}/students`, { params });
+    if (filters.minAge) params = params.set('minAge', filters.minAge.toString());
+    if (filters.maxAge) params = params.set('maxAge', filters.maxAge.toString());
+    if (filters.sort) params = params.set('sort', filters.sort);
+    if (filters.order) params = params.set('order', filters.order);
+    if (filters.limit) params = params.set('limit', filters.limit.toString());
+    if (filters.offset) params = params.set('offset', filters.offset.toString());
+    return this.http.get<StudentPage>(`${this.ENROLLMENT_API_URL}/students`, { params });
   }
 
   // --- File API (8083) ---
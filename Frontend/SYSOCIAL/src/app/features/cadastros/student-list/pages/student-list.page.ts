@@ -61,7 +61,6 @@ export class StudentListPage implements OnInit {
   private service = inject(EnrollmentService);
   private router = inject(Router);
 
-  allFilteredStudents: StudentSummary[] = [];
   paginatedStudents: StudentSummary[] = [];
   
   totalStudents = 0;
@@ -90,19 +89,24 @@ export class StudentListPage implements OnInit {
 
   loadStudents(filters: StudentFilter, resetPage: boolean) {
     this.isLoading = true;
-    
-    this.service.searchStudents(filters).subscribe({
-      next: (data) => {
-        this.allFilteredStudents = data;
-        this.totalStudents = data.length;
-        
-        if (resetPage) {
-          this.currentPage = 1;
-        }
+
+    if (resetPage) {
+      this.currentPage = 1;
+    }
+
+    // Paginação feita no backend: envia apenas a página atual
+    const pageFilters: StudentFilter = {
+      ...filters,
+      limit: this.pageSize,
+      offset: (this.currentPage - 1) * this.pageSize
+    };
+
+    this.service.searchStudents(pageFilters).subscribe({
+      next: (page) => {
+        this.paginatedStudents = page.data;
+        this.totalStudents = page.pagination.total;
 
         this.saveState();
-        
-        this.updatePaginatedList();
         this.isLoading = false;
       },
       error: (err) => {
@@ -114,14 +118,7 @@ export class StudentListPage implements OnInit {
 
   onPageChange(newPage: number) {
     this.currentPage = newPage;
-    this.updatePaginatedList();
-    this.saveState();
-  }
-
-  updatePaginatedList() {
-    const start = (this.currentPage - 1) * this.pageSize;
-    const end = start + this.pageSize;
-    this.paginatedStudents = this.allFilteredStudents.slice(start, end);
+    this.loadStudents(this.currentFilters, false);
   }
 
   saveState() {
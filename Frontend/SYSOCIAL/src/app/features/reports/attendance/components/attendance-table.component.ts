@@ -50,7 +50,12 @@ import { StudentAttendance, AttendanceRecord, AttendanceGrid } from '../interfac
               </td>
 
               <td *ngFor="let date of getVisibleDates()" class="p-2 text-center border-r border-gray-100">
-                <ng-container *ngIf="getRecord(student, date) as record">
+                <div *ngIf="isOutsideEnrollment(student, date)"
+                     class="w-10 h-10 mx-auto rounded bg-gray-50 border border-dashed border-gray-200 flex items-center justify-center text-gray-300 text-sm"
+                     title="Fora do período de matrícula">
+                  —
+                </div>
+                <ng-container *ngIf="!isOutsideEnrollment(student, date) && getRecord(student, date) as record">
                   
                   <div *ngIf="record.status === 'FJ'" 
                        class="w-10 h-10 mx-auto rounded bg-yellow-50 border border-yellow-200 flex items-center justify-center cursor-help group/tooltip relative"
@@ -156,6 +161,10 @@ export class AttendanceTableComponent implements OnChanges {
   }
 
   // --- Helpers Visuais ---
+  isOutsideEnrollment(student: StudentAttendance, date: string): boolean {
+    return !!student.outsideEnrollment && !!student.outsideEnrollment[date];
+  }
+
   isEmpty(status: string): boolean {
     return status === '___EMPTY___';
   }
@@ -35,9 +35,16 @@ export interface DataChamadaDTO {
 // Resposta completa da matriz de chamadas
 export interface AttendanceResponseDTO {
   datas: DataChamadaDTO[]; 
+  periodo?: {
+    situacao: string;    // ABERTO, FECHADO ou REABERTO
+    editavel: boolean;
+    editavelAte: string | null;
+    motivo?: string;
+  };
   alunos: {
     alunoId: number;
     alunoNome: string;
+    matriculas?: { dataInicio: string; dataFim: string | null }[]; // Períodos de matrícula na turma no mês
     presencas: {
       [date: string]: {
         present: string;
@@ -78,12 +85,14 @@ export interface AttendanceGrid {
   dates: string[]; // Lista de datas para colunas da tabela
   students: StudentAttendance[]; // Dados dos alunos
   dateIdMap: { [date: string]: number }; // Data (YYYY-MM-DD) -> ID da Chamada
+  lockedReason?: string; // Preenchido quando o mês está fechado para edição
 }
 
 export interface StudentAttendance {
   studentId: number;
   studentName: string;
   attendance: { [date: string]: AttendanceRecord };
+  outsideEnrollment?: { [date: string]: boolean }; // Datas em que o aluno não estava matriculado na turma
   stats: {
     presents: number;
     absences: number;
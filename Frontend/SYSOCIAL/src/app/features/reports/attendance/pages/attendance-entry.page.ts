@@ -116,6 +116,11 @@ export class AttendanceEntryPage implements OnInit {
         return;
     }
     
+    if (this.gridData.lockedReason) {
+        alert(`Chamadas deste mês não podem ser alteradas: ${this.gridData.lockedReason}.`);
+        return;
+    }
+
     if (this.changedDates.size === 0) {
         alert('Nenhuma alteração para salvar.');
         return;
@@ -126,12 +131,13 @@ export class AttendanceEntryPage implements OnInit {
     const requests = Array.from(this.changedDates).map(date => {
         const callId = this.gridData!.dateIdMap[date];
         
-        if (!callId) {
-            console.warn(`ID da chamada não encontrado para a data ${date}`);
-            return null; 
+        if (!callId && !this.currentFilter?.classId) {
+            console.warn(`Turma não selecionada para abrir a chamada de ${date}`);
+            return null;
         }
 
-        return this.service.saveAttendanceForCall(
+        return this.service.saveAttendance(
+            this.currentFilter!.classId!,
             callId, 
             this.gridData!.students,
             date
@@ -156,6 +156,11 @@ export class AttendanceManagerPage implements OnInit {
 
   saveAll() {
     if (!this.gridData || !this.gridData.dateIdMap) return;
+    if (this.gridData.lockedReason) {
+        alert(`Chamadas deste mês não podem ser alteradas: ${this.gridData.lockedReason}.`);
+        return;
+    }
+
     if (this.changedDates.size === 0) {
         alert('Nenhuma alteração para salvar.');
         return;
@@ -166,12 +171,13 @@ export class AttendanceManagerPage implements OnInit {
     const requests = Array.from(this.changedDates).map(date => {
         const callId = this.gridData!.dateIdMap[date];
         
-        if (!callId) {
-            console.warn(`[Save] ID de chamada não encontrado para ${date}`);
+        if (!callId && !this.currentFilter?.classId) {
+            console.warn(`Turma não selecionada para abrir a chamada de ${date}`);
             return null;
         }
 
-        return this.service.saveAttendanceForCall(
+        return this.service.saveAttendance(
+            this.currentFilter!.classId!,
             callId, 
             this.gridData!.students,
             date
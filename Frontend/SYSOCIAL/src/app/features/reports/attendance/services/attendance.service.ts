@@ -1,7 +1,7 @@
 import { Injectable, inject } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
-import { Observable, of } from 'rxjs';
-import { map, catchError } from 'rxjs/operators';
+import { Observable, of, throwError, timer } from 'rxjs';
+import { map, catchError, switchMap, retry } from 'rxjs/operators';
 import { 
   AttendanceGrid, ClassOption, CourseOption, 
   AttendanceResponseDTO, UpsertPresencasPayload,
@@ -116,14 +116,15 @@ export class AttendanceService {
           const apiRecord = aluno.presencas[dateKey];
           const cleanKey = dateKey.split('T')[0];
 
-          if (!dateIdMap[cleanKey]) return;
+          if (!(cleanKey in dateIdMap)) return; // id 0 = chamada ainda não aberta, mas a data é exibida
 
           let status = 'F'; 
           const rawPresent = String(apiRecord.present).toUpperCase().trim();
           
           if (['P', 'TRUE', 'T', '1', 'S'].includes(rawPresent)) status = 'P';
           else if (['F', 'FALSE', '0', 'N'].includes(rawPresent)) status = 'F';
-          else if (rawPresent === 'FJ') status = 'FJ';
+          else if (['FJ', 'J'].includes(rawPresent)) status = 'FJ';
+          else if (rawPresent) status = rawPresent; // demais status configurados (ex: A = atraso, D = dispensado)
           
           if (rawPresent === '') status = '___EMPTY___';
 
@@ -134,6 +135,15 @@ export class AttendanceService {
         });
       }
 
+      // Datas fora dos períodos de matrícula do aluno na turma (não recebem presença)
+      const outsideEnrollment: { [date: string]: boolean } = {};
+      if (aluno.matriculas) {
+        dates.forEach(date => {
+          const enrolled = aluno.matriculas!.some(m => m.dataInicio <= date && (!m.dataFim || m.dataFim >= date));
+          if (!enrolled) outsideEnrollment[date] = true;
+        });
+      }
+
       // Estatísticas (Recalculadas apenas com as datas visíveis)
       const records = Object.values(attendanceMap);
       const stats = {
@@ -146,17 +156,40 @@ export class AttendanceService {
         studentId: aluno.alunoId,
         studentName: aluno.alunoNome,
         attendance: attendanceMap,
+        outsideEnrollment: outsideEnrollment,
         stats: stats
       };
     });
 
-    return { dates, students, dateIdMap };
+    const lockedReason = apiData.periodo && !apiData.periodo.editavel
+      ? (apiData.periodo.motivo || 'mês fechado para edição')
+      : undefined;
+
+    return { dates, students, dateIdMap, lockedReason };
   }
 
   // --- SALVAMENTO ---
 
+  // Abre a chamada da turma na data (a consulta da matriz não cria chamadas); se já existir, devolve a existente
+  openCall(classId: number, date: string): Observable<number> {
+    return this.http.post<{ id: number }>(`${this.API_URL}/chamadas/sessoes`, { turmaId: classId, dataAula: date }).pipe(
+      map(response => response.id)
+    );
+  }
+
+  // Datas ainda sem chamada (id 0) têm a chamada aberta antes de gravar as presenças
+  saveAttendance(classId: number, callId: number, records: StudentAttendance[], date: string): Observable<any> {
+    if (callId) {
+      return this.saveAttendanceForCall(callId, records, date);
+    }
+    return this.openCall(classId, date).pipe(
+      switchMap(id => this.saveAttendanceForCall(id, records, date))
+    );
+  }
+
   saveAttendanceForCall(callId: number, records: StudentAttendance[], date: string): Observable<any> {
-    const payloadRecords = records.map(student => {
+    // Alunos fora do período de matrícula na data não recebem presença (o backend recusa o lote inteiro)
+    const payloadRecords = records.filter(student => !student.outsideEnrollment?.[date]).map(student => {
       const record = student.attendance[date];
       
       let safeObs = '';
@@ -180,12 +213,24 @@ export class AttendanceService {
       };
     });
 
+    if (payloadRecords.length === 0) return of(null); // Ninguém matriculado na data
+
     const payload: UpsertPresencasPayload = {
       chamadaId: callId,
       records: payloadRecords
     };
 
-    return this.http.post(`${this.API_URL}/presencas/turma`, payload);
+    // A mesma chave em todas as tentativas: se a conexão cair depois de gravar, o reenvio devolve o resultado original
+    const idempotencyKey = crypto.randomUUID();
+    return this.http.post(`${this.API_URL}/presencas/turma`, payload, {
+      headers: { 'Idempotency-Key': idempotencyKey }
+    }).pipe(
+      // Só repete falhas de rede (0), requisição ainda em andamento (409) e erros do servidor
+      retry({
+        count: 2,
+        delay: err => (err.status === 0 || err.status === 409 || err.status >= 500) ? timer(1000) : throwError(() => err)
+      })
+    );
   }
 
   // --- HELPERS ---